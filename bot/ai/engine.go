@@ -8,6 +8,8 @@ import (
 	"sort"
 	"sync"
 	"time"
+
+	"github.com/luno/luno-bot/bot/indicators"
 )
 
 // AnalysisResult contains AI-enhanced market analysis
@@ -698,22 +700,14 @@ func calculateVolatility(candles []OHLCData) float64 {
 	}
 	
 	// Calculate average true range (ATR) as volatility measure
-	var atr float64
-	
-	for i := n - 14; i < n; i++ {
-		trueRange := math.Max(
-			candles[i].High-candles[i].Low,
-			math.Max(
-				math.Abs(candles[i].High-candles[i-1].Close),
-				math.Abs(candles[i].Low-candles[i-1].Close),
-			),
-		)
-		atr += trueRange / candles[i].Close // Normalize by price
+	atr := indicators.NewATR(14)
+	for _, c := range candles {
+		atr.Update(c.High, c.Low, c.Close)
 	}
-	atr /= 14
+	normATR := atr.Value() / candles[n-1].Close // Normalize by price
 	
 	// Normalize to 0-1 range (ATR of 2% -> 0.5)
-	return math.Max(0, math.Min(1, atr/0.04))
+	return math.Max(0, math.Min(1, normATR/0.04))
 }
 
 // Trend strength helper
@@ -724,20 +718,14 @@ func calculateTrendStrength(candles []OHLCData) float64 {
 	}
 	
 	// Simple moving averages
-	var sma10, sma20 float64
-	
-	for i := n - 10; i < n; i++ {
-		sma10 += candles[i].Close
-	}
-	sma10 /= 10
-	
-	for i := n - 20; i < n; i++ {
-		sma20 += candles[i].Close
+	sma10, sma20 := indicators.NewSMA(10), indicators.NewSMA(20)
+	for _, c := range candles[n-20:] {
+		sma10.Update(c.Close)
+		sma20.Update(c.Close)
 	}
-	sma20 /= 20
 	
 	// Measure alignment of SMAs
-	diff := (sma10 - sma20) / sma20
+	diff := (sma10.Value() - sma20.Value()) / sma20.Value()
 	
 	// Normalize to 0-1 range
 	return math.Max(0, math.Min(1, (diff+0.03)/0.06))
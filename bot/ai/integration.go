@@ -1,6 +1,7 @@
 package ai
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"math"
@@ -9,6 +10,7 @@ import (
 	"time"
 
 	"github.com/luno/luno-bot/bot"
+	"github.com/luno/luno-bot/config"
 )
 
 // CandleData represents OHLC price data
@@ -58,6 +60,14 @@ type AIController struct {
 	Strategy   bot.Strategy
 	Executor   bot.Executor
 	Logger     *log.Logger
+
+	// MarketExecutor places orders requested without a price; nil refuses them
+	MarketExecutor bot.Executor
+	// TradeConfig carries limits and accounts for AI orders when there are
+	// no per-market settings
+	TradeConfig bot.Config
+
+	markets *config.Config // per-market limits and accounts, from the bot's config
 }
 
 // NewAIController creates a new AI controller
@@ -112,6 +122,10 @@ func NewAIController(
 		Executor:   executor,
 		Logger:     aiLogger,
 	}
+	if c, ok := cfg.(*config.Config); ok && c != nil {
+		controller.TradeConfig = bot.ConfigFromStore(c)
+		controller.markets = c
+	}
 	
 	// Set up integration points
 	controller.setupIntegration()
@@ -294,21 +308,39 @@ func (c *AIController) executeBacktest(params map[string]float64) StrategyPerfor
 	}
 }
 
-// executeOrder places an order via the Executor
+// executeOrder places an AI order requested without a price (price 0) as a
+// market order through the MarketExecutor, at the AI's volume and checked
+// against the current touch. Priced orders are only logged.
 func (c *AIController) executeOrder(pair string, side string, volume float64, price float64) error {
-	if c.Executor == nil {
-		return fmt.Errorf("executor not available")
+	c.Logger.Printf("AI order requested: %s %.6f %s @ %.2f", 
+		side, volume, pair, price)
+	if price != 0 {
+		return nil
+	}
+	if c.MarketExecutor == nil || c.LunoClient == nil {
+		return fmt.Errorf("market orders not available")
+	}
+	ctx := context.Background()
+	// risk checks in the chain need the current touch
+	md, err := bot.NewRESTMarketData(c.LunoClient).MarketData(ctx, pair)
+	if err != nil {
+		return err
 	}
 	
-	// Format volume to Luno precision
-	formattedVolume := fmt.Sprintf("%.6f", volume)
-	
-	// Log the order (simulated execution for now)
-	c.Logger.Printf("AI order requested: %s %s %s @ %.2f", 
-		side, formattedVolume, pair, price)
-	
-	// In a real implementation, would call the executor with proper parameters
-	// This is a simplified version that just logs and returns success
+	sig := bot.SignalBuy
+	if side == "sell" {
+		sig = bot.SignalSell
+	}
+	cfg := c.TradeConfig
+	if c.markets != nil {
+		cfg = bot.ConfigFromStore(c.markets.ForPair(pair))
+	}
+	cfg.Pair = pair
+	cfg.StakeSize = volume
+	cfg.OrderMode = "market"
+	if err := c.MarketExecutor.Execute(ctx, sig, md, cfg); err != nil {
+		return fmt.Errorf("execute %s %s: %w", side, pair, err)
+	}
 	return nil
 }
 
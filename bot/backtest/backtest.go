@@ -0,0 +1,262 @@
+// Package backtest replays historical candles or trades through a bot.Strategy
+// and the live executor chain against a simulated Exchange.
+package backtest
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/luno/luno-bot/bot"
+	luno "github.com/luno/luno-go"
+)
+
+// Execution selects the executor placed above sizing in the chain.
+const (
+	ExecDirect = "direct"
+	ExecTWAP   = "twap"
+	ExecVWAP   = "vwap"
+)
+
+// Options configures a backtest run.
+type Options struct {
+	FeeRate        float64           // fee per side for the default MidFill model; defaults to cfg.TakerFee
+	Fills          bot.FillModel     // defaults to MidFill at FeeRate
+	InitialCounter float64           // starting counter balance; defaults to cfg.InitialEquity
+	Sizer          bot.PositionSizer // defaults to FixedSizer
+	Execution      string            // ExecDirect, ExecTWAP or ExecVWAP
+	Slices         int               // TWAP/VWAP slices; slices run back to back on the same bar
+	BarInterval    time.Duration     // candle length passed to strategies; zero for trade ticks
+}
+
+// PnLPoint is cumulative realized PnL at a bar.
+type PnLPoint struct {
+	Time time.Time `json:"time"`
+	PnL  float64   `json:"pnl"`
+}
+
+// DrawdownPoint is the drop from peak realized PnL at a bar.
+type DrawdownPoint struct {
+	Time     time.Time `json:"time"`
+	Drawdown float64   `json:"drawdown"`
+}
+
+// Report summarises a backtest run. Trades are round trips from flat to flat.
+type Report struct {
+	Trades          int             `json:"trades"`
+	Wins            int             `json:"wins"`
+	Losses          int             `json:"losses"`
+	WinRate         float64         `json:"win_rate"`
+	TotalPnL        float64         `json:"total_pnl"`
+	AvgPnL          float64         `json:"avg_pnl"`
+	Sharpe          float64         `json:"sharpe"`
+	MaxDrawdown     float64         `json:"max_drawdown"`
+	Fees            float64         `json:"fees"`
+	FinalEquity     float64         `json:"final_equity"`
+	Errors          int             `json:"errors"`
+	LastError       string          `json:"last_error,omitempty"`
+	PnLHistory      []PnLPoint      `json:"pnl_history"`
+	DrawdownHistory []DrawdownPoint `json:"drawdown_history"`
+	Fills           []Fill          `json:"fills"`
+	TradePnLs       []float64       `json:"trade_pnls"`
+}
+
+// Runner replays bars through a strategy and executor wired to Exchange.
+// Strategies receive each whole bar as a bot.MarketEvent quoted at the fill
+// model's touch; Interval is the bar length, zero for trade ticks.
+type Runner struct {
+	Strategy bot.Strategy
+	Executor bot.Executor
+	Exchange *Exchange
+	Config   bot.Config
+	Interval time.Duration
+}
+
+// NewRunner constructs a Runner; exec must place its orders on ex.
+func NewRunner(strategy bot.Strategy, exec bot.Executor, ex *Exchange, cfg bot.Config) *Runner {
+	return &Runner{Strategy: strategy, Executor: exec, Exchange: ex, Config: cfg}
+}
+
+// NewExecutor builds the live chain on ex: LunoExecutor, optionally TWAP or
+// VWAP, and SizingExecutor sizing from the exchange balances. Slices are not
+// spaced out in simulated time.
+func NewExecutor(ex *Exchange, opts Options) (bot.Executor, error) {
+	inner := bot.NewLunoExecutor(ex)
+	inner.FillTimeout = 0 // resting orders fill on later bars
+	sizer := opts.Sizer
+	if sizer == nil {
+		sizer = &bot.FixedSizer{}
+	}
+	var exec bot.Executor = inner
+	switch opts.Execution {
+	case "", ExecDirect:
+	case ExecTWAP:
+		exec = bot.NewTWAPExecutor(exec, opts.Slices, 0)
+	case ExecVWAP:
+		exec = bot.NewVWAPExecutor(exec, ex, opts.Slices, 0, nil)
+	default:
+		return nil, fmt.Errorf("unknown execution %q", opts.Execution)
+	}
+	sizing := bot.NewSizingExecutor(exec, sizer)
+	sizing.Equity = bot.BalanceEquity{Balances: ex}
+	return sizing, nil
+}
+
+// Run replays bars through strategy on a fresh Exchange using the chain from NewExecutor.
+func Run(ctx context.Context, strategy bot.Strategy, cfg bot.Config, bars []Bar, opts Options) (*Report, error) {
+	counter := opts.InitialCounter
+	if counter == 0 {
+		counter = cfg.InitialEquity
+	}
+	fills := opts.Fills
+	if fills == nil {
+		fee := opts.FeeRate
+		if fee == 0 {
+			fee = cfg.TakerFee
+		}
+		fills = &bot.MidFill{FeeRate: fee}
+	}
+	// strategies see the round-trip cost of the fees being charged
+	if fm, ok := fills.(bot.FeeModel); ok {
+		f := fm.Fees()
+		cfg.MakerFee, cfg.TakerFee = f.Maker, f.Taker
+	}
+	ex := NewExchange(cfg.Pair, counter, fills)
+	exec, err := NewExecutor(ex, opts)
+	if err != nil {
+		return nil, err
+	}
+	r := NewRunner(strategy, exec, ex, cfg)
+	r.Interval = opts.BarInterval
+	return r.Run(ctx, bars)
+}
+
+// Run feeds each bar to the exchange, strategy and executor in turn.
+// Executor errors are counted in the report rather than stopping the run.
+func (r *Runner) Run(ctx context.Context, bars []Bar) (*Report, error) {
+	rep := &Report{
+		PnLHistory:      make([]PnLPoint, 0, len(bars)),
+		DrawdownHistory: make([]DrawdownPoint, 0, len(bars)),
+	}
+	var led ledger
+	var peak float64
+	for _, b := range bars {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		r.Exchange.Advance(b)
+		bid, ask := r.Exchange.Touch()
+		md := bot.MarketData{Bid: bid, Ask: ask, Last: b.Close, Timestamp: b.Time}
+		ev := bot.MarketEvent{MarketData: md, Bar: b, Interval: r.Interval}
+		if r.Config.StrategyDepth {
+			if book, err := r.Exchange.GetOrderBook(ctx, &luno.GetOrderBookRequest{Pair: r.Config.Pair}); err == nil {
+				ev.Bids, ev.Asks = book.Bids, book.Asks
+			}
+		}
+		sig := bot.AdaptStrategy(r.Strategy).OnEvent(ev, r.Config)
+		if err := r.Executor.Execute(ctx, sig, md, r.Config); err != nil {
+			rep.Errors++
+			rep.LastError = err.Error()
+		}
+		led.apply(r.Exchange.Fills(), rep)
+		if led.realized > peak {
+			peak = led.realized
+		}
+		dd := peak - led.realized
+		rep.MaxDrawdown = math.Max(rep.MaxDrawdown, dd)
+		rep.PnLHistory = append(rep.PnLHistory, PnLPoint{Time: b.Time, PnL: led.realized})
+		rep.DrawdownHistory = append(rep.DrawdownHistory, DrawdownPoint{Time: b.Time, Drawdown: dd})
+	}
+	rep.TotalPnL = led.realized
+	rep.Fees = led.fees
+	rep.FinalEquity = r.Exchange.Equity()
+	rep.Fills = r.Exchange.Fills()
+	rep.summarise()
+	return rep, nil
+}
+
+// ledger turns fills into realized round-trip PnL using average cost.
+type ledger struct {
+	seen     int
+	position float64
+	cost     float64 // counter spent on the open position, fees included
+	tripPnL  float64
+	realized float64
+	fees     float64
+}
+
+// apply processes fills not yet seen, closing trips in rep when flat.
+func (l *ledger) apply(fills []Fill, rep *Report) {
+	for _, f := range fills[l.seen:] {
+		l.fees += f.Fee
+		if f.Type == luno.OrderTypeBid {
+			l.position += f.Base
+			l.cost += f.Price*f.Base + f.Fee
+			continue
+		}
+		avg := 0.0
+		if l.position > 0 {
+			avg = l.cost / l.position
+		}
+		pnl := f.Price*f.Base - f.Fee - avg*f.Base
+		l.cost -= avg * f.Base
+		l.position -= f.Base
+		l.tripPnL += pnl
+		l.realized += pnl
+		if l.position <= 1e-12 {
+			rep.TradePnLs = append(rep.TradePnLs, l.tripPnL)
+			l.position, l.cost, l.tripPnL = 0, 0, 0
+		}
+	}
+	l.seen = len(fills)
+}
+
+// summarise fills in trade counts, rates and Sharpe from TradePnLs.
+func (rep *Report) summarise() {
+	rep.Trades = len(rep.TradePnLs)
+	for _, p := range rep.TradePnLs {
+		if p > 0 {
+			rep.Wins++
+		} else {
+			rep.Losses++
+		}
+	}
+	if rep.Trades == 0 {
+		return
+	}
+	rep.WinRate = float64(rep.Wins) / float64(rep.Trades) * 100
+	rep.AvgPnL = rep.TotalPnL / float64(rep.Trades)
+	if rep.Trades > 1 {
+		var sumsq float64
+		for _, p := range rep.TradePnLs {
+			sumsq += (p - rep.AvgPnL) * (p - rep.AvgPnL)
+		}
+		std := math.Sqrt(sumsq / float64(rep.Trades-1))
+		if std > 0 {
+			rep.Sharpe = rep.AvgPnL / std * math.Sqrt(float64(rep.Trades))
+		}
+	}
+}
+
+// BarsFromCandles converts Luno candles to bars.
+func BarsFromCandles(candles []luno.Candle) []Bar {
+	return bot.CandleBars(candles)
+}
+
+// BarsFromTrades converts public trades, in any order, to chronological tick bars.
+func BarsFromTrades(trades []luno.PublicTrade) []Bar {
+	bars := make([]Bar, len(trades))
+	for i, t := range trades {
+		p := t.Price.Float64()
+		bars[i] = Bar{Time: time.Time(t.Timestamp), Open: p, High: p, Low: p, Close: p, Volume: t.Volume.Float64()}
+	}
+	sortBars(bars)
+	return bars
+}
+
+// sortBars orders bars by time, keeping the order of equal timestamps.
+func sortBars(bars []Bar) {
+	sort.SliceStable(bars, func(i, j int) bool { return bars[i].Time.Before(bars[j].Time) })
+}
@@ -0,0 +1,201 @@
+package backtest
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/luno/luno-bot/bot"
+	"github.com/luno/luno-bot/config"
+	luno "github.com/luno/luno-go"
+	"github.com/luno/luno-go/decimal"
+)
+
+// script emits signals in order, one per bar.
+type script struct {
+	sigs []bot.Signal
+	i    int
+}
+
+func (s *script) Next(md bot.MarketData, cfg bot.Config) bot.Signal {
+	if s.i >= len(s.sigs) {
+		return bot.SignalNone
+	}
+	sig := s.sigs[s.i]
+	s.i++
+	return sig
+}
+
+func testBars(closes ...float64) []Bar {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	bars := make([]Bar, len(closes))
+	for i, c := range closes {
+		bars[i] = Bar{Time: t0.Add(time.Duration(i) * time.Minute), Open: c, High: c, Low: c, Close: c, Volume: 10}
+	}
+	return bars
+}
+
+func TestRunRoundTrips(t *testing.T) {
+	strat := &script{sigs: []bot.Signal{
+		bot.SignalBuy, bot.SignalNone, bot.SignalSell,
+		bot.SignalBuy, bot.SignalSell,
+	}}
+	cfg := bot.Config{Pair: "XBTZAR", StakeSize: 1, PositionLimit: 1}
+	rep, err := Run(context.Background(), strat, cfg, testBars(100, 105, 110, 110, 104), Options{FeeRate: 0.001, InitialCounter: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Errors != 0 {
+		t.Fatalf("unexpected executor errors: %s", rep.LastError)
+	}
+	if rep.Trades != 2 || rep.Wins != 1 || rep.Losses != 1 {
+		t.Fatalf("got trades=%d wins=%d losses=%d, expected 2/1/1", rep.Trades, rep.Wins, rep.Losses)
+	}
+	wantFees := (100 + 110 + 110 + 104) * 0.001
+	wantPnL := 10 - 6 - wantFees
+	if math.Abs(rep.Fees-wantFees) > 1e-6 || math.Abs(rep.TotalPnL-wantPnL) > 1e-6 {
+		t.Errorf("got fees=%.4f pnl=%.4f, expected %.4f and %.4f", rep.Fees, rep.TotalPnL, wantFees, wantPnL)
+	}
+	if math.Abs(rep.FinalEquity-(1000+wantPnL)) > 1e-6 {
+		t.Errorf("final equity = %.4f, expected %.4f", rep.FinalEquity, 1000+wantPnL)
+	}
+	if math.Abs(rep.MaxDrawdown-(6+0.214)) > 1e-6 {
+		t.Errorf("max drawdown = %.4f", rep.MaxDrawdown)
+	}
+	if len(rep.PnLHistory) != 5 || len(rep.Fills) != 4 {
+		t.Errorf("got %d pnl points and %d fills", len(rep.PnLHistory), len(rep.Fills))
+	}
+}
+
+// eventRecorder keeps the events it is given.
+type eventRecorder struct{ events []bot.MarketEvent }
+
+func (e *eventRecorder) Next(md bot.MarketData, cfg bot.Config) bot.Signal {
+	return e.OnEvent(bot.TickEvent(md), cfg)
+}
+
+func (e *eventRecorder) OnEvent(ev bot.MarketEvent, cfg bot.Config) bot.Signal {
+	e.events = append(e.events, ev)
+	return bot.SignalNone
+}
+
+func TestRunPassesFullCandles(t *testing.T) {
+	bars := testBars(100, 101)
+	bars[1].Open, bars[1].High, bars[1].Low, bars[1].Volume = 99, 104, 97, 42
+	rec := &eventRecorder{}
+	cfg := bot.Config{Pair: "XBTZAR", StakeSize: 1, PositionLimit: 1}
+	if _, err := Run(context.Background(), rec, cfg, bars, Options{InitialCounter: 1000, BarInterval: time.Minute}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(rec.events) != 2 {
+		t.Fatalf("got %d events, want 2", len(rec.events))
+	}
+	ev := rec.events[1]
+	if ev.Open != 99 || ev.High != 104 || ev.Low != 97 || ev.Close != 101 || ev.Volume != 42 || ev.Interval != time.Minute {
+		t.Errorf("event bar = %+v, interval %v", ev.Bar, ev.Interval)
+	}
+	if ev.Last != 101 || ev.Bid <= 0 || ev.Ask <= 0 {
+		t.Errorf("event quote = %+v", ev.MarketData)
+	}
+
+	// MarketData strategies keep working through the adapter
+	strat := &script{sigs: []bot.Signal{bot.SignalBuy, bot.SignalNone}}
+	if _, err := Run(context.Background(), strat, cfg, bars, Options{InitialCounter: 1000}); err != nil || strat.i != 2 {
+		t.Errorf("adapted strategy: ran %d bars, err %v", strat.i, err)
+	}
+}
+
+func TestNewExecutorRejectsUnknownExecution(t *testing.T) {
+	if _, err := NewExecutor(NewExchange("XBTZAR", 0, nil), Options{Execution: "iceberg"}); err == nil {
+		t.Fatal("expected error for unknown execution")
+	}
+}
+
+func post(t *testing.T, ex *Exchange, typ luno.OrderType, price, volume float64) string {
+	t.Helper()
+	resp, err := ex.PostLimitOrder(context.Background(), &luno.PostLimitOrderRequest{
+		Pair:   ex.Pair,
+		Type:   typ,
+		Price:  decimal.NewFromFloat64(price, 8),
+		Volume: decimal.NewFromFloat64(volume, 8),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.OrderId
+}
+
+func TestMarketFillRestingPartialFills(t *testing.T) {
+	model := bot.NewMarketFill(0, 0.001, 0.002)
+	model.Participation = 0.5
+	ex := NewExchange("XBTZAR", 1000, model)
+	bars := testBars(101, 100, 100, 100)
+	bars[1].Low = 100  // touches the limit without trading through
+	bars[2].Low = 99.5 // trades through with 4 volume: half fills
+	bars[3].Low = 99.5
+	bars[2].Volume, bars[3].Volume = 4, 4
+
+	ex.Advance(bars[0])
+	post(t, ex, luno.OrderTypeBid, 100, 4)
+	ex.Advance(bars[1])
+	if n := len(ex.Fills()); n != 0 {
+		t.Fatalf("got %d fills when price only touched the limit", n)
+	}
+	ex.Advance(bars[2])
+	ex.Advance(bars[3])
+	fills := ex.Fills()
+	if len(fills) != 2 {
+		t.Fatalf("got %d fills, expected 2 partial fills", len(fills))
+	}
+	for _, f := range fills {
+		if f.Base != 2 || f.Price != 100 || !f.Maker || math.Abs(f.Fee-0.2) > 1e-9 {
+			t.Errorf("unexpected fill %+v", f)
+		}
+	}
+	if base, counter := ex.Balances(); base != 4 || math.Abs(counter-(1000-400.4)) > 1e-9 {
+		t.Errorf("balances = %.4f base, %.4f counter", base, counter)
+	}
+}
+
+func TestMarketFillLatencyAndDepth(t *testing.T) {
+	model := bot.NewMarketFill(20, 0, 0.001)
+	model.Delay = time.Minute
+	model.LevelVolume, model.LevelBps = 1, 10
+	ex := NewExchange("XBTZAR", 1000, model)
+	bars := testBars(100, 100)
+
+	ex.Advance(bars[0])
+	if bid, ask := ex.Touch(); math.Abs(bid-99.9) > 1e-9 || math.Abs(ask-100.1) > 1e-9 {
+		t.Fatalf("touch = %.4f/%.4f, expected 99.9/100.1", bid, ask)
+	}
+	post(t, ex, luno.OrderTypeBid, 200, 2)
+	if n := len(ex.Fills()); n != 0 {
+		t.Fatalf("got %d fills before latency elapsed", n)
+	}
+	ex.Advance(bars[1])
+	fills := ex.Fills()
+	if len(fills) != 1 {
+		t.Fatalf("got %d fills, expected 1", len(fills))
+	}
+	// two synthetic levels: 100.1 and 10bps above it
+	want := (100.1 + 100.1*1.001) / 2
+	if f := fills[0]; f.Base != 2 || f.Maker || math.Abs(f.Price-want) > 1e-6 || math.Abs(f.Fee-want*2*0.001) > 1e-6 {
+		t.Errorf("unexpected fill %+v, expected price %.6f", f, want)
+	}
+}
+
+func TestResolveConfigAppliesOverrides(t *testing.T) {
+	stake, limit := 1.0, 4.0
+	stored := &config.Config{StakeSize: 2, PositionLimit: 3, Markets: []config.MarketConfig{{Pair: "ETHZAR", PositionLimit: &limit}}}
+
+	// the pair's market settings apply, then the overrides
+	cfg, _, err := ResolveConfig(stored, "ETHZAR", Overrides{StakeSize: &stake})
+	if err != nil || cfg.Pair != "ETHZAR" || cfg.StakeSize != 1 || cfg.PositionLimit != 4 {
+		t.Errorf("Resolved %+v, %v", cfg, err)
+	}
+	if _, _, err := ResolveConfig(nil, "XBTZAR", Overrides{StakeSize: &stake}); !errors.Is(err, ErrNoStake) {
+		t.Errorf("Expected ErrNoStake without a limit, got %v", err)
+	}
+}
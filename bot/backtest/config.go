@@ -0,0 +1,54 @@
+package backtest
+
+import (
+	"errors"
+
+	"github.com/luno/luno-bot/bot"
+	"github.com/luno/luno-bot/config"
+)
+
+// ErrNoStake is returned when neither the config nor the overrides give a
+// stake and position limit.
+var ErrNoStake = errors.New("stake_size and position_limit must be configured or given")
+
+// Overrides replace the pair's configured stake, limit, equity and sizer for
+// one run; nil fields keep the configured value.
+type Overrides struct {
+	StakeSize     *float64
+	PositionLimit *float64
+	InitialEquity *float64
+	Sizer         *string
+}
+
+// ResolveConfig returns the config and position sizer a backtest of pair
+// runs with: stored's settings for the pair with o applied, so a run is
+// staked and sized like the live bot unless told otherwise. stored may be
+// nil when there is no config to follow.
+func ResolveConfig(stored *config.Config, pair string, o Overrides) (bot.Config, bot.PositionSizer, error) {
+	c := &config.Config{}
+	if stored != nil {
+		c = stored.ForPair(pair)
+	}
+	if o.StakeSize != nil {
+		c.StakeSize = *o.StakeSize
+	}
+	if o.PositionLimit != nil {
+		c.PositionLimit = *o.PositionLimit
+	}
+	if o.InitialEquity != nil {
+		c.InitialEquity = *o.InitialEquity
+	}
+	if o.Sizer != nil {
+		c.PositionSizerType = *o.Sizer
+	}
+	if c.StakeSize <= 0 || c.PositionLimit <= 0 {
+		return bot.Config{}, nil, ErrNoStake
+	}
+	sizer, err := bot.SizerFromStore(c, nil, "")
+	if err != nil {
+		return bot.Config{}, nil, err
+	}
+	cfg := bot.ConfigFromStore(c)
+	cfg.Pair = pair
+	return cfg, sizer, nil
+}
@@ -0,0 +1,385 @@
+package backtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/luno/luno-bot/bot"
+	luno "github.com/luno/luno-go"
+	"github.com/luno/luno-go/decimal"
+)
+
+// Bar is one step of replayed market data. Ticks are bars with
+// Open, High, Low and Close all equal to the trade price.
+type Bar = bot.Bar
+
+// Fill is an execution on the simulated exchange.
+type Fill struct {
+	Time    time.Time      `json:"time"`
+	OrderID string         `json:"order_id"`
+	Type    luno.OrderType `json:"type"`
+	Price   float64        `json:"price"`
+	Base    float64        `json:"base"`
+	Fee     float64        `json:"fee"` // in counter currency
+	Maker   bool           `json:"maker"`
+}
+
+// Exchange is a single-pair simulated exchange implementing bot.Client, so the
+// live executor chain can run unchanged during a backtest. Orders are matched
+// by a bot.FillModel: they reach the market after its latency, take what
+// liquidity their limit allows, and rest for later bars to fill.
+type Exchange struct {
+	Pair  string
+	Model bot.FillModel
+
+	mu      sync.Mutex
+	bar     Bar
+	history []Bar
+	base    float64
+	counter float64
+	orders  map[string]*order
+	order   []string // order IDs in posting sequence
+	pending []string // IDs of orders that may still be pending, in posting sequence
+	fills   []Fill
+	seq     int
+}
+
+// order is a posted order and its progress through the fill model.
+type order struct {
+	luno.Order
+	due     time.Time // when the order reaches the market
+	arrived bool      // has taken liquidity and is now resting
+	market  bool      // stop any remainder after taking instead of resting
+}
+
+// NewExchange constructs an exchange for pair holding counter currency.
+// A nil model fills at the mid with no fees.
+func NewExchange(pair string, counter float64, model bot.FillModel) *Exchange {
+	if model == nil {
+		model = &bot.MidFill{}
+	}
+	return &Exchange{Pair: pair, Model: model, counter: counter, orders: make(map[string]*order)}
+}
+
+// Advance moves the exchange to bar. Orders whose latency has elapsed take
+// liquidity at the bar's open; orders already resting are matched against
+// the bar's range. Only pending orders are visited, so a long replay does
+// not rescan every order it has posted.
+func (x *Exchange) Advance(b Bar) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.bar = b
+	x.history = append(x.history, b)
+	pending := x.pending[:0]
+	for _, id := range x.pending {
+		o := x.orders[id]
+		if o.State != luno.OrderStatePending {
+			continue
+		}
+		switch {
+		case b.Time.Before(o.due):
+		case !o.arrived:
+			x.take(o, bot.Quote{Time: b.Time, Bid: b.Open, Ask: b.Open, High: b.Open, Low: b.Open, Volume: b.Volume})
+		default:
+			x.apply(o, x.Model.Rest(x.simOrder(o), x.quote()))
+		}
+		if o.State == luno.OrderStatePending {
+			pending = append(pending, id)
+		}
+	}
+	x.pending = pending
+}
+
+// Touch returns the model's bid and ask at the current close.
+func (x *Exchange) Touch() (bid, ask float64) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return x.Model.Touch(x.quote())
+}
+
+// Balances returns base and counter holdings.
+func (x *Exchange) Balances() (base, counter float64) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return x.base, x.counter
+}
+
+// Equity marks holdings to the current close.
+func (x *Exchange) Equity() float64 {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return x.counter + x.base*x.bar.Close
+}
+
+// Fills returns all executions so far.
+func (x *Exchange) Fills() []Fill {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return append([]Fill(nil), x.fills...)
+}
+
+// quote is the current bar as a bot.Quote. Callers hold x.mu.
+func (x *Exchange) quote() bot.Quote {
+	b := x.bar
+	return bot.Quote{Time: b.Time, Bid: b.Close, Ask: b.Close, High: b.High, Low: b.Low, Volume: b.Volume}
+}
+
+// simOrder is the unfilled remainder of o. Callers hold x.mu.
+func (x *Exchange) simOrder(o *order) bot.SimOrder {
+	return bot.SimOrder{Type: o.Type, Limit: o.LimitPrice.Float64(), Volume: o.LimitVolume.Float64() - o.Base.Float64()}
+}
+
+// take matches a newly arrived order and leaves any remainder resting. Callers hold x.mu.
+func (x *Exchange) take(o *order, q bot.Quote) {
+	o.arrived = true
+	x.apply(o, x.Model.Take(x.simOrder(o), q))
+	if o.market && o.State == luno.OrderStatePending {
+		o.State = luno.OrderStateComplete
+		o.CompletedTimestamp = luno.Time(x.bar.Time)
+	}
+}
+
+// apply books a fill against o and the balances. Callers hold x.mu.
+func (x *Exchange) apply(o *order, f bot.FillResult) {
+	if f.Volume <= 0 {
+		return
+	}
+	notional := f.Price * f.Volume
+	if o.Type == luno.OrderTypeBid {
+		x.base += f.Volume
+		x.counter -= notional + f.Fee
+	} else {
+		x.base -= f.Volume
+		x.counter += notional - f.Fee
+	}
+	o.Base = decimal.NewFromFloat64(o.Base.Float64()+f.Volume, 8)
+	o.Counter = decimal.NewFromFloat64(o.Counter.Float64()+notional, 8)
+	o.FeeCounter = decimal.NewFromFloat64(o.FeeCounter.Float64()+f.Fee, 8)
+	if o.Base.Float64() >= o.LimitVolume.Float64()-1e-12 {
+		o.State = luno.OrderStateComplete
+		o.CompletedTimestamp = luno.Time(x.bar.Time)
+	}
+	x.fills = append(x.fills, Fill{Time: x.bar.Time, OrderID: o.OrderId, Type: o.Type, Price: f.Price, Base: f.Volume, Fee: f.Fee, Maker: f.Maker})
+}
+
+// SetAuth is a no-op.
+func (x *Exchange) SetAuth(id, secret string) error { return nil }
+
+// GetTickers returns the current bar as a ticker quoted by the fill model.
+func (x *Exchange) GetTickers(ctx context.Context, req *luno.GetTickersRequest) (*luno.GetTickersResponse, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	bid, ask := x.Model.Touch(x.quote())
+	return &luno.GetTickersResponse{Tickers: []luno.Ticker{{
+		Pair:                x.Pair,
+		Bid:                 decimal.NewFromFloat64(bid, 8),
+		Ask:                 decimal.NewFromFloat64(ask, 8),
+		LastTrade:           decimal.NewFromFloat64(x.bar.Close, 8),
+		Rolling24HourVolume: decimal.NewFromFloat64(x.bar.Volume, 8),
+		Status:              luno.StatusActive,
+	}}}, nil
+}
+
+// GetOrderBook returns a one-level book at the fill model's touch.
+func (x *Exchange) GetOrderBook(ctx context.Context, req *luno.GetOrderBookRequest) (*luno.GetOrderBookResponse, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.bar.Time.IsZero() {
+		return nil, errors.New("backtest: no market data yet")
+	}
+	bid, ask := x.Model.Touch(x.quote())
+	vol := decimal.NewFromFloat64(x.bar.Volume, 8)
+	return &luno.GetOrderBookResponse{
+		Bids:      []luno.OrderBookEntry{{Price: decimal.NewFromFloat64(bid, 8), Volume: vol}},
+		Asks:      []luno.OrderBookEntry{{Price: decimal.NewFromFloat64(ask, 8), Volume: vol}},
+		Timestamp: x.bar.Time.UnixMilli(),
+	}, nil
+}
+
+// PostLimitOrder places a limit order. Without latency it takes liquidity
+// immediately at the current close; otherwise it arrives on a later bar.
+func (x *Exchange) PostLimitOrder(ctx context.Context, req *luno.PostLimitOrderRequest) (*luno.PostLimitOrderResponse, error) {
+	if req.Type != luno.OrderTypeBid && req.Type != luno.OrderTypeAsk {
+		return nil, fmt.Errorf("backtest: unsupported order type %s", req.Type)
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	o := x.place(req.Pair, req.Type, req.Price, req.Volume, false)
+	return &luno.PostLimitOrderResponse{OrderId: o.OrderId}, nil
+}
+
+// PostMarketOrder places an order with no limit that takes what the fill
+// model offers when it arrives and stops the rest. A buy's counter volume is
+// converted to base at the current ask. Market orders are booked as bids
+// and asks, like limit orders.
+func (x *Exchange) PostMarketOrder(ctx context.Context, req *luno.PostMarketOrderRequest) (*luno.PostMarketOrderResponse, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	var typ luno.OrderType
+	var volume decimal.Decimal
+	switch req.Type {
+	case luno.OrderTypeBuy:
+		_, ask := x.Model.Touch(x.quote())
+		if ask <= 0 {
+			return nil, errors.New("backtest: no market data yet")
+		}
+		typ, volume = luno.OrderTypeBid, decimal.NewFromFloat64(req.CounterVolume.Float64()/ask, 8)
+	case luno.OrderTypeSell:
+		typ, volume = luno.OrderTypeAsk, req.BaseVolume
+	default:
+		return nil, fmt.Errorf("backtest: unsupported market order type %s", req.Type)
+	}
+	o := x.place(req.Pair, typ, decimal.Zero(), volume, true)
+	return &luno.PostMarketOrderResponse{OrderId: o.OrderId}, nil
+}
+
+// place books a new order and, without latency, takes liquidity at once.
+// Callers hold x.mu.
+func (x *Exchange) place(pair string, typ luno.OrderType, price, volume decimal.Decimal, market bool) *order {
+	x.seq++
+	o := &order{Order: luno.Order{
+		OrderId:           fmt.Sprintf("BT%d", x.seq),
+		Pair:              pair,
+		Type:              typ,
+		State:             luno.OrderStatePending,
+		LimitPrice:        price,
+		LimitVolume:       volume,
+		Base:              decimal.Zero(),
+		Counter:           decimal.Zero(),
+		FeeCounter:        decimal.Zero(),
+		CreationTimestamp: luno.Time(x.bar.Time),
+	}, market: market}
+	o.due = x.bar.Time.Add(x.Model.Latency())
+	x.orders[o.OrderId] = o
+	x.order = append(x.order, o.OrderId)
+	x.pending = append(x.pending, o.OrderId)
+	if x.Model.Latency() == 0 {
+		x.take(o, x.quote())
+	}
+	return o
+}
+
+// ListTrades returns no trades; replayed data comes from bars.
+func (x *Exchange) ListTrades(ctx context.Context, req *luno.ListTradesRequest) (*luno.ListTradesResponse, error) {
+	return &luno.ListTradesResponse{}, nil
+}
+
+// GetCandles returns replayed bars since req.Since, up to the current bar.
+func (x *Exchange) GetCandles(ctx context.Context, req *luno.GetCandlesRequest) (*luno.GetCandlesResponse, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	since := time.Time(req.Since)
+	// bars are replayed in time order
+	first := sort.Search(len(x.history), func(i int) bool { return !x.history[i].Time.Before(since) })
+	var candles []luno.Candle
+	for _, b := range x.history[first:] {
+		candles = append(candles, luno.Candle{
+			Timestamp: luno.Time(b.Time),
+			Open:      decimal.NewFromFloat64(b.Open, 8),
+			High:      decimal.NewFromFloat64(b.High, 8),
+			Low:       decimal.NewFromFloat64(b.Low, 8),
+			Close:     decimal.NewFromFloat64(b.Close, 8),
+			Volume:    decimal.NewFromFloat64(b.Volume, 8),
+		})
+	}
+	return &luno.GetCandlesResponse{Pair: x.Pair, Duration: req.Duration, Candles: candles}, nil
+}
+
+// GetBalances returns the base and counter holdings as accounts 1 and 2.
+func (x *Exchange) GetBalances(ctx context.Context, req *luno.GetBalancesRequest) (*luno.GetBalancesResponse, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return &luno.GetBalancesResponse{Balance: []luno.AccountBalance{
+		{AccountId: "1", Name: "base", Balance: decimal.NewFromFloat64(x.base, 8)},
+		{AccountId: "2", Name: "counter", Balance: decimal.NewFromFloat64(x.counter, 8)},
+	}}, nil
+}
+
+// GetOrderV2 returns an order's current state.
+func (x *Exchange) GetOrderV2(ctx context.Context, req *luno.GetOrderV2Request) (*luno.GetOrderV2Response, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	o, ok := x.orders[req.Id]
+	if !ok {
+		return nil, fmt.Errorf("backtest: order %s not found", req.Id)
+	}
+	status := luno.StatusAwaiting
+	if o.State == luno.OrderStateComplete {
+		status = luno.StatusComplete
+	}
+	side := luno.SideBuy
+	if o.Type == luno.OrderTypeAsk {
+		side = luno.SideSell
+	}
+	return &luno.GetOrderV2Response{
+		OrderId:     o.OrderId,
+		Pair:        o.Pair,
+		Side:        side,
+		Status:      status,
+		LimitPrice:  o.LimitPrice,
+		LimitVolume: o.LimitVolume,
+		Base:        o.Base,
+		Counter:     o.Counter,
+		FeeCounter:  o.FeeCounter,
+	}, nil
+}
+
+// ListOrders returns orders for req.Pair, newest first.
+func (x *Exchange) ListOrders(ctx context.Context, req *luno.ListOrdersRequest) (*luno.ListOrdersResponse, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	var orders []luno.Order
+	for i := len(x.order) - 1; i >= 0; i-- {
+		o := x.orders[x.order[i]]
+		if (req.Pair == "" || o.Pair == req.Pair) && (req.State == "" || o.State == req.State) {
+			orders = append(orders, o.Order)
+		}
+	}
+	return &luno.ListOrdersResponse{Orders: orders}, nil
+}
+
+// StopOrder cancels a resting order.
+func (x *Exchange) StopOrder(ctx context.Context, req *luno.StopOrderRequest) (*luno.StopOrderResponse, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	o, ok := x.orders[req.OrderId]
+	if !ok {
+		return nil, fmt.Errorf("backtest: order %s not found", req.OrderId)
+	}
+	if o.State == luno.OrderStatePending {
+		o.State = luno.OrderStateComplete
+		o.CompletedTimestamp = luno.Time(x.bar.Time)
+	}
+	return &luno.StopOrderResponse{Success: true}, nil
+}
+
+// Markets reports the pair as active with 8 decimal places and no minimum volume.
+func (x *Exchange) Markets(ctx context.Context, req *luno.MarketsRequest) (*luno.MarketsResponse, error) {
+	return &luno.MarketsResponse{Markets: []luno.MarketInfo{{
+		MarketId:      x.Pair,
+		PriceScale:    8,
+		VolumeScale:   8,
+		MinVolume:     decimal.Zero(),
+		MaxVolume:     decimal.Zero(),
+		MinPrice:      decimal.Zero(),
+		MaxPrice:      decimal.Zero(),
+		TradingStatus: luno.TradingStatusActive,
+	}}}, nil
+}
+
+// GetFeeInfo reports the fill model's fees, or zero fees when it has none.
+func (x *Exchange) GetFeeInfo(ctx context.Context, req *luno.GetFeeInfoRequest) (*luno.GetFeeInfoResponse, error) {
+	var f bot.FeeRates
+	if fm, ok := x.Model.(bot.FeeModel); ok {
+		f = fm.Fees()
+	}
+	return &luno.GetFeeInfoResponse{
+		MakerFee: strconv.FormatFloat(f.Maker, 'f', -1, 64),
+		TakerFee: strconv.FormatFloat(f.Taker, 'f', -1, 64),
+	}, nil
+}
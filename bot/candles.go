@@ -0,0 +1,156 @@
+package bot
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	luno "github.com/luno/luno-go"
+)
+
+// Bar is an OHLCV candle over [Time, Time+interval).
+type Bar struct {
+	Time   time.Time `json:"time"`
+	Open   float64   `json:"open"`
+	High   float64   `json:"high"`
+	Low    float64   `json:"low"`
+	Close  float64   `json:"close"`
+	Volume float64   `json:"volume"`
+}
+
+// ParseTimeframe parses a bar interval such as "1m", "5m", "1h", "4h" or
+// "1d"; "" is zero, meaning every tick.
+func ParseTimeframe(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid timeframe %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < time.Second {
+		return 0, fmt.Errorf("invalid timeframe %q", s)
+	}
+	return d, nil
+}
+
+// Resampler aggregates prices, trades or finer bars into bars of Interval
+// aligned to the Unix epoch, so 1d bars open at midnight UTC. Intervals
+// without data produce no bar. Input must arrive in time order; data older
+// than the open bar is folded into it.
+type Resampler struct {
+	Interval time.Duration
+
+	cur  Bar
+	open bool
+}
+
+// NewResampler constructs a Resampler producing bars of interval.
+func NewResampler(interval time.Duration) *Resampler {
+	return &Resampler{Interval: interval}
+}
+
+// Add records a trade of volume at price and returns the bar it closed, if any.
+func (r *Resampler) Add(t time.Time, price, volume float64) (Bar, bool) {
+	return r.AddBar(Bar{Time: t, Open: price, High: price, Low: price, Close: price, Volume: volume})
+}
+
+// AddTick records md's last trade price, or its mid when none is known, with
+// no volume.
+func (r *Resampler) AddTick(md MarketData) (Bar, bool) {
+	price := md.Last
+	if price <= 0 {
+		price = (md.Bid + md.Ask) / 2
+	}
+	t := md.Timestamp
+	if t.IsZero() {
+		t = time.Now()
+	}
+	return r.Add(t, price, 0)
+}
+
+// AddBar merges a finer bar and returns the bar it closed, if any.
+func (r *Resampler) AddBar(b Bar) (Bar, bool) {
+	if b.Close <= 0 {
+		return Bar{}, false
+	}
+	start := truncateUnix(b.Time, r.Interval)
+	if !r.open || start.After(r.cur.Time) {
+		closed, ok := r.cur, r.open
+		b.Time = start
+		r.cur, r.open = b, true
+		return closed, ok
+	}
+	if b.High > r.cur.High {
+		r.cur.High = b.High
+	}
+	if b.Low < r.cur.Low {
+		r.cur.Low = b.Low
+	}
+	r.cur.Close = b.Close
+	r.cur.Volume += b.Volume
+	return Bar{}, false
+}
+
+// Current returns the bar still open.
+func (r *Resampler) Current() (Bar, bool) {
+	return r.cur, r.open
+}
+
+// CandleBars converts Luno candles to bars.
+func CandleBars(candles []luno.Candle) []Bar {
+	bars := make([]Bar, len(candles))
+	for i, c := range candles {
+		bars[i] = Bar{
+			Time:   time.Time(c.Timestamp),
+			Open:   c.Open.Float64(),
+			High:   c.High.Float64(),
+			Low:    c.Low.Float64(),
+			Close:  c.Close.Float64(),
+			Volume: c.Volume.Float64(),
+		}
+	}
+	return bars
+}
+
+// ResampleTrades aggregates public trades, in any order, into bars of
+// interval. The last bar may still be open.
+func ResampleTrades(trades []luno.PublicTrade, interval time.Duration) []Bar {
+	sorted := append([]luno.PublicTrade(nil), trades...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return time.Time(sorted[i].Timestamp).Before(time.Time(sorted[j].Timestamp))
+	})
+	r := NewResampler(interval)
+	var bars []Bar
+	for _, t := range sorted {
+		if b, ok := r.Add(time.Time(t.Timestamp), t.Price.Float64(), t.Volume.Float64()); ok {
+			bars = append(bars, b)
+		}
+	}
+	if b, ok := r.Current(); ok {
+		bars = append(bars, b)
+	}
+	return bars
+}
+
+// ResampleBars aggregates chronological bars, such as 1m candles, into bars
+// of a longer interval. The last bar may still be open.
+func ResampleBars(in []Bar, interval time.Duration) []Bar {
+	r := NewResampler(interval)
+	var bars []Bar
+	for _, b := range in {
+		if closed, ok := r.AddBar(b); ok {
+			bars = append(bars, closed)
+		}
+	}
+	if b, ok := r.Current(); ok {
+		bars = append(bars, b)
+	}
+	return bars
+}
@@ -0,0 +1,138 @@
+package bot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	luno "github.com/luno/luno-go"
+	"github.com/luno/luno-go/decimal"
+)
+
+func TestParseTimeframe(t *testing.T) {
+	for in, want := range map[string]time.Duration{
+		"": 0, "1m": time.Minute, "5m": 5 * time.Minute, "4h": 4 * time.Hour, "1d": 24 * time.Hour,
+	} {
+		if got, err := ParseTimeframe(in); err != nil || got != want {
+			t.Errorf("ParseTimeframe(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"0d", "x", "10ms", "-1m"} {
+		if _, err := ParseTimeframe(in); err == nil {
+			t.Errorf("ParseTimeframe(%q): expected an error", in)
+		}
+	}
+}
+
+func TestResampleTrades(t *testing.T) {
+	base := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	trade := func(offset time.Duration, price, volume float64) luno.PublicTrade {
+		return luno.PublicTrade{
+			Timestamp: luno.Time(base.Add(offset)),
+			Price:     decimal.NewFromFloat64(price, 2),
+			Volume:    decimal.NewFromFloat64(volume, 2),
+		}
+	}
+	// out of order, with no trades between 10:05 and 10:10
+	bars := ResampleTrades([]luno.PublicTrade{
+		trade(2*time.Minute, 105, 1),
+		trade(0, 100, 1),
+		trade(4*time.Minute, 98, 2),
+		trade(3*time.Minute, 110, 0.5),
+		trade(11*time.Minute, 120, 3),
+	}, 5*time.Minute)
+	want := []Bar{
+		{Time: base, Open: 100, High: 110, Low: 98, Close: 98, Volume: 4.5},
+		{Time: base.Add(10 * time.Minute), Open: 120, High: 120, Low: 120, Close: 120, Volume: 3},
+	}
+	if len(bars) != len(want) {
+		t.Fatalf("got %d bars, want %d: %+v", len(bars), len(want), bars)
+	}
+	for i := range want {
+		if !bars[i].Time.Equal(want[i].Time) || bars[i].Open != want[i].Open || bars[i].High != want[i].High ||
+			bars[i].Low != want[i].Low || bars[i].Close != want[i].Close || bars[i].Volume != want[i].Volume {
+			t.Errorf("bar %d = %+v, want %+v", i, bars[i], want[i])
+		}
+	}
+
+	hourly := ResampleBars(bars, time.Hour)
+	if len(hourly) != 1 || hourly[0].High != 120 || hourly[0].Low != 98 || hourly[0].Volume != 7.5 {
+		t.Errorf("hourly = %+v", hourly)
+	}
+}
+
+func TestTimeframeStrategyRunsOnClosedBars(t *testing.T) {
+	inner := &tickCounter{}
+	tf := NewTimeframeStrategy(time.Minute, inner, true)
+	base := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	var sigs []Signal
+	for _, offset := range []time.Duration{0, 20 * time.Second, 50 * time.Second, 70 * time.Second, 80 * time.Second, 130 * time.Second} {
+		sigs = append(sigs, tf.Next(MarketData{Bid: 100, Ask: 102, Timestamp: base.Add(offset)}, Config{}))
+	}
+	if inner.ticks != 2 {
+		t.Errorf("inner saw %d bars, want 2", inner.ticks)
+	}
+	want := []Signal{SignalNone, SignalNone, SignalNone, SignalBuy, SignalBuy, SignalBuy}
+	for i := range want {
+		if sigs[i] != want[i] {
+			t.Errorf("tick %d: got %v, want %v", i, sigs[i], want[i])
+		}
+	}
+
+	tf = NewTimeframeStrategy(time.Minute, &tickCounter{}, false)
+	tf.Next(MarketData{Bid: 100, Ask: 102, Timestamp: base}, Config{})
+	if sig := tf.Next(MarketData{Bid: 100, Ask: 102, Timestamp: base.Add(time.Minute)}, Config{}); sig != SignalBuy {
+		t.Errorf("closing tick: got %v, want buy", sig)
+	}
+	if sig := tf.Next(MarketData{Bid: 100, Ask: 102, Timestamp: base.Add(70 * time.Second)}, Config{}); sig != SignalNone {
+		t.Errorf("without hold: got %v, want none", sig)
+	}
+}
+
+// seedClient serves fixed candles and, through tradesClient, public trades.
+type seedClient struct {
+	*tradesClient
+	candles []luno.Candle
+}
+
+func (c *seedClient) GetCandles(ctx context.Context, req *luno.GetCandlesRequest) (*luno.GetCandlesResponse, error) {
+	return &luno.GetCandlesResponse{Pair: req.Pair, Duration: req.Duration, Candles: c.candles}, nil
+}
+
+func TestTimeframeStrategySeedsFromHistory(t *testing.T) {
+	ctx := context.Background()
+	base := time.Now().Add(-time.Hour).Truncate(10 * time.Minute)
+	client := &seedClient{tradesClient: &tradesClient{}}
+	for i := 0; i < 6; i++ {
+		p := decimal.NewFromInt64(int64(100 + i))
+		client.candles = append(client.candles, luno.Candle{Timestamp: luno.Time(base.Add(time.Duration(i) * 5 * time.Minute)), Open: p, High: p, Low: p, Close: p, Volume: decimal.NewFromInt64(1)})
+	}
+
+	// six 5m candles make three 10m bars: two closed, the newest left open
+	inner := &tickCounter{}
+	tf := NewTimeframeStrategy(10*time.Minute, inner, false)
+	if err := tf.Seed(ctx, client, Config{Pair: "XBTZAR"}); err != nil {
+		t.Fatal(err)
+	}
+	if inner.ticks != 2 {
+		t.Errorf("Inner saw %d seeded bars, want 2", inner.ticks)
+	}
+	if sig := tf.Next(MarketData{Bid: 110, Ask: 110, Timestamp: base.Add(30 * time.Minute)}, Config{}); sig != SignalBuy || inner.ticks != 3 {
+		t.Errorf("First live bar: signal %v after %d bars, want buy after 3", sig, inner.ticks)
+	}
+
+	// without candles it resamples public trades
+	client.candles = nil
+	for i := 0; i < 3; i++ {
+		ts := base.Add(time.Duration(i) * 10 * time.Minute)
+		client.trades = append(client.trades, luno.PublicTrade{Timestamp: luno.Time(ts), Price: decimal.NewFromInt64(100), Volume: decimal.NewFromInt64(1)})
+	}
+	inner = &tickCounter{}
+	tf = NewTimeframeStrategy(10*time.Minute, inner, false)
+	if err := tf.Seed(ctx, client, Config{Pair: "XBTZAR"}); err != nil {
+		t.Fatal(err)
+	}
+	if inner.ticks != 2 {
+		t.Errorf("Inner saw %d bars seeded from trades, want 2", inner.ticks)
+	}
+}
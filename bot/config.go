@@ -0,0 +1,110 @@
+package bot
+
+import (
+	"fmt"
+
+	"github.com/luno/luno-bot/config"
+)
+
+// ConfigFromStore converts a persisted config.Config into the strategy Config.
+func ConfigFromStore(c *config.Config) Config {
+	return Config{
+		Pair:                     c.Pair,
+		EntryThreshold:           c.EntryThreshold,
+		ExitThreshold:            c.ExitThreshold,
+		StakeSize:                c.StakeSize,
+		Cooldown:                 c.Cooldown,
+		PositionLimit:            c.PositionLimit,
+		MaxDrawdown:              c.MaxDrawdown,
+		ShortWindow:              c.ShortWindow,
+		LongWindow:               c.LongWindow,
+		BaseAccountId:            c.BaseAccountId,
+		CounterAccountId:         c.CounterAccountId,
+		RSIPeriod:                c.RSIPeriod,
+		RSIOverBought:            c.RSIOverBought,
+		RSIOverSold:              c.RSIOverSold,
+		MACDFastPeriod:           c.MACDFastPeriod,
+		MACDSlowPeriod:           c.MACDSlowPeriod,
+		MACDSignalPeriod:         c.MACDSignalPeriod,
+		BBPeriod:                 c.BBPeriod,
+		BBMultiplier:             c.BBMultiplier,
+		InitialEquity:            c.InitialEquity,
+		PositionSizerType:        c.PositionSizerType,
+		KellyWinProb:             c.KellyWinProb,
+		KellyWinLossRatio:        c.KellyWinLossRatio,
+		TWAPSlices:               c.TWAPSlices,
+		TWAPIntervalSeconds:      c.TWAPIntervalSeconds,
+		VWAPSource:               c.VWAPSource,
+		VWAPHistoryWindowMinutes: c.VWAPHistoryWindowMinutes,
+		VWAPOrderbookDepthLevels: c.VWAPOrderbookDepthLevels,
+		VWAPHybridWeight:         c.VWAPHybridWeight,
+		FillModel:                c.FillModel,
+		FillSpreadBps:            c.FillSpreadBps,
+		MakerFee:                 c.MakerFee,
+		TakerFee:                 c.TakerFee,
+		FillLatencyMs:            c.FillLatencyMs,
+		FillParticipation:        c.FillParticipation,
+		FillLevelVolume:          c.FillLevelVolume,
+		FillLevelBps:             c.FillLevelBps,
+		MaxOrderNotional:         c.MaxOrderNotional,
+		MaxDailyVolume:           c.MaxDailyVolume,
+		PriceBandBps:             c.PriceBandBps,
+		MaxOpenOrders:            c.MaxOpenOrders,
+		MaxPairExposure:          c.MaxPairExposure,
+		MaxConsecutiveErrors:     c.MaxConsecutiveErrors,
+		StaleDataSeconds:         c.StaleDataSeconds,
+		StopLossPct:              c.StopLossPct,
+		TakeProfitPct:            c.TakeProfitPct,
+		TrailingStopPct:          c.TrailingStopPct,
+		ATRStopMultiplier:        c.ATRStopMultiplier,
+		ATRPeriod:                c.ATRPeriod,
+		MaxHoldSeconds:           c.MaxHoldSeconds,
+		BumpMinVolume:            c.BumpMinVolume,
+		OrderMode:                c.OrderMode,
+		MakerRepriceTicks:        c.MakerRepriceTicks,
+		MakerTimeoutSeconds:      c.MakerTimeoutSeconds,
+		MakerCrossOnTimeout:      c.MakerCrossOnTimeout,
+		MakerTickSize:            c.MakerTickSize,
+		MaxSlippageBps:           c.MaxSlippageBps,
+		ExecutionAlgo:            c.ExecutionAlgo,
+		TWAPJitter:               c.TWAPJitter,
+		TWAPDeadlineSeconds:      c.TWAPDeadlineSeconds,
+		POVRate:                  c.POVRate,
+		POVMaxSeconds:            c.POVMaxSeconds,
+		Strategy:                 c.Strategy,
+		StrategyParams:           c.StrategyParams,
+		VoteMode:                 c.VoteMode,
+		VoteMin:                  c.VoteMin,
+		VoteThreshold:            c.VoteThreshold,
+		VoteWeights:              c.VoteWeights,
+		VoteVeto:                 c.VoteVeto,
+		Timeframe:                c.Timeframe,
+		FastTimeframe:            c.FastTimeframe,
+		SlowTimeframe:            c.SlowTimeframe,
+		StrategyDepth:            c.StrategyDepth,
+		BaseHoldings:             c.BaseHoldings,
+	}
+}
+
+// SizerFromStore builds the position sizer c.PositionSizerType selects.
+// kelly_history reads chain's round trips from trips; with nil trips, as in
+// a backtest, it sizes from the configured Kelly odds.
+func SizerFromStore(c *config.Config, trips RoundTripHistory, chain string) (PositionSizer, error) {
+	kelly := KellySizer{WinProb: c.KellyWinProb, WinLoss: c.KellyWinLossRatio}
+	switch c.PositionSizerType {
+	case "", "fixed":
+		return &FixedSizer{}, nil
+	case "kelly":
+		return &kelly, nil
+	case "kelly_history":
+		if trips == nil {
+			return &kelly, nil
+		}
+		return NewHistoryKellySizer(trips, chain, c.KellyMinTrades, kelly), nil
+	case "fixed_fractional":
+		return NewFixedFractionalSizer(c.RiskPerTradePct), nil
+	case "vol_target":
+		return NewVolTargetSizer(c.VolTargetPct), nil
+	}
+	return nil, fmt.Errorf("unknown position_sizer_type %q", c.PositionSizerType)
+}
@@ -0,0 +1,341 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/luno/luno-bot/config"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// EngineState describes the lifecycle state of the trading engine.
+type EngineState string
+
+const (
+	EngineStopped EngineState = "stopped"
+	EngineRunning EngineState = "running"
+	EnginePaused  EngineState = "paused"
+)
+
+var (
+	ErrEngineRunning    = errors.New("engine already running")
+	ErrEngineNotRunning = errors.New("engine not running")
+	ErrEngineNotPaused  = errors.New("engine not paused")
+)
+
+// EngineTicks counts engine ticks, labelled by pair and signal.
+var EngineTicks = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "engine_ticks_total",
+	Help: "Trading engine ticks by pair and signal",
+}, []string{"pair", "signal"})
+
+// MarketUpdate carries fresh market data for a pair.
+type MarketUpdate struct {
+	Pair string
+	Data MarketData
+}
+
+// EngineStatus is a snapshot of the engine for status reporting.
+type EngineStatus struct {
+	State      EngineState `json:"state"`
+	Pairs      []string    `json:"pairs"`
+	Interval   string      `json:"interval"`
+	Ticks      int64       `json:"ticks"`
+	LastTick   time.Time   `json:"last_tick"`
+	LastPair   string      `json:"last_pair"`
+	LastSignal string      `json:"last_signal"`
+	LastError  string      `json:"last_error"`
+	// Markets holds the last tick of each pair
+	Markets map[string]PairStatus `json:"markets"`
+}
+
+// PairStatus is the outcome of a pair's last tick.
+type PairStatus struct {
+	Ticks      int64     `json:"ticks"`
+	LastTick   time.Time `json:"last_tick"`
+	LastSignal string    `json:"last_signal"`
+	LastError  string    `json:"last_error"`
+	// Votes holds each child's signal when the strategy combines several
+	Votes []ChildVote `json:"votes,omitempty"`
+}
+
+// Engine drives a Strategy and Executor on a fixed interval and on market
+// updates, once per configured market with that market's settings. Use a
+// PairStrategy and PairExecutor to keep each pair's state separate.
+type Engine struct {
+	store    config.StateStore
+	client   Client
+	strategy Strategy
+	executor Executor
+	interval time.Duration
+	market   MarketDataSource
+	fees     *Fees
+	updates  <-chan MarketUpdate
+
+	mu     sync.Mutex
+	state  EngineState
+	cancel context.CancelFunc
+	done   chan struct{}
+	status EngineStatus
+}
+
+// NewEngine constructs an engine that ticks every interval for the configured pair.
+func NewEngine(store config.StateStore, client Client, strategy Strategy, executor Executor, interval time.Duration) *Engine {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	return &Engine{
+		store:    store,
+		client:   client,
+		strategy: strategy,
+		executor: executor,
+		interval: interval,
+		market:   NewRESTMarketData(client),
+		state:    EngineStopped,
+	}
+}
+
+// SetUpdates makes the engine also tick whenever an update arrives on ch.
+// It must be called before Start.
+func (e *Engine) SetUpdates(ch <-chan MarketUpdate) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.updates = ch
+}
+
+// SetMarketData replaces the REST order book as the source for scheduled ticks.
+// It must be called before Start.
+func (e *Engine) SetMarketData(src MarketDataSource) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.market = src
+}
+
+// SetFees makes each tick use the pair's account fees, so strategies and
+// executors see the actual round-trip cost. It must be called before Start.
+func (e *Engine) SetFees(f *Fees) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.fees = f
+}
+
+// Start launches the engine loop.
+func (e *Engine) Start() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.state != EngineStopped {
+		return ErrEngineRunning
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	e.cancel = cancel
+	e.done = make(chan struct{})
+	e.state = EngineRunning
+	go e.run(ctx, e.updates, e.done)
+	return nil
+}
+
+// Pause keeps the loop alive but skips ticks until Resume.
+func (e *Engine) Pause() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.state != EngineRunning {
+		return ErrEngineNotRunning
+	}
+	e.state = EnginePaused
+	return nil
+}
+
+// Resume continues ticking after Pause.
+func (e *Engine) Resume() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.state != EnginePaused {
+		return ErrEngineNotPaused
+	}
+	e.state = EngineRunning
+	return nil
+}
+
+// Stop ends the engine loop and waits for the current tick to finish.
+func (e *Engine) Stop() error {
+	e.mu.Lock()
+	if e.state == EngineStopped {
+		e.mu.Unlock()
+		return ErrEngineNotRunning
+	}
+	cancel, done := e.cancel, e.done
+	e.state = EngineStopped
+	e.mu.Unlock()
+
+	cancel()
+	<-done
+	return nil
+}
+
+// Status returns a snapshot of the engine state and last tick.
+func (e *Engine) Status() EngineStatus {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	st := e.status
+	st.State = e.state
+	st.Interval = e.interval.String()
+	st.Pairs = append([]string(nil), e.status.Pairs...)
+	st.Markets = make(map[string]PairStatus, len(e.status.Markets))
+	for pair, ps := range e.status.Markets {
+		st.Markets[pair] = ps
+	}
+	return st
+}
+
+// run is the engine loop; it exits when ctx is cancelled.
+func (e *Engine) run(ctx context.Context, updates <-chan MarketUpdate, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	e.tickAll(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			e.tickAll(ctx)
+		case u, ok := <-updates:
+			if !ok {
+				updates = nil
+				continue
+			}
+			raw, err := e.loadConfig()
+			if err != nil {
+				e.record(u.Pair, SignalNone, nil, err)
+				continue
+			}
+			if !containsPair(e.pairs(raw), u.Pair) {
+				continue // update for a pair this engine does not trade
+			}
+			md := u.Data
+			e.tick(ctx, ConfigFromStore(raw.ForPair(u.Pair)), &md)
+		}
+	}
+}
+
+// tickAll runs one step for every configured market.
+func (e *Engine) tickAll(ctx context.Context) {
+	raw, err := e.loadConfig()
+	if err != nil {
+		e.record("", SignalNone, nil, err)
+		return
+	}
+	for _, pair := range e.pairs(raw) {
+		e.tick(ctx, ConfigFromStore(raw.ForPair(pair)), nil)
+	}
+}
+
+// tick runs strategy and executor for cfg.Pair; md is fetched when nil.
+func (e *Engine) tick(ctx context.Context, cfg Config, md *MarketData) {
+	e.mu.Lock()
+	paused := e.state != EngineRunning
+	e.mu.Unlock()
+	if paused || ctx.Err() != nil {
+		return
+	}
+	if md == nil {
+		data, err := e.market.MarketData(ctx, cfg.Pair)
+		if err != nil {
+			e.record(cfg.Pair, SignalNone, nil, err)
+			return
+		}
+		md = &data
+	}
+	cfg = e.fees.Apply(ctx, cfg)
+	ev := TickEvent(*md)
+	if vs, ok := e.market.(VolumeSource); ok {
+		volume, err := vs.TradedVolume(ctx, cfg.Pair)
+		if err != nil {
+			fmt.Printf("Traded volume for %s: %v\n", cfg.Pair, err)
+		}
+		ev.Volume = volume
+	}
+	if cfg.StrategyDepth {
+		bids, asks, err := e.market.OrderBook(ctx, cfg.Pair)
+		if err != nil {
+			fmt.Printf("Order book for %s: %v\n", cfg.Pair, err)
+		}
+		ev.Bids, ev.Asks = bids, asks
+	}
+	sig := AdaptStrategy(e.strategy).OnEvent(ev, cfg)
+	err := e.executor.Execute(ctx, sig, *md, cfg)
+	e.record(cfg.Pair, sig, e.votes(cfg.Pair), err)
+}
+
+// votes returns the child signals behind pair's last signal, if the
+// strategy reports them.
+func (e *Engine) votes(pair string) []ChildVote {
+	switch s := e.strategy.(type) {
+	case *PairStrategy:
+		return s.Votes(pair)
+	case Voter:
+		return s.Votes()
+	}
+	return nil
+}
+
+// record stores the outcome of a tick for Status.
+func (e *Engine) record(pair string, sig Signal, votes []ChildVote, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := time.Now()
+	e.status.Ticks++
+	e.status.LastTick = now
+	e.status.LastPair = pair
+	e.status.LastSignal = sig.String()
+	e.status.LastError = ""
+	if err != nil {
+		e.status.LastError = err.Error()
+	}
+	if pair == "" {
+		return
+	}
+	if e.status.Markets == nil {
+		e.status.Markets = make(map[string]PairStatus)
+	}
+	ps := e.status.Markets[pair]
+	ps.Ticks++
+	ps.LastTick = now
+	ps.LastSignal = e.status.LastSignal
+	ps.LastError = e.status.LastError
+	ps.Votes = votes
+	e.status.Markets[pair] = ps
+	EngineTicks.WithLabelValues(pair, ps.LastSignal).Inc()
+}
+
+// loadConfig reads the current config so edits via PUT /config apply on the next tick.
+func (e *Engine) loadConfig() (*config.Config, error) {
+	raw, err := e.store.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+	return raw, nil
+}
+
+// pairs returns the markets traded on each tick.
+func (e *Engine) pairs(raw *config.Config) []string {
+	pairs := raw.Pairs()
+	e.mu.Lock()
+	e.status.Pairs = pairs
+	e.mu.Unlock()
+	return pairs
+}
+
+// containsPair reports whether pair is in pairs.
+func containsPair(pairs []string, pair string) bool {
+	for _, p := range pairs {
+		if p == pair {
+			return true
+		}
+	}
+	return false
+}
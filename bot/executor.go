@@ -2,18 +2,46 @@ package bot
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
+
+	luno "github.com/luno/luno-go"
 )
 
+// ErrMaxDrawdown is returned when realized drawdown exceeds Config.MaxDrawdown.
+var ErrMaxDrawdown = errors.New("max drawdown exceeded")
+
 // SimulatedExecutor enforces risk controls and simulates order execution.
+// Orders are filled by Fills; a nil model fills at the mid. Fees set in
+// Config replace the model's fees. With a Ledger, fills move its virtual
+// balances and orders it cannot pay for are rejected. Slices of a larger
+// order (Config.SliceShare) add to an open position and close it part by
+// part. Its methods are safe to call from concurrent chains and jobs; read
+// the exported fields directly only when nothing else is using it.
 type SimulatedExecutor struct {
-	Position            float64   // current position size
-	EntryPrice          float64   // price at entry
-	TotalPnL            float64   // cumulative PnL
-	PeakPnL             float64   // highest PnL
-	MaxDrawdownExceeded bool      // flag if drawdown breached
-	LastTradeTime       time.Time // last execution timestamp
+	Position            float64          // current position size
+	EntryPrice          float64          // price at entry
+	TotalPnL            float64          // cumulative PnL, net of fees
+	TotalFees           float64          // cumulative fees paid
+	PeakPnL             float64          // highest PnL
+	MaxDrawdownExceeded bool             // flag if drawdown breached
+	LastTradeTime       time.Time        // last execution timestamp
+	Fills               FillModel        // fill model; nil means MidFill with no fees
+	Market              MarketDataSource // optional order book depth for Fills
+	Markets             *Markets         // optional pair precision, limits and status
+	Ledger              *PaperLedger     // optional virtual balances; nil trades without funds checks
+
+	mu       sync.Mutex
+	entryFee float64     // fee paid on the open position, charged to PnL on exit
+	pending  *simPending // signal waiting out the model's latency
+}
+
+// simPending is a signal placed but not yet at the market.
+type simPending struct {
+	sig Signal
+	due time.Time
 }
 
 // NewSimulatedExecutor constructs a new Simulation executor.
@@ -22,33 +50,108 @@ func NewSimulatedExecutor() *SimulatedExecutor {
 }
 
 // Execute processes a trading signal using market data and config, enforcing limits.
+// With a latency in the fill model the signal is filled against the first
+// market data at or after the delay, and new signals are ignored until then.
 func (e *SimulatedExecutor) Execute(ctx context.Context, sig Signal, md MarketData, cfg Config) error {
-	// cooldown enforcement
-	if !e.LastTradeTime.IsZero() && md.Timestamp.Sub(e.LastTradeTime) < cfg.Cooldown {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	model := e.fillModel()
+	// charge the fees in cfg, e.g. the account's rates applied by the engine
+	if fm, ok := model.(FeeModel); ok && (cfg.MakerFee > 0 || cfg.TakerFee > 0) {
+		model = fm.WithFees(FeeRates{Maker: cfg.MakerFee, Taker: cfg.TakerFee})
+	}
+	if p := e.pending; p != nil {
+		if md.Timestamp.Before(p.due) {
+			return nil
+		}
+		e.pending = nil
+		return e.fill(ctx, model, p.sig, md, cfg)
+	}
+
+	if sig == SignalNone {
+		return nil
+	}
+	// cooldown between orders; slices of one signal share its timestamp,
+	// and exits, are not held back
+	sameSignal := cfg.SliceShare > 0 && md.Timestamp.Equal(e.LastTradeTime)
+	exit := sig == SignalSell && e.Position > 0
+	if !e.LastTradeTime.IsZero() && !sameSignal && !exit && md.Timestamp.Sub(e.LastTradeTime) < cfg.Cooldown {
 		return nil
 	}
 	e.LastTradeTime = md.Timestamp
 
-	price := (md.Bid + md.Ask) / 2
+	if d := model.Latency(); d > 0 && sig != SignalNone {
+		e.pending = &simPending{sig: sig, due: md.Timestamp.Add(d)}
+		return nil
+	}
+	return e.fill(ctx, model, sig, md, cfg)
+}
+
+// fill applies sig to the position at the prices model gives for md.
+func (e *SimulatedExecutor) fill(ctx context.Context, model FillModel, sig Signal, md MarketData, cfg Config) error {
+	q := Quote{Time: md.Timestamp, Bid: md.Bid, Ask: md.Ask, High: md.Ask, Low: md.Bid}
+	if e.Market != nil && sig != SignalNone {
+		bids, asks, err := e.Market.OrderBook(ctx, cfg.Pair)
+		if err != nil {
+			return fmt.Errorf("order book: %w", err)
+		}
+		q.Bids, q.Asks = bids, asks
+	}
 
 	switch sig {
 	case SignalBuy:
-		// only enter if no position
-		if e.Position != 0 {
+		// a whole order only enters if no position; slices build it up
+		if e.Position != 0 && !e.Markets.Dust(cfg.Pair, e.Position) && cfg.SliceShare == 0 {
 			return nil
 		}
-		if cfg.StakeSize > cfg.PositionLimit {
-			return fmt.Errorf("stake size %.2f > position limit %.2f", cfg.StakeSize, cfg.PositionLimit)
+		if e.Position+cfg.StakeSize > cfg.PositionLimit+fillEpsilon {
+			return fmt.Errorf("stake size %.2f on position %.2f > position limit %.2f", cfg.StakeSize, e.Position, cfg.PositionLimit)
+		}
+		volume, err := e.orderVolume(luno.OrderTypeBid, md, cfg.StakeSize, cfg)
+		if err != nil || volume == 0 {
+			return err
+		}
+		f := model.Take(SimOrder{Type: luno.OrderTypeBid, Volume: volume}, q)
+		if f.Volume == 0 {
+			return nil // no liquidity
 		}
-		e.Position = cfg.StakeSize
-		e.EntryPrice = price
+		if err := e.book(cfg, luno.OrderTypeBid, f); err != nil {
+			return err
+		}
+		total := e.Position + f.Volume
+		e.EntryPrice = (e.EntryPrice*e.Position + f.Price*f.Volume) / total
+		e.Position = total
+		e.entryFee += f.Fee
+		e.TotalFees += f.Fee
 	case SignalSell:
-		// only exit if in position
-		if e.Position == 0 {
+		// a whole order closes the position, a slice its share of it; dust
+		// below the minimum volume stays
+		volume := exitVolume(e.Markets, cfg.Pair, e.Position, cfg.SliceShare)
+		if volume == 0 {
 			return nil
 		}
-		profit := (price - e.EntryPrice) * e.Position
+		volume, err := e.orderVolume(luno.OrderTypeAsk, md, volume, cfg)
+		if err != nil || volume == 0 {
+			return err
+		}
+		f := model.Take(SimOrder{Type: luno.OrderTypeAsk, Volume: volume}, q)
+		if f.Volume == 0 {
+			return nil // no liquidity
+		}
+		if err := e.book(cfg, luno.OrderTypeAsk, f); err != nil {
+			return err
+		}
+		// charge the share of the entry fee for the volume closed
+		entryFee := e.entryFee * f.Volume / e.Position
+		profit := (f.Price-e.EntryPrice)*f.Volume - entryFee - f.Fee
 		e.TotalPnL += profit
+		e.TotalFees += f.Fee
+		e.entryFee -= entryFee
+		// the fill is booked, so the position closes even past the drawdown limit
+		e.Position -= f.Volume
+		if e.Position <= fillEpsilon {
+			e.Position, e.entryFee = 0, 0
+		}
 		// update peak for drawdown
 		if e.TotalPnL > e.PeakPnL {
 			e.PeakPnL = e.TotalPnL
@@ -56,17 +159,102 @@ func (e *SimulatedExecutor) Execute(ctx context.Context, sig Signal, md MarketDa
 		drawdown := e.PeakPnL - e.TotalPnL
 		if drawdown > cfg.MaxDrawdown {
 			e.MaxDrawdownExceeded = true
-			return fmt.Errorf("max drawdown %.2f exceeded", cfg.MaxDrawdown)
+			return fmt.Errorf("%w: drawdown %.2f, limit %.2f", ErrMaxDrawdown, drawdown, cfg.MaxDrawdown)
 		}
-		e.Position = 0
 	}
 	return nil
 }
 
-// CancelAll resets any open position.
-func (e *SimulatedExecutor) CancelAll(ctx context.Context) error {
-	if e.Position != 0 {
-		e.Position = 0
+// book moves the ledger's balances for a fill, rejecting it as a
+// *RiskError when the balances cannot cover it.
+func (e *SimulatedExecutor) book(cfg Config, typ luno.OrderType, f FillResult) error {
+	if e.Ledger == nil {
+		return nil
+	}
+	if err := e.Ledger.Trade(cfg.Pair, typ, f.Price, f.Volume, f.Fee); err != nil {
+		RiskRejections.WithLabelValues(riskCheckNames[ErrInsufficientFunds], cfg.Pair).Inc()
+		return &RiskError{Check: ErrInsufficientFunds, Pair: cfg.Pair, Detail: err.Error()}
+	}
+	return nil
+}
+
+// orderVolume applies the pair's market rules, when set, to an order for
+// volume, returning zero while the market is not active.
+func (e *SimulatedExecutor) orderVolume(typ luno.OrderType, md MarketData, volume float64, cfg Config) (float64, error) {
+	if e.Markets == nil {
+		return volume, nil
+	}
+	_, v, err := e.Markets.Order(cfg.Pair, typ, (md.Bid+md.Ask)/2, volume, cfg.BumpMinVolume)
+	if errors.Is(err, ErrMarketInactive) {
+		return 0, nil
 	}
+	if err != nil {
+		return 0, err
+	}
+	if typ == luno.OrderTypeBid && v.Float64() > cfg.PositionLimit {
+		return 0, fmt.Errorf("minimum volume %s exceeds position limit %.2f", v, cfg.PositionLimit)
+	}
+	return v.Float64(), nil
+}
+
+// fillModel returns Fills, defaulting to a fee-free MidFill.
+func (e *SimulatedExecutor) fillModel() FillModel {
+	if e.Fills == nil {
+		return &MidFill{}
+	}
+	return e.Fills
+}
+
+// CancelAll drops a signal still waiting out the fill model's latency, the
+// simulator's only open order; the position is kept, as a filled one would be.
+func (e *SimulatedExecutor) CancelAll(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.pending = nil
 	return nil
 }
+
+// CurrentPosition returns the simulated position and its entry price.
+func (e *SimulatedExecutor) CurrentPosition() (position, entryPrice float64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.Position, e.EntryPrice
+}
+
+// RealizedPnL returns cumulative PnL on closed positions, net of fees.
+func (e *SimulatedExecutor) RealizedPnL() float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.TotalPnL
+}
+
+// SimulatedStats is a consistent snapshot of a SimulatedExecutor's account.
+type SimulatedStats struct {
+	Position            float64
+	EntryPrice          float64
+	TotalPnL            float64
+	TotalFees           float64
+	MaxDrawdownExceeded bool
+}
+
+// Stats returns the position and PnL as of the last completed order.
+func (e *SimulatedExecutor) Stats() SimulatedStats {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return SimulatedStats{
+		Position:            e.Position,
+		EntryPrice:          e.EntryPrice,
+		TotalPnL:            e.TotalPnL,
+		TotalFees:           e.TotalFees,
+		MaxDrawdownExceeded: e.MaxDrawdownExceeded,
+	}
+}
+
+// Restore replaces the simulated position with values rebuilt from the exchange.
+// Open orders are ignored since simulated orders fill immediately.
+func (e *SimulatedExecutor) Restore(position, entryPrice float64, open []luno.Order) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.Position = position
+	e.EntryPrice = entryPrice
+}
@@ -0,0 +1,173 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/luno/luno-bot/storage"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Exit reasons recorded by ExitManager.
+const (
+	ExitStopLoss     = "stop_loss"
+	ExitTakeProfit   = "take_profit"
+	ExitATRStop      = "atr_stop"
+	ExitTrailingStop = "trailing_stop"
+	ExitMaxHold      = "max_hold"
+)
+
+// defaultATRPeriod is used when Config.ATRPeriod is unset.
+const defaultATRPeriod = 14
+
+// PositionExits counts positions closed by ExitManager, labelled by reason and pair.
+var PositionExits = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "exits_total",
+	Help: "Positions exited by stop-loss, take-profit, trailing, ATR or holding time rules",
+}, []string{"reason", "pair"})
+
+// ExitStore persists exit records; storage.SQLiteStore implements it.
+type ExitStore interface {
+	SaveExit(storage.ExitRecord) error
+}
+
+// ExitManager wraps an Executor and turns ticks into sells when an open
+// position hits a stop-loss, take-profit, ATR stop, trailing stop or its
+// maximum holding time, as set in Config. Each exit is recorded with its
+// reason. Wrap the whole chain with it so it sees every tick the strategy does.
+type ExitManager struct {
+	Inner     Executor
+	Positions PositionSource
+	Store     ExitStore // optional
+
+	mu     sync.Mutex
+	open   map[string]*exitState
+	atr    atrSet
+	record []storage.ExitRecord
+}
+
+// exitState tracks an open position between ticks.
+type exitState struct {
+	entry  float64
+	volume float64
+	opened time.Time
+	high   float64 // highest bid since entry
+	reason string  // set once an exit has been triggered
+}
+
+// NewExitManager constructs an ExitManager; store may be nil.
+func NewExitManager(inner Executor, positions PositionSource, store ExitStore) *ExitManager {
+	return &ExitManager{
+		Inner:     inner,
+		Positions: positions,
+		Store:     store,
+		open:      make(map[string]*exitState),
+		atr:       make(atrSet),
+	}
+}
+
+// Execute checks the open position against the exit rules, replacing sig
+// with SignalSell while a rule is triggered, and delegates.
+func (m *ExitManager) Execute(ctx context.Context, sig Signal, md MarketData, cfg Config) error {
+	now := md.Timestamp
+	if now.IsZero() {
+		now = time.Now()
+	}
+	price := md.Bid
+	if price <= 0 {
+		price = (md.Bid + md.Ask) / 2
+	}
+
+	m.mu.Lock()
+	atr := m.updateATR(md, cfg)
+	st := m.track(cfg.Pair, price, now)
+	var rec *storage.ExitRecord
+	if st != nil && st.reason == "" && sig != SignalSell {
+		if reason := exitReason(st, price, atr, now, cfg); reason != "" {
+			st.reason = reason
+			rec = &storage.ExitRecord{Time: now, Pair: cfg.Pair, Reason: reason, EntryPrice: st.entry, Price: price, Volume: st.volume}
+			m.record = append(m.record, *rec)
+		}
+	}
+	triggered := st != nil && st.reason != ""
+	m.mu.Unlock()
+
+	if rec != nil {
+		PositionExits.WithLabelValues(rec.Reason, rec.Pair).Inc()
+		fmt.Printf("Exit %s on %s: entry %.2f, price %.2f, volume %.8f\n", rec.Reason, rec.Pair, rec.EntryPrice, rec.Price, rec.Volume)
+		if m.Store != nil {
+			if err := m.Store.SaveExit(*rec); err != nil {
+				fmt.Printf("Save exit: %v\n", err)
+			}
+		}
+	}
+	if triggered {
+		sig = SignalSell // keep exiting until the position is flat
+	}
+	err := m.Inner.Execute(ctx, sig, md, cfg)
+	// start the holding clock on the tick that opened the position
+	m.mu.Lock()
+	m.track(cfg.Pair, price, now)
+	m.mu.Unlock()
+	return err
+}
+
+// track syncs the pair's exit state with the current position and returns
+// it, or nil when flat. m.mu must be held.
+func (m *ExitManager) track(pair string, price float64, now time.Time) *exitState {
+	position, entry := m.Positions.CurrentPosition()
+	if position <= 0 || entry <= 0 {
+		delete(m.open, pair)
+		return nil
+	}
+	st := m.open[pair]
+	if st == nil || st.entry != entry {
+		st = &exitState{entry: entry, opened: now, high: price}
+		m.open[pair] = st
+	}
+	st.volume = position
+	if price > st.high {
+		st.high = price
+	}
+	return st
+}
+
+// updateATR feeds the tick into the pair's ATR when the ATR stop is enabled
+// and returns its value once ready. m.mu must be held.
+func (m *ExitManager) updateATR(md MarketData, cfg Config) float64 {
+	if cfg.ATRStopMultiplier <= 0 {
+		return 0
+	}
+	return m.atr.update(md, cfg)
+}
+
+// exitReason returns the first rule triggered at price, or "" when none is.
+func exitReason(st *exitState, price, atr float64, now time.Time, cfg Config) string {
+	switch {
+	case cfg.StopLossPct > 0 && price <= st.entry*(1-cfg.StopLossPct/100):
+		return ExitStopLoss
+	case cfg.ATRStopMultiplier > 0 && atr > 0 && price <= st.entry-cfg.ATRStopMultiplier*atr:
+		return ExitATRStop
+	case cfg.TrailingStopPct > 0 && price <= st.high*(1-cfg.TrailingStopPct/100):
+		return ExitTrailingStop
+	case cfg.TakeProfitPct > 0 && price >= st.entry*(1+cfg.TakeProfitPct/100):
+		return ExitTakeProfit
+	case cfg.MaxHoldSeconds > 0 && now.Sub(st.opened) >= time.Duration(cfg.MaxHoldSeconds)*time.Second:
+		return ExitMaxHold
+	}
+	return ""
+}
+
+// Exits returns the exits triggered since start, oldest first.
+func (m *ExitManager) Exits() []storage.ExitRecord {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]storage.ExitRecord(nil), m.record...)
+}
+
+// CancelAll delegates cancellation.
+func (m *ExitManager) CancelAll(ctx context.Context) error {
+	return m.Inner.CancelAll(ctx)
+}
@@ -0,0 +1,64 @@
+package bot
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestExitManagerRules(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tick := func(at time.Duration, mid float64) MarketData {
+		return MarketData{Bid: mid - 0.5, Ask: mid + 0.5, Timestamp: t0.Add(at)}
+	}
+	base := Config{Pair: "XBTZAR", StakeSize: 1, PositionLimit: 1, MaxDrawdown: 1e9}
+
+	cases := []struct {
+		name   string
+		cfg    func(*Config)
+		prices []float64 // one tick a minute after entering at 100
+		want   string
+		at     int // index of the tick that exits
+	}{
+		{"stop loss", func(c *Config) { c.StopLossPct = 5 }, []float64{98, 96, 95.4}, ExitStopLoss, 2},
+		{"take profit", func(c *Config) { c.TakeProfitPct = 3 }, []float64{101, 103.6}, ExitTakeProfit, 1},
+		{"trailing stop", func(c *Config) { c.TrailingStopPct = 2 }, []float64{105, 110, 108, 107.7}, ExitTrailingStop, 3},
+		{"max hold", func(c *Config) { c.MaxHoldSeconds = 150 }, []float64{100, 100, 100}, ExitMaxHold, 2},
+		{"atr stop", func(c *Config) { c.ATRStopMultiplier = 2; c.ATRPeriod = 2 }, []float64{99.5, 98.5, 96.5}, ExitATRStop, 2},
+		{"disabled", func(c *Config) {}, []float64{50, 200}, "", -1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := base
+			tc.cfg(&cfg)
+			sim := NewSimulatedExecutor()
+			m := NewExitManager(sim, sim, nil)
+			ctx := context.Background()
+			if err := m.Execute(ctx, SignalBuy, tick(0, 100), cfg); err != nil || sim.Position != 1 {
+				t.Fatalf("Entry failed: %v, position %v", err, sim.Position)
+			}
+			exited := -1
+			for i, p := range tc.prices {
+				if err := m.Execute(ctx, SignalNone, tick(time.Duration(i+1)*time.Minute, p), cfg); err != nil {
+					t.Fatal(err)
+				}
+				if sim.Position == 0 && exited < 0 {
+					exited = i
+				}
+			}
+			if exited != tc.at {
+				t.Fatalf("Exited at tick %d, want %d", exited, tc.at)
+			}
+			exits := m.Exits()
+			if tc.want == "" {
+				if len(exits) != 0 {
+					t.Errorf("Unexpected exits %+v", exits)
+				}
+				return
+			}
+			if len(exits) != 1 || exits[0].Reason != tc.want {
+				t.Errorf("Exits %+v, want one %s", exits, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	luno "github.com/luno/luno-go"
+)
+
+// defaultMakerTimeout bounds a maker order when Config.MakerTimeoutSeconds is unset.
+const defaultMakerTimeout = time.Minute
+
+// MakerExecutor places post-only limit orders at the best bid for buys and
+// the best ask for sells, so fills pay maker fees. While the order works it
+// is cancelled and replaced whenever the touch moves more than
+// Config.MakerRepriceTicks ticks from its price. After
+// Config.MakerTimeoutSeconds the order is stopped and, with
+// Config.MakerCrossOnTimeout, the remainder crosses the spread.
+// Position and order tracking are shared with the wrapped LunoExecutor.
+type MakerExecutor struct {
+	*LunoExecutor
+	Market MarketDataSource // touch used to price orders
+}
+
+// NewMakerExecutor constructs a MakerExecutor placing orders through live.
+func NewMakerExecutor(live *LunoExecutor, market MarketDataSource) *MakerExecutor {
+	if market == nil {
+		market = NewRESTMarketData(live.client)
+	}
+	return &MakerExecutor{LunoExecutor: live, Market: market}
+}
+
+// Execute works a post-only order for sig until it fills or times out.
+func (m *MakerExecutor) Execute(ctx context.Context, sig Signal, md MarketData, cfg Config) error {
+	m.exec.Lock()
+	defer m.exec.Unlock()
+	typ, volume, ok, err := m.prepare(ctx, sig, cfg)
+	if !ok || err != nil {
+		return err
+	}
+	timeout := time.Duration(cfg.MakerTimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = defaultMakerTimeout
+	}
+	deadline := time.Now().Add(timeout)
+	band := float64(cfg.MakerRepriceTicks) * m.tick(cfg)
+
+	remaining := volume
+	var id string
+	var price float64
+	for {
+		if id == "" {
+			if price, err = m.makerPrice(ctx, cfg.Pair, typ); err != nil {
+				return err
+			}
+			id, err = m.post(ctx, cfg, typ, price, remaining, true)
+			if errors.Is(err, ErrMarketInactive) || (errors.Is(err, ErrBelowMinVolume) && remaining < volume) {
+				return nil // market closed, or only an unplaceable remainder is left
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if time.Now().After(deadline) {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(m.PollInterval):
+		}
+
+		o, err := m.orders.Refresh(ctx, id)
+		if err != nil {
+			return err
+		}
+		if o.Done() {
+			remaining -= o.FilledBase
+			id = ""
+			if o.State == OrderFilled || remaining <= fillEpsilon {
+				return nil
+			}
+			continue // stopped, or rejected for crossing; repost at the new touch
+		}
+		touch, err := m.makerPrice(ctx, cfg.Pair, typ)
+		if err != nil {
+			return err
+		}
+		if math.Abs(touch-price) > band {
+			o, err := m.orders.Cancel(ctx, id)
+			if err != nil {
+				return err
+			}
+			remaining -= o.FilledBase
+			id = ""
+			if remaining <= fillEpsilon {
+				return nil
+			}
+		}
+	}
+
+	o, err := m.orders.Cancel(ctx, id)
+	if err != nil {
+		return err
+	}
+	remaining -= o.FilledBase
+	if !cfg.MakerCrossOnTimeout || remaining <= fillEpsilon {
+		return nil
+	}
+	md, err = m.Market.MarketData(ctx, cfg.Pair)
+	if err != nil {
+		return err
+	}
+	cross := md.Ask
+	if typ == luno.OrderTypeAsk {
+		cross = md.Bid
+	}
+	id, err = m.post(ctx, cfg, typ, cross, remaining, false)
+	if errors.Is(err, ErrMarketInactive) || errors.Is(err, ErrBelowMinVolume) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("cross after maker timeout: %w", err)
+	}
+	return m.await(ctx, id)
+}
+
+// makerPrice returns the best bid for buys and the best ask for sells.
+func (m *MakerExecutor) makerPrice(ctx context.Context, pair string, typ luno.OrderType) (float64, error) {
+	md, err := m.Market.MarketData(ctx, pair)
+	if err != nil {
+		return 0, err
+	}
+	if isBuyOrder(typ) {
+		return md.Bid, nil
+	}
+	return md.Ask, nil
+}
+
+// tick returns the pair's price increment.
+func (m *MakerExecutor) tick(cfg Config) float64 {
+	if cfg.MakerTickSize > 0 {
+		return cfg.MakerTickSize
+	}
+	if mi, ok := m.Markets.Info(cfg.Pair); ok {
+		return math.Pow10(-int(mi.PriceScale))
+	}
+	return math.Pow10(-defaultScale)
+}
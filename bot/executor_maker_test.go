@@ -0,0 +1,106 @@
+package bot
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	luno "github.com/luno/luno-go"
+	dec "github.com/luno/luno-go/decimal"
+)
+
+// makerClient rests post-only orders until stopped and fills crossing orders.
+type makerClient struct {
+	Client
+	mu      sync.Mutex
+	posts   []luno.PostLimitOrderRequest
+	stopped map[string]bool
+}
+
+func (c *makerClient) PostLimitOrder(ctx context.Context, req *luno.PostLimitOrderRequest) (*luno.PostLimitOrderResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.posts = append(c.posts, *req)
+	return &luno.PostLimitOrderResponse{OrderId: strconv.Itoa(len(c.posts) - 1)}, nil
+}
+
+func (c *makerClient) GetOrderV2(ctx context.Context, req *luno.GetOrderV2Request) (*luno.GetOrderV2Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i, _ := strconv.Atoi(req.Id)
+	p := c.posts[i]
+	resp := &luno.GetOrderV2Response{OrderId: req.Id, Status: luno.StatusAwaiting, Base: dec.Zero(), Counter: dec.Zero()}
+	switch {
+	case !p.PostOnly:
+		resp.Status, resp.Base, resp.Counter = luno.StatusComplete, p.Volume, p.Price.Mul(p.Volume)
+	case c.stopped[req.Id]:
+		resp.Status = luno.StatusComplete
+	}
+	return resp, nil
+}
+
+func (c *makerClient) StopOrder(ctx context.Context, req *luno.StopOrderRequest) (*luno.StopOrderResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stopped[req.OrderId] = true
+	return &luno.StopOrderResponse{Success: true}, nil
+}
+
+func (c *makerClient) ListOrders(ctx context.Context, req *luno.ListOrdersRequest) (*luno.ListOrdersResponse, error) {
+	return &luno.ListOrdersResponse{}, nil
+}
+
+// steppedMarket quotes bid 100 for the first few calls, then 105.
+type steppedMarket struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (s *steppedMarket) MarketData(ctx context.Context, pair string) (MarketData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	if s.calls <= 3 {
+		return MarketData{Bid: 100, Ask: 101}, nil
+	}
+	return MarketData{Bid: 105, Ask: 106}, nil
+}
+
+func (s *steppedMarket) OrderBook(ctx context.Context, pair string) ([]luno.OrderBookEntry, []luno.OrderBookEntry, error) {
+	return nil, nil, nil
+}
+
+func TestMakerExecutorRepricesAndCrosses(t *testing.T) {
+	fc := &makerClient{stopped: make(map[string]bool)}
+	live := NewLunoExecutor(fc)
+	live.PollInterval = 5 * time.Millisecond
+	m := NewMakerExecutor(live, &steppedMarket{})
+	cfg := Config{
+		Pair: "XBTZAR", StakeSize: 1, PositionLimit: 1,
+		MakerRepriceTicks: 2, MakerTickSize: 1, MakerTimeoutSeconds: 1, MakerCrossOnTimeout: true,
+	}
+	if err := m.Execute(context.Background(), SignalBuy, MarketData{Bid: 100, Ask: 101}, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(fc.posts) != 3 {
+		t.Fatalf("Expected 3 orders, got %d", len(fc.posts))
+	}
+	want := []struct {
+		price    string
+		postOnly bool
+	}{{"100.00000000", true}, {"105.00000000", true}, {"106.00000000", false}}
+	for i, w := range want {
+		if p := fc.posts[i]; p.Price.String() != w.price || p.PostOnly != w.postOnly {
+			t.Errorf("Order %d: price %s post-only %v, want %s %v", i, p.Price, p.PostOnly, w.price, w.postOnly)
+		}
+	}
+	if !fc.stopped["0"] || !fc.stopped["1"] {
+		t.Errorf("Expected both maker orders stopped, got %v", fc.stopped)
+	}
+	if pos, entry := m.CurrentPosition(); pos != 1 || entry != 106 {
+		t.Errorf("Position %v at %v, want 1 at 106", pos, entry)
+	}
+}
@@ -0,0 +1,127 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/google/uuid"
+	luno "github.com/luno/luno-go"
+)
+
+// MarketExecutor places market orders. Before sending, it walks the order
+// book to estimate the average fill price for the order's volume and refuses
+// the signal with ErrSlippage when that estimate is more than
+// Config.MaxSlippageBps from the mid, or when the book is too thin to fill it.
+// Position and order tracking are shared with the wrapped LunoExecutor.
+type MarketExecutor struct {
+	*LunoExecutor
+	Market MarketDataSource // book used to estimate the fill
+}
+
+// NewMarketExecutor constructs a MarketExecutor placing orders through live.
+func NewMarketExecutor(live *LunoExecutor, market MarketDataSource) *MarketExecutor {
+	if market == nil {
+		market = NewRESTMarketData(live.client)
+	}
+	return &MarketExecutor{LunoExecutor: live, Market: market}
+}
+
+// Execute sends a market order for sig once its expected slippage is within limits.
+func (m *MarketExecutor) Execute(ctx context.Context, sig Signal, md MarketData, cfg Config) error {
+	m.exec.Lock()
+	defer m.exec.Unlock()
+	typ, volume, ok, err := m.prepare(ctx, sig, cfg)
+	if !ok || err != nil {
+		return err
+	}
+	bids, asks, err := m.Market.OrderBook(ctx, cfg.Pair)
+	if err != nil {
+		return err
+	}
+	price, slippage, err := estimateFill(typ, bids, asks, volume)
+	if err != nil {
+		return m.reject(cfg, err.Error())
+	}
+	if cfg.MaxSlippageBps > 0 && slippage > cfg.MaxSlippageBps {
+		return m.reject(cfg, fmt.Sprintf("%.1f bps expected at %.8f, limit %.1f bps", slippage, price, cfg.MaxSlippageBps))
+	}
+	id, err := m.postMarket(ctx, cfg, typ, price, volume)
+	if errors.Is(err, ErrMarketInactive) {
+		return nil // skip until trading resumes
+	}
+	if err != nil {
+		return err
+	}
+	return m.await(ctx, id)
+}
+
+// reject counts and returns a slippage refusal.
+func (m *MarketExecutor) reject(cfg Config, detail string) error {
+	RiskRejections.WithLabelValues(riskCheckNames[ErrSlippage], cfg.Pair).Inc()
+	return &RiskError{Check: ErrSlippage, Pair: cfg.Pair, Detail: detail}
+}
+
+// postMarket sends a market order rounded to the pair's market rules and
+// tracks it at the estimated price. Buys spend volume at price in counter
+// currency; sells sell volume of base.
+func (m *MarketExecutor) postMarket(ctx context.Context, cfg Config, typ luno.OrderType, price, volume float64) (string, error) {
+	p, v, err := m.Markets.Order(cfg.Pair, typ, price, volume, cfg.BumpMinVolume)
+	if err != nil {
+		return "", err
+	}
+	req := &luno.PostMarketOrderRequest{
+		Pair:             cfg.Pair,
+		BaseAccountId:    cfg.BaseAccountId,
+		CounterAccountId: cfg.CounterAccountId,
+		ClientOrderId:    uuid.New().String(),
+	}
+	if isBuyOrder(typ) {
+		if v.Float64() > cfg.PositionLimit {
+			return "", fmt.Errorf("minimum volume %s exceeds position limit %.2f", v, cfg.PositionLimit)
+		}
+		scale := int64(defaultScale)
+		if mi, ok := m.Markets.Info(cfg.Pair); ok {
+			scale = mi.PriceScale
+		}
+		req.Type = luno.OrderTypeBuy
+		req.CounterVolume = roundScale(v.Float64()*p.Float64(), scale, true)
+	} else {
+		req.Type = luno.OrderTypeSell
+		req.BaseVolume = v
+	}
+	resp, err := m.client.PostMarketOrder(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	m.orders.Track(resp.OrderId, cfg.Pair, req.Type, p.Float64(), v.Float64())
+	return resp.OrderId, nil
+}
+
+// estimateFill walks the asks for a buy or the bids for a sell and returns
+// the average price for volume and its distance from the mid in bps.
+func estimateFill(typ luno.OrderType, bids, asks []luno.OrderBookEntry, volume float64) (price, slippageBps float64, err error) {
+	if len(bids) == 0 || len(asks) == 0 {
+		return 0, 0, errors.New("order book is empty")
+	}
+	mid := (bids[0].Price.Float64() + asks[0].Price.Float64()) / 2
+	levels := asks
+	if !isBuyOrder(typ) {
+		levels = bids
+	}
+	var base, counter float64
+	for _, l := range levels {
+		v := math.Min(volume-base, l.Volume.Float64())
+		base += v
+		counter += v * l.Price.Float64()
+		if volume-base <= fillEpsilon {
+			break
+		}
+	}
+	if volume-base > fillEpsilon {
+		return 0, 0, fmt.Errorf("book depth %.8f below volume %.8f", base, volume)
+	}
+	price = counter / base
+	return price, math.Abs(price-mid) / mid * 1e4, nil
+}
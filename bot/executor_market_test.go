@@ -0,0 +1,75 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	luno "github.com/luno/luno-go"
+	dec "github.com/luno/luno-go/decimal"
+)
+
+// marketClient fills market buys in full at the counter amount sent.
+type marketClient struct {
+	Client
+	posts []luno.PostMarketOrderRequest
+}
+
+func (c *marketClient) PostMarketOrder(ctx context.Context, req *luno.PostMarketOrderRequest) (*luno.PostMarketOrderResponse, error) {
+	c.posts = append(c.posts, *req)
+	return &luno.PostMarketOrderResponse{OrderId: "M1"}, nil
+}
+
+func (c *marketClient) GetOrderV2(ctx context.Context, req *luno.GetOrderV2Request) (*luno.GetOrderV2Response, error) {
+	counter := c.posts[len(c.posts)-1].CounterVolume
+	return &luno.GetOrderV2Response{OrderId: req.Id, Status: luno.StatusComplete, Base: dec.NewFromInt64(1), Counter: counter}, nil
+}
+
+func (c *marketClient) ListOrders(ctx context.Context, req *luno.ListOrdersRequest) (*luno.ListOrdersResponse, error) {
+	return &luno.ListOrdersResponse{}, nil
+}
+
+// depthMarket serves a fixed two-level ask book.
+type depthMarket struct{}
+
+func (depthMarket) MarketData(ctx context.Context, pair string) (MarketData, error) {
+	return MarketData{Bid: 100, Ask: 101}, nil
+}
+
+func (depthMarket) OrderBook(ctx context.Context, pair string) ([]luno.OrderBookEntry, []luno.OrderBookEntry, error) {
+	return []luno.OrderBookEntry{bookLevel(100, 2)}, []luno.OrderBookEntry{bookLevel(101, 0.5), bookLevel(103, 1)}, nil
+}
+
+func TestMarketExecutorSlippageGuard(t *testing.T) {
+	ctx := context.Background()
+	md := MarketData{Bid: 100, Ask: 101}
+	fc := &marketClient{}
+	m := NewMarketExecutor(NewLunoExecutor(fc), depthMarket{})
+
+	// 1 XBT walks both levels for an average of 102, about 149 bps above the mid
+	cfg := Config{Pair: "XBTZAR", StakeSize: 1, PositionLimit: 1, MaxSlippageBps: 100}
+	if err := m.Execute(ctx, SignalBuy, md, cfg); !errors.Is(err, ErrSlippage) {
+		t.Fatalf("Expected ErrSlippage, got %v", err)
+	}
+	cfg.StakeSize, cfg.PositionLimit = 5, 5
+	if err := m.Execute(ctx, SignalBuy, md, cfg); !errors.Is(err, ErrSlippage) {
+		t.Fatalf("Expected ErrSlippage beyond book depth, got %v", err)
+	}
+	if len(fc.posts) != 0 {
+		t.Fatalf("Expected no orders sent, got %d", len(fc.posts))
+	}
+
+	cfg.StakeSize, cfg.PositionLimit, cfg.MaxSlippageBps = 1, 1, 200
+	if err := m.Execute(ctx, SignalBuy, md, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if len(fc.posts) != 1 {
+		t.Fatalf("Expected 1 order, got %d", len(fc.posts))
+	}
+	if p := fc.posts[0]; p.Type != luno.OrderTypeBuy || p.CounterVolume.String() != "102.00000000" {
+		t.Errorf("Order %s counter %s, want BUY 102.00000000", p.Type, p.CounterVolume)
+	}
+	if pos, entry := m.CurrentPosition(); pos != 1 || entry != 102 {
+		t.Errorf("Position %v at %v, want 1 at 102", pos, entry)
+	}
+}
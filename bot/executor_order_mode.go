@@ -0,0 +1,51 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+)
+
+// OrderModeExecutor places each order the way its Config.OrderMode asks:
+// "maker" through Maker, "market" through Market, and anything else through
+// Limit. It lets orders with different placement, such as AI market orders
+// and strategy limit orders, share one risk and reconciliation chain.
+type OrderModeExecutor struct {
+	Limit  Executor
+	Maker  Executor // optional; nil places maker orders through Limit
+	Market Executor // optional; nil refuses market orders
+}
+
+// NewOrderModeExecutor constructs an OrderModeExecutor.
+func NewOrderModeExecutor(limit, maker, market Executor) *OrderModeExecutor {
+	return &OrderModeExecutor{Limit: limit, Maker: maker, Market: market}
+}
+
+// Execute delegates to the executor for cfg.OrderMode.
+func (o *OrderModeExecutor) Execute(ctx context.Context, sig Signal, md MarketData, cfg Config) error {
+	switch cfg.OrderMode {
+	case "maker":
+		if o.Maker != nil {
+			return o.Maker.Execute(ctx, sig, md, cfg)
+		}
+	case "market":
+		if o.Market == nil {
+			return fmt.Errorf("market orders not available for %s", cfg.Pair)
+		}
+		return o.Market.Execute(ctx, sig, md, cfg)
+	}
+	return o.Limit.Execute(ctx, sig, md, cfg)
+}
+
+// CancelAll cancels through every executor, returning the first error.
+func (o *OrderModeExecutor) CancelAll(ctx context.Context) error {
+	var firstErr error
+	for _, e := range []Executor{o.Limit, o.Maker, o.Market} {
+		if e == nil {
+			continue
+		}
+		if err := e.CancelAll(ctx); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,125 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// POVExecutor works an order at a participation rate: every Interval it
+// executes Rate times the base volume traded on the pair since the previous
+// slice, as reported by ListTrades, until the stake is done. After
+// MaxDuration any remainder is left unexecuted rather than forced through,
+// so MaxDuration must be set; a quiet market would otherwise hold the order
+// open forever.
+type POVExecutor struct {
+	Inner       Executor
+	Client      Client        // trade history; a CachedClient also serves cached trades
+	Rate        float64       // share of traded volume to take per slice, e.g. 0.1
+	Interval    time.Duration // time between slices
+	MaxDuration time.Duration // give up on the remainder after this long
+	// Jobs runs orders in the background instead of blocking Execute; nil blocks.
+	Jobs *JobRunner
+
+	mu      sync.Mutex
+	seq     int
+	cancels map[int]context.CancelFunc // blocking orders in flight
+}
+
+// NewPOVExecutor constructs a POVExecutor taking rate of market volume every interval.
+func NewPOVExecutor(inner Executor, client Client, rate float64, interval, maxDuration time.Duration) *POVExecutor {
+	return &POVExecutor{Inner: inner, Client: client, Rate: rate, Interval: interval, MaxDuration: maxDuration}
+}
+
+// Execute submits the order as a background job, or with no Jobs works it
+// in place, sizing each child order from the volume traded since the last one.
+func (p *POVExecutor) Execute(ctx context.Context, sig Signal, md MarketData, cfg Config) error {
+	if sig == SignalNone {
+		return nil
+	}
+	if p.Rate <= 0 || p.Rate > 1 {
+		return fmt.Errorf("pov rate %.2f outside (0, 1]", p.Rate)
+	}
+	if p.MaxDuration <= 0 {
+		return fmt.Errorf("pov needs a max duration")
+	}
+	fmt.Printf("POVExecutor: taking %.0f%% of volume every %s\n", p.Rate*100, p.Interval)
+	if p.Jobs != nil {
+		if p.Jobs.Preempt(cfg.Pair, sig) {
+			fmt.Printf("POVExecutor: job already working %s %s, skipping signal\n", sig, cfg.Pair)
+			return nil
+		}
+		job, err := p.Jobs.SubmitPOV(sig, md, cfg, p.Rate, p.Interval, p.MaxDuration)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("POVExecutor: started job %d\n", job.ID)
+		return nil
+	}
+
+	ctx, done := p.track(ctx)
+	defer done()
+	deadline := time.Now().Add(p.MaxDuration)
+	last := time.Now()
+	remaining := cfg.StakeSize
+	for remaining > fillEpsilon {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(p.Interval):
+		}
+		traded, newest, err := tradedSince(ctx, p.Client, cfg.Pair, last)
+		if err != nil {
+			return err
+		}
+		last = newest
+		if child := math.Min(traded*p.Rate, remaining); child > fillEpsilon {
+			sliceCfg := cfg
+			sliceCfg.StakeSize, sliceCfg.SliceShare = child, child/remaining
+			if err := p.Inner.Execute(ctx, sig, md, sliceCfg); err != nil {
+				return err
+			}
+			remaining -= child
+		}
+		if !time.Now().Before(deadline) && remaining > fillEpsilon {
+			fmt.Printf("POVExecutor: %s elapsed, leaving %.8f of %.8f unexecuted\n", p.MaxDuration, remaining, cfg.StakeSize)
+			return nil
+		}
+	}
+	return nil
+}
+
+// track derives a context for a blocking order that CancelAll cancels, and
+// returns it with a func to release it when the order ends.
+func (p *POVExecutor) track(ctx context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cancels == nil {
+		p.cancels = make(map[int]context.CancelFunc)
+	}
+	p.seq++
+	id := p.seq
+	p.cancels[id] = cancel
+	return ctx, func() {
+		cancel()
+		p.mu.Lock()
+		delete(p.cancels, id)
+		p.mu.Unlock()
+	}
+}
+
+// CancelAll stops orders being worked, cancels background jobs and delegates cancellation.
+func (p *POVExecutor) CancelAll(ctx context.Context) error {
+	p.mu.Lock()
+	for _, cancel := range p.cancels {
+		cancel()
+	}
+	p.mu.Unlock()
+	if p.Jobs != nil {
+		p.Jobs.CancelAll()
+	}
+	return p.Inner.CancelAll(ctx)
+}
@@ -0,0 +1,123 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"math"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/luno/luno-bot/storage"
+	luno "github.com/luno/luno-go"
+	dec "github.com/luno/luno-go/decimal"
+)
+
+// tradesClient serves a list of market trades a page at a time, oldest first.
+type tradesClient struct {
+	Client
+	mu     sync.Mutex
+	trades []luno.PublicTrade
+}
+
+func (c *tradesClient) add(ts time.Time, volume float64, n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := 0; i < n; i++ {
+		c.trades = append(c.trades, luno.PublicTrade{Timestamp: luno.Time(ts.Add(time.Duration(i) * time.Millisecond)), Volume: dec.NewFromFloat64(volume, 8)})
+	}
+}
+
+func (c *tradesClient) ListTrades(ctx context.Context, req *luno.ListTradesRequest) (*luno.ListTradesResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var page []luno.PublicTrade
+	for _, t := range c.trades {
+		if time.Time(t.Timestamp).After(time.Time(req.Since)) && len(page) < tradesPage {
+			page = append(page, t)
+		}
+	}
+	return &luno.ListTradesResponse{Trades: page}, nil
+}
+
+func TestPOVExecutorParticipation(t *testing.T) {
+	// 150 trades span two pages
+	trades := &tradesClient{}
+	trades.add(time.Now().Add(time.Hour), 0.02, 150)
+	rec := &stakeRecorder{}
+	p := NewPOVExecutor(rec, trades, 0.1, time.Millisecond, 50*time.Millisecond)
+	if err := p.Execute(context.Background(), SignalBuy, MarketData{Bid: 100, Ask: 100}, Config{Pair: "XBTZAR", StakeSize: 0.5}); err != nil {
+		t.Fatal(err)
+	}
+	// 10% of the 3 traded, then nothing more traded before the deadline
+	if len(rec.stakes) != 1 || math.Abs(rec.stakes[0]-0.3) > 1e-9 {
+		t.Errorf("Slices %v, want [0.3]", rec.stakes)
+	}
+
+	if err := NewPOVExecutor(rec, trades, 0.1, time.Millisecond, 0).Execute(context.Background(), SignalBuy, MarketData{}, Config{StakeSize: 1}); err == nil {
+		t.Error("Expected an error without a max duration")
+	}
+
+	// CancelAll stops an order being worked
+	p.MaxDuration = time.Hour
+	errc := make(chan error, 1)
+	go func() {
+		errc <- p.Execute(context.Background(), SignalBuy, MarketData{Bid: 100, Ask: 100}, Config{Pair: "XBTZAR", StakeSize: 5})
+	}()
+	waitFor(t, func() bool { p.mu.Lock(); defer p.mu.Unlock(); return len(p.cancels) == 1 })
+	if err := p.CancelAll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-errc:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected cancellation, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("CancelAll did not stop the order")
+	}
+}
+
+func TestPOVExecutorRunsJob(t *testing.T) {
+	store, err := storage.NewSQLiteStore(filepath.Join(t.TempDir(), "jobs.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	trades := &tradesClient{}
+	base := time.Now().Add(time.Hour)
+	trades.add(base, 1.5, 2)
+
+	rec := &stakeRecorder{}
+	jobs := NewJobRunner("sim", rec, rec, store)
+	jobs.Trades = trades
+	p := NewPOVExecutor(rec, trades, 0.1, time.Millisecond, time.Hour)
+	p.Jobs = jobs
+	if err := p.Execute(context.Background(), SignalBuy, MarketData{Bid: 100, Ask: 100}, Config{Pair: "XBTZAR", StakeSize: 0.5}); err != nil {
+		t.Fatal(err)
+	}
+	all := jobs.Jobs()
+	if len(all) != 1 || all[0].Kind != "pov" {
+		t.Fatalf("Jobs %+v", all)
+	}
+	id := all[0].ID
+	waitFor(t, func() bool { j, _ := jobs.Job(id); return len(j.Slices) == 1 })
+
+	// the next slice is capped at what is left of the stake
+	trades.add(base.Add(time.Minute), 5, 1)
+	waitFor(t, func() bool { j, _ := jobs.Job(id); return j.Status == JobDone })
+	saved, err := store.ListJobs("sim")
+	if err != nil || len(saved) != 1 {
+		t.Fatalf("ListJobs: %v, %d jobs", err, len(saved))
+	}
+	want := []float64{0.3, 0.2}
+	if len(saved[0].Slices) != len(want) {
+		t.Fatalf("Saved slices %+v", saved[0].Slices)
+	}
+	for i, w := range want {
+		if s := saved[0].Slices[i]; math.Abs(s.Filled-w) > 1e-9 || s.Status != SliceDone {
+			t.Errorf("Slice %d: %+v, want %v filled", i, s, w)
+		}
+	}
+}
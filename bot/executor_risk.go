@@ -0,0 +1,225 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Risk check failures. RiskExecutor returns them wrapped in a *RiskError.
+var (
+	ErrCooldown      = errors.New("cooldown active")
+	ErrMaxNotional   = errors.New("order notional above limit")
+	ErrDailyVolume   = errors.New("daily traded volume limit reached")
+	ErrPriceBand     = errors.New("price outside band around mid")
+	ErrMaxOpenOrders = errors.New("too many open orders")
+	ErrPairExposure  = errors.New("pair exposure limit reached")
+	ErrSlippage      = errors.New("expected slippage above limit")
+)
+
+// riskCheckNames labels rejections in metrics and Rejections.
+var riskCheckNames = map[error]string{
+	ErrCooldown:          "cooldown",
+	ErrMaxNotional:       "max_notional",
+	ErrDailyVolume:       "daily_volume",
+	ErrPriceBand:         "price_band",
+	ErrMaxOpenOrders:     "max_open_orders",
+	ErrPairExposure:      "pair_exposure",
+	ErrSlippage:          "slippage",
+	ErrInsufficientFunds: "insufficient_funds",
+}
+
+// RiskRejections counts rejected signals, labelled by check and pair.
+var RiskRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "risk_rejections_total",
+	Help: "Signals rejected by pre-trade risk checks",
+}, []string{"check", "pair"})
+
+// RiskError describes a rejected signal. errors.Is matches its Check.
+type RiskError struct {
+	Check  error
+	Pair   string
+	Detail string
+}
+
+func (e *RiskError) Error() string {
+	return fmt.Sprintf("risk check %s on %s: %s", e.Check, e.Pair, e.Detail)
+}
+
+func (e *RiskError) Unwrap() error { return e.Check }
+
+// PositionSource reports a filled position and its entry price.
+type PositionSource interface {
+	CurrentPosition() (position, entryPrice float64)
+}
+
+// RiskExecutor wraps an Executor with pre-trade checks driven by Config:
+// cooldown between orders, max order notional, max daily traded volume, a
+// price band around the mid, max open orders and per-pair exposure. The
+// notional, daily volume and exposure limits apply only to buys, and sells
+// that reduce a position skip the cooldown and price band, so an open
+// position can always be exited, e.g. by a stop-loss. With Positions, daily
+// volume and the cooldown count only orders that moved the position, not
+// signals the inner executor ignored. Place it below sizing so it sees the
+// final stake.
+type RiskExecutor struct {
+	Inner     Executor
+	Positions PositionSource   // optional; required for the exposure limit, sell volume and counting fills
+	Orders    *OrderManager    // optional; required for the open order limit
+	Market    MarketDataSource // optional reference mid for the price band; defaults to md.Last
+
+	mu          sync.Mutex
+	lastTrade   map[string]time.Time
+	day         time.Time
+	dailyVolume float64 // quote value filled since day began
+	rejections  map[string]int
+}
+
+// NewRiskExecutor constructs a RiskExecutor.
+func NewRiskExecutor(inner Executor, positions PositionSource, orders *OrderManager) *RiskExecutor {
+	return &RiskExecutor{
+		Inner:      inner,
+		Positions:  positions,
+		Orders:     orders,
+		lastTrade:  make(map[string]time.Time),
+		rejections: make(map[string]int),
+	}
+}
+
+// Execute runs the checks for a trading signal and delegates when all pass.
+func (r *RiskExecutor) Execute(ctx context.Context, sig Signal, md MarketData, cfg Config) error {
+	if sig == SignalNone {
+		return r.Inner.Execute(ctx, sig, md, cfg)
+	}
+	now := md.Timestamp
+	if now.IsZero() {
+		now = time.Now()
+	}
+	price := (md.Bid + md.Ask) / 2
+	var position float64
+	if r.Positions != nil {
+		position, _ = r.Positions.CurrentPosition()
+	}
+	volume := cfg.StakeSize
+	if sig == SignalSell {
+		volume = position
+		if cfg.SliceShare > 0 && cfg.SliceShare < 1 {
+			volume *= cfg.SliceShare
+		}
+	}
+	notional := price * volume
+
+	if err := r.check(ctx, sig, md, cfg, now, price, position, notional); err != nil {
+		r.reject(err)
+		return err
+	}
+	if err := r.Inner.Execute(ctx, sig, md, cfg); err != nil {
+		return err
+	}
+	// count what filled; without Positions every accepted order counts in full
+	filled := volume
+	if r.Positions != nil {
+		after, _ := r.Positions.CurrentPosition()
+		filled = math.Abs(after - position)
+	}
+	if filled <= fillEpsilon {
+		return nil
+	}
+	r.mu.Lock()
+	r.lastTrade[cfg.Pair] = now
+	r.dailyVolume += price * filled
+	r.mu.Unlock()
+	return nil
+}
+
+// check returns the first failing check as a *RiskError.
+func (r *RiskExecutor) check(ctx context.Context, sig Signal, md MarketData, cfg Config, now time.Time, price, position, notional float64) error {
+	fail := func(check error, format string, args ...interface{}) error {
+		return &RiskError{Check: check, Pair: cfg.Pair, Detail: fmt.Sprintf(format, args...)}
+	}
+
+	r.mu.Lock()
+	last := r.lastTrade[cfg.Pair]
+	if day := now.UTC().Truncate(24 * time.Hour); !day.Equal(r.day) {
+		r.day, r.dailyVolume = day, 0
+	}
+	traded := r.dailyVolume
+	r.mu.Unlock()
+
+	// slices of one signal (TWAP/VWAP) share its market data and are not held back
+	sameSignal := !md.Timestamp.IsZero() && md.Timestamp.Equal(last)
+	reducing := sig == SignalSell && position > 0
+	if cfg.Cooldown > 0 && !reducing && !last.IsZero() && !sameSignal && now.Sub(last) < cfg.Cooldown {
+		return fail(ErrCooldown, "last order %s ago, cooldown %s", now.Sub(last).Round(time.Second), cfg.Cooldown)
+	}
+	if cfg.PriceBandBps > 0 && !reducing {
+		ref := md.Last
+		if r.Market != nil {
+			if fresh, err := r.Market.MarketData(ctx, cfg.Pair); err == nil && fresh.Bid > 0 && fresh.Ask > 0 {
+				ref = (fresh.Bid + fresh.Ask) / 2
+			}
+		}
+		if price <= 0 {
+			return fail(ErrPriceBand, "no valid mid price")
+		}
+		if ref > 0 {
+			if dev := math.Abs(price-ref) / ref * 1e4; dev > cfg.PriceBandBps {
+				return fail(ErrPriceBand, "price %.2f is %.0fbps from reference %.2f, band %.0fbps", price, dev, ref, cfg.PriceBandBps)
+			}
+		}
+	}
+	if cfg.MaxOpenOrders > 0 && r.Orders != nil {
+		if n := len(r.Orders.Open(cfg.Pair)); n >= cfg.MaxOpenOrders {
+			return fail(ErrMaxOpenOrders, "%d open orders, limit %d", n, cfg.MaxOpenOrders)
+		}
+	}
+	if sig != SignalBuy {
+		return nil
+	}
+	if cfg.MaxOrderNotional > 0 && notional > cfg.MaxOrderNotional {
+		return fail(ErrMaxNotional, "order notional %.2f, limit %.2f", notional, cfg.MaxOrderNotional)
+	}
+	if cfg.MaxDailyVolume > 0 && traded+notional > cfg.MaxDailyVolume {
+		return fail(ErrDailyVolume, "traded %.2f today, order %.2f, limit %.2f", traded, notional, cfg.MaxDailyVolume)
+	}
+	if cfg.MaxPairExposure > 0 {
+		if exposure := (position + cfg.StakeSize) * price; exposure > cfg.MaxPairExposure {
+			return fail(ErrPairExposure, "exposure would be %.2f, limit %.2f", exposure, cfg.MaxPairExposure)
+		}
+	}
+	return nil
+}
+
+// reject counts a failed check.
+func (r *RiskExecutor) reject(err error) {
+	var re *RiskError
+	if !errors.As(err, &re) {
+		return
+	}
+	name := riskCheckNames[re.Check]
+	r.mu.Lock()
+	r.rejections[name]++
+	r.mu.Unlock()
+	RiskRejections.WithLabelValues(name, re.Pair).Inc()
+}
+
+// Rejections returns rejection counts keyed by check.
+func (r *RiskExecutor) Rejections() map[string]int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make(map[string]int, len(r.rejections))
+	for k, v := range r.rejections {
+		out[k] = v
+	}
+	return out
+}
+
+// CancelAll delegates cancellation.
+func (r *RiskExecutor) CancelAll(ctx context.Context) error {
+	return r.Inner.CancelAll(ctx)
+}
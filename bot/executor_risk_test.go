@@ -0,0 +1,114 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// countingExecutor records how many signals reach it.
+type countingExecutor struct{ calls int }
+
+func (c *countingExecutor) Execute(ctx context.Context, sig Signal, md MarketData, cfg Config) error {
+	c.calls++
+	return nil
+}
+func (c *countingExecutor) CancelAll(ctx context.Context) error { return nil }
+
+// fixedPosition is a PositionSource with a set position.
+type fixedPosition float64
+
+func (p fixedPosition) CurrentPosition() (float64, float64) { return float64(p), 0 }
+
+func TestRiskExecutorChecks(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	md := func(at time.Duration, mid float64) MarketData {
+		return MarketData{Bid: mid - 1, Ask: mid + 1, Last: mid, Timestamp: t0.Add(at)}
+	}
+	base := Config{Pair: "XBTZAR", StakeSize: 1}
+
+	cases := []struct {
+		name string
+		cfg  func(c *Config)
+		pos  float64
+		sig  Signal
+		md   MarketData
+		want error
+	}{
+		{"notional", func(c *Config) { c.MaxOrderNotional = 50 }, 0, SignalBuy, md(0, 100), ErrMaxNotional},
+		{"exposure", func(c *Config) { c.MaxPairExposure = 150 }, 1, SignalBuy, md(0, 100), ErrPairExposure},
+		{"band", func(c *Config) { c.PriceBandBps = 50 }, 0, SignalBuy, MarketData{Bid: 109, Ask: 111, Last: 100, Timestamp: t0}, ErrPriceBand},
+		{"sell ignores notional", func(c *Config) { c.MaxOrderNotional = 50 }, 1, SignalSell, md(0, 100), nil},
+		{"exit ignores band", func(c *Config) { c.PriceBandBps = 50 }, 1, SignalSell, MarketData{Bid: 89, Ask: 91, Last: 100, Timestamp: t0}, nil},
+	}
+	for _, tc := range cases {
+		inner := &countingExecutor{}
+		cfg := base
+		tc.cfg(&cfg)
+		r := NewRiskExecutor(inner, fixedPosition(tc.pos), nil)
+		err := r.Execute(context.Background(), tc.sig, tc.md, cfg)
+		if !errors.Is(err, tc.want) || (tc.want == nil && err != nil) {
+			t.Errorf("%s: got %v, expected %v", tc.name, err, tc.want)
+		}
+		var re *RiskError
+		if tc.want != nil && (!errors.As(err, &re) || inner.calls != 0) {
+			t.Errorf("%s: expected a *RiskError before reaching inner, got %T with %d calls", tc.name, err, inner.calls)
+		}
+	}
+}
+
+func TestRiskExecutorCooldownAndDailyVolume(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC)
+	inner := &countingExecutor{}
+	r := NewRiskExecutor(inner, nil, nil)
+	cfg := Config{Pair: "XBTZAR", StakeSize: 1, Cooldown: time.Minute, MaxDailyVolume: 250}
+	at := func(d time.Duration) MarketData { return MarketData{Bid: 100, Ask: 100, Timestamp: t0.Add(d)} }
+	ctx := context.Background()
+
+	if err := r.Execute(ctx, SignalBuy, at(0), cfg); err != nil {
+		t.Fatal(err)
+	}
+	// a second slice of the same signal is not a new order for cooldown
+	if err := r.Execute(ctx, SignalBuy, at(0), cfg); err != nil {
+		t.Fatalf("slice rejected: %v", err)
+	}
+	if err := r.Execute(ctx, SignalBuy, at(30*time.Second), cfg); !errors.Is(err, ErrCooldown) {
+		t.Errorf("expected cooldown, got %v", err)
+	}
+	if err := r.Execute(ctx, SignalBuy, at(2*time.Minute), cfg); !errors.Is(err, ErrDailyVolume) {
+		t.Errorf("expected daily volume limit, got %v", err)
+	}
+	// the limit resets at the next UTC day
+	if err := r.Execute(ctx, SignalBuy, at(time.Hour+time.Minute), cfg); err != nil {
+		t.Errorf("expected new day to reset volume, got %v", err)
+	}
+	got := r.Rejections()
+	if inner.calls != 3 || got["cooldown"] != 1 || got["daily_volume"] != 1 {
+		t.Errorf("got %d calls and rejections %v", inner.calls, got)
+	}
+}
+
+func TestRiskExecutorCountsFills(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	sim := NewSimulatedExecutor()
+	r := NewRiskExecutor(sim, sim, nil)
+	cfg := Config{Pair: "XBTZAR", StakeSize: 1, PositionLimit: 1, Cooldown: time.Minute, MaxDailyVolume: 150}
+	at := func(d time.Duration) MarketData { return MarketData{Bid: 100, Ask: 100, Timestamp: t0.Add(d)} }
+	ctx := context.Background()
+
+	if err := r.Execute(ctx, SignalBuy, at(0), cfg); err != nil {
+		t.Fatal(err)
+	}
+	// a stop-loss exit is not held back by the cooldown
+	if err := r.Execute(ctx, SignalSell, at(10*time.Second), cfg); err != nil {
+		t.Fatalf("exit rejected: %v", err)
+	}
+	// a sell with nothing to sell fills nothing and counts for nothing
+	if err := r.Execute(ctx, SignalSell, at(2*time.Minute), cfg); err != nil {
+		t.Fatal(err)
+	}
+	if r.dailyVolume != 200 || !r.lastTrade["XBTZAR"].Equal(t0.Add(10*time.Second)) {
+		t.Errorf("Counted %v traded, last at %v; want 200 at the exit", r.dailyVolume, r.lastTrade["XBTZAR"])
+	}
+}
@@ -2,12 +2,14 @@ package bot
 
 import (
 	"context"
+	"fmt"
 )
 
 // SizingExecutor wraps an Executor and applies position sizing.
 type SizingExecutor struct {
-	Inner Executor
-	Sizer PositionSizer
+	Inner  Executor
+	Sizer  PositionSizer
+	Equity EquitySource // live equity for signals; nil uses cfg.InitialEquity
 }
 
 // NewSizingExecutor constructs a SizingExecutor.
@@ -17,8 +19,17 @@ func NewSizingExecutor(inner Executor, sizer PositionSizer) *SizingExecutor {
 
 // Execute computes stake size via the sizer, updates cfg, and delegates execution.
 func (s *SizingExecutor) Execute(ctx context.Context, sig Signal, md MarketData, cfg Config) error {
+	// Only signals need live equity; the sizer still sees every tick
+	equity := cfg.InitialEquity
+	if s.Equity != nil && sig != SignalNone {
+		e, err := s.Equity.Equity(ctx, md, cfg)
+		if err != nil {
+			return fmt.Errorf("sizing equity: %w", err)
+		}
+		equity = e
+	}
 	// Compute dynamic stake size
-	size := s.Sizer.Size(cfg.InitialEquity, cfg)
+	size := s.Sizer.Size(equity, md, cfg)
 	cfg.StakeSize = size
 	return s.Inner.Execute(ctx, sig, md, cfg)
 }
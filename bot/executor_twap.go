@@ -3,14 +3,27 @@ package bot
 import (
 	"context"
 	"fmt"
+	"math/rand"
 	"time"
 )
 
-// TWAPExecutor slices large orders into multiple smaller orders.
+// TWAPExecutor slices large orders into multiple smaller orders. With
+// Jitter, slice sizes and the gaps between them vary randomly so the
+// schedule is harder to spot; with Deadline, the whole order completes
+// within that time of the signal.
 type TWAPExecutor struct {
 	Inner    Executor
 	Slices   int
 	Interval time.Duration
+	// Jitter varies each slice's size and the gap before it by up to this fraction (0-1); 0 is a plain TWAP.
+	Jitter float64
+	// Deadline bounds the whole schedule, background jobs included; gaps shrink
+	// to fit and any remainder goes out at the deadline. 0 = none.
+	Deadline time.Duration
+	// Rand drives Jitter; nil uses the math/rand default source.
+	Rand *rand.Rand
+	// Jobs runs multi-slice schedules in the background instead of blocking Execute; nil blocks.
+	Jobs *JobRunner
 }
 
 // NewTWAPExecutor creates a TWAP executor that executes orders in Slices over Interval durations.
@@ -21,6 +34,14 @@ func NewTWAPExecutor(inner Executor, slices int, interval time.Duration) *TWAPEx
 	return &TWAPExecutor{Inner: inner, Slices: slices, Interval: interval}
 }
 
+// NewRandomTWAPExecutor creates a TWAP executor whose slice sizes and timing
+// vary by up to jitter and which completes within deadline.
+func NewRandomTWAPExecutor(inner Executor, slices int, interval time.Duration, jitter float64, deadline time.Duration) *TWAPExecutor {
+	t := NewTWAPExecutor(inner, slices, interval)
+	t.Jitter, t.Deadline = jitter, deadline
+	return t
+}
+
 // Execute slices the execution into smaller timed chunks.
 func (t *TWAPExecutor) Execute(ctx context.Context, sig Signal, md MarketData, cfg Config) error {
 	// No action if no signal
@@ -28,28 +49,97 @@ func (t *TWAPExecutor) Execute(ctx context.Context, sig Signal, md MarketData, c
 		return nil
 	}
 	fmt.Printf("TWAPExecutor: executing %d slices every %s\n", t.Slices, t.Interval)
-	// Divide stake size across slices
-	sliceSize := cfg.StakeSize / float64(t.Slices)
-	for i := 0; i < t.Slices; i++ {
-		// configure this slice
-		sliceCfg := cfg
-		sliceCfg.StakeSize = sliceSize
-		if err := t.Inner.Execute(ctx, sig, md, sliceCfg); err != nil {
+	weights, delays := t.schedule()
+	if t.Jobs != nil && t.Slices > 1 {
+		if t.Jobs.Preempt(cfg.Pair, sig) {
+			fmt.Printf("TWAPExecutor: job already working %s %s, skipping signal\n", sig, cfg.Pair)
+			return nil
+		}
+		job, err := t.Jobs.SubmitSchedule("twap", sig, md, cfg, weights, delays, t.Interval, t.Deadline)
+		if err != nil {
 			return err
 		}
-		// wait for next slice if not last
-		if i < t.Slices-1 {
+		fmt.Printf("TWAPExecutor: started job %d\n", job.ID)
+		return nil
+	}
+	var deadline <-chan time.Time
+	if t.Deadline > 0 {
+		deadline = time.After(t.Deadline)
+	}
+	done := 0.0 // share of the stake executed
+	for i := 0; i < t.Slices; i++ {
+		// wait for this slice, or send everything left at the deadline
+		w := weights[i]
+		late := false
+		if i > 0 {
 			select {
 			case <-ctx.Done():
 				return ctx.Err()
-			case <-time.After(t.Interval):
+			case <-deadline:
+				w, late = 1-done, true
+			case <-time.After(delays[i]):
 			}
 		}
+		// configure this slice
+		sliceCfg := cfg
+		sliceCfg.StakeSize = cfg.StakeSize * w
+		sliceCfg.SliceShare = 1
+		if !late && done+w < 1 {
+			sliceCfg.SliceShare = w / (1 - done)
+		}
+		if err := t.Inner.Execute(ctx, sig, md, sliceCfg); err != nil {
+			return err
+		}
+		done += w
+		if late {
+			fmt.Printf("TWAPExecutor: deadline %s reached, sent remainder in slice %d\n", t.Deadline, i+1)
+			break
+		}
 	}
 	return nil
 }
 
-// CancelAll delegates cancellation.
+// schedule returns each slice's share of the stake and the wait before it.
+// Without Jitter the shares are equal and slices are Interval apart.
+func (t *TWAPExecutor) schedule() (weights []float64, delays []time.Duration) {
+	jitter := func() float64 {
+		if t.Jitter <= 0 {
+			return 1
+		}
+		u := rand.Float64()
+		if t.Rand != nil {
+			u = t.Rand.Float64()
+		}
+		return 1 + t.Jitter*(2*u-1)
+	}
+	weights = make([]float64, t.Slices)
+	delays = make([]time.Duration, t.Slices)
+	var sum float64
+	var total time.Duration
+	for i := range weights {
+		weights[i] = jitter()
+		sum += weights[i]
+		if i > 0 {
+			delays[i] = time.Duration(float64(t.Interval) * jitter())
+			total += delays[i]
+		}
+	}
+	for i := range weights {
+		weights[i] /= sum
+	}
+	if t.Deadline > 0 && total > t.Deadline {
+		scale := float64(t.Deadline) / float64(total)
+		for i := range delays {
+			delays[i] = time.Duration(float64(delays[i]) * scale)
+		}
+	}
+	return weights, delays
+}
+
+// CancelAll cancels background jobs and delegates cancellation.
 func (t *TWAPExecutor) CancelAll(ctx context.Context) error {
+	if t.Jobs != nil {
+		t.Jobs.CancelAll()
+	}
 	return t.Inner.CancelAll(ctx)
 }
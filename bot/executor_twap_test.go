@@ -0,0 +1,108 @@
+package bot
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestRandomTWAPMeetsDeadline(t *testing.T) {
+	rec := &stakeRecorder{}
+	tw := NewRandomTWAPExecutor(rec, 5, time.Hour, 0.5, 20*time.Millisecond)
+	tw.Rand = rand.New(rand.NewSource(1))
+	start := time.Now()
+	if err := tw.Execute(context.Background(), SignalBuy, MarketData{Bid: 100, Ask: 100}, Config{Pair: "XBTZAR", StakeSize: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Schedule took %s, deadline 20ms", elapsed)
+	}
+	if len(rec.stakes) == 0 {
+		t.Fatal("Expected slices")
+	}
+	var total float64
+	equal := true
+	for _, s := range rec.stakes {
+		total += s
+		equal = equal && math.Abs(s-rec.stakes[0]) < 1e-9
+	}
+	if math.Abs(total-1) > 1e-9 {
+		t.Errorf("Executed %v, want 1", total)
+	}
+	if equal && len(rec.stakes) > 1 {
+		t.Errorf("Expected randomized slice sizes, got %v", rec.stakes)
+	}
+}
+
+func TestStopLossPreemptsBuyJob(t *testing.T) {
+	ctx := context.Background()
+	sim := NewSimulatedExecutor()
+	jobs := NewJobRunner("sim", sim, sim, nil)
+	tw := NewTWAPExecutor(sim, 4, time.Hour)
+	tw.Jobs = jobs
+	exits := NewExitManager(tw, sim, nil)
+	cfg := Config{Pair: "XBTZAR", StakeSize: 1, PositionLimit: 1, MaxDrawdown: 1e9, StopLossPct: 5}
+	now := time.Now()
+
+	// the buy job fills its first slice and waits an hour for the next
+	if err := exits.Execute(ctx, SignalBuy, MarketData{Bid: 100, Ask: 100, Timestamp: now}, cfg); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { pos, _ := sim.CurrentPosition(); return pos > 0 })
+	buy := jobs.Jobs()[0]
+
+	// the stop cancels the rest of the buy and starts selling what was bought
+	if err := exits.Execute(ctx, SignalNone, MarketData{Bid: 94, Ask: 94, Timestamp: now.Add(time.Minute)}, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if j, _ := jobs.Job(buy.ID); j.Status != JobCancelled {
+		t.Errorf("Buy job %s, want cancelled", j.Status)
+	}
+	all := jobs.Jobs()
+	if len(all) != 2 || all[0].Side != "sell" || all[0].Volume != 0.25 {
+		t.Fatalf("Jobs after the stop %+v", all)
+	}
+	waitFor(t, func() bool { pos, _ := sim.CurrentPosition(); return pos < 0.25 })
+
+	// a repeated sell signal is dropped while the sell job works
+	if err := tw.Execute(ctx, SignalSell, MarketData{Bid: 94, Ask: 94, Timestamp: now.Add(2 * time.Minute)}, cfg); err != nil || len(jobs.Jobs()) != 2 {
+		t.Errorf("Repeated sell: %v, %d jobs", err, len(jobs.Jobs()))
+	}
+	jobs.CancelAll()
+}
+
+func TestTWAPJobFlushesAtDeadline(t *testing.T) {
+	rec := &stakeRecorder{}
+	jobs := NewJobRunner("sim", rec, nil, nil)
+	tw := NewRandomTWAPExecutor(rec, 4, time.Hour, 0, 30*time.Millisecond)
+	tw.Jobs = jobs
+	if err := tw.Execute(context.Background(), SignalBuy, MarketData{Bid: 100, Ask: 100}, Config{Pair: "XBTZAR", StakeSize: 1}); err != nil {
+		t.Fatal(err)
+	}
+	id := jobs.Jobs()[0].ID
+	waitFor(t, func() bool { j, _ := jobs.Job(id); return j.Slices[0].Status == SliceDone })
+
+	// a job paused past its deadline sends the remainder when resumed
+	if _, err := jobs.Pause(id); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(40 * time.Millisecond)
+	if _, err := jobs.Resume(id); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { j, _ := jobs.Job(id); return j.Status == JobDone })
+	j, _ := jobs.Job(id)
+	want := []string{SliceDone, SliceDone, SliceCancelled, SliceCancelled}
+	for i, s := range j.Slices {
+		if s.Status != want[i] {
+			t.Errorf("Slice %d %s, want %s", i, s.Status, want[i])
+		}
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if len(rec.stakes) != 2 || math.Abs(rec.stakes[1]-0.75) > 1e-9 {
+		t.Errorf("Stakes %v, want the 0.75 remainder in the second", rec.stakes)
+	}
+}
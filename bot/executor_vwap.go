@@ -14,9 +14,12 @@ import (
 type VWAPExecutor struct {
     Inner    Executor
     Client   Client
+    Market   MarketDataSource // order book for "orderbook" and "hybrid" weights
     Slices   int
     Interval time.Duration
     Store    *storage.SQLiteStore
+    // Jobs runs multi-slice schedules in the background instead of blocking Execute; nil blocks.
+    Jobs     *JobRunner
 }
 
 // NewVWAPExecutor constructs a VWAP executor that distributes execution over given slices and interval.
@@ -24,7 +27,7 @@ func NewVWAPExecutor(inner Executor, client Client, slices int, interval time.Du
     if slices <= 1 {
         slices = 1
     }
-    return &VWAPExecutor{Inner: inner, Client: client, Slices: slices, Interval: interval, Store: store}
+    return &VWAPExecutor{Inner: inner, Client: client, Market: NewRESTMarketData(client), Slices: slices, Interval: interval, Store: store}
 }
 
 // Execute slices execution based on VWAP; currently evenly weighted as placeholder.
@@ -53,6 +56,18 @@ func (v *VWAPExecutor) Execute(ctx context.Context, sig Signal, md MarketData, c
             weights[i] = 1.0 / float64(v.Slices)
         }
     }
+    if v.Jobs != nil && v.Slices > 1 {
+        if v.Jobs.Preempt(cfg.Pair, sig) {
+            fmt.Printf("VWAPExecutor: job already working %s %s, skipping signal\n", sig, cfg.Pair)
+            return nil
+        }
+        job, err := v.Jobs.Submit("vwap", sig, md, cfg, weights, v.Interval)
+        if err != nil {
+            return err
+        }
+        fmt.Printf("VWAPExecutor: started job %d\n", job.ID)
+        return nil
+    }
     // Persist trade record
     price := (md.Bid + md.Ask) / 2
     var tradeID int64
@@ -71,9 +86,18 @@ func (v *VWAPExecutor) Execute(ctx context.Context, sig Signal, md MarketData, c
         }
         tradeID = id
     }
+    left := 0.0 // weight of the slices still to run
+    for _, w := range weights {
+        left += w
+    }
     for i := 0; i < v.Slices; i++ {
         sliceCfg := cfg
         sliceCfg.StakeSize = cfg.StakeSize * weights[i]
+        sliceCfg.SliceShare = 1
+        if i < v.Slices-1 && left > 0 {
+            sliceCfg.SliceShare = weights[i] / left
+        }
+        left -= weights[i]
         if err := v.Inner.Execute(ctx, sig, md, sliceCfg); err != nil {
             return err
         }
@@ -94,8 +118,11 @@ func (v *VWAPExecutor) Execute(ctx context.Context, sig Signal, md MarketData, c
     return nil
 }
 
-// CancelAll delegates cancellation to inner executor.
+// CancelAll cancels background jobs and delegates cancellation to inner executor.
 func (v *VWAPExecutor) CancelAll(ctx context.Context) error {
+    if v.Jobs != nil {
+        v.Jobs.CancelAll()
+    }
     return v.Inner.CancelAll(ctx)
 }
 
@@ -151,8 +178,7 @@ func (v *VWAPExecutor) computeHistoricalWeights(ctx context.Context, cfg Config)
 
 // computeOrderbookWeights calculates weights from orderbook depth data.
 func (v *VWAPExecutor) computeOrderbookWeights(ctx context.Context, cfg Config, sig Signal) []float64 {
-    req := &luno.GetOrderBookRequest{Pair: cfg.Pair}
-    resp, err := v.Client.GetOrderBook(ctx, req)
+    bids, asks, err := v.Market.OrderBook(ctx, cfg.Pair)
     weights := make([]float64, v.Slices)
     if err != nil {
         for i := range weights {
@@ -160,9 +186,9 @@ func (v *VWAPExecutor) computeOrderbookWeights(ctx context.Context, cfg Config,
         }
         return weights
     }
-    items := resp.Asks
+    items := asks
     if sig == SignalSell {
-        items = resp.Bids
+        items = bids
     }
     depth := len(items)
     if depth == 0 {
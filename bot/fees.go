@@ -0,0 +1,128 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	luno "github.com/luno/luno-go"
+)
+
+// defaultFeeTTL is how long fetched fees are reused; Luno recalculates them
+// from 30-day volume once a day.
+const defaultFeeTTL = time.Hour
+
+// defaultFeeRetry is how long a failed fetch is remembered before GetFeeInfo
+// is called again, so callers without fees do not retry on every lookup.
+const defaultFeeRetry = time.Minute
+
+// FeeRates are a pair's maker and taker fees as fractions of notional.
+type FeeRates struct {
+	Maker float64 `json:"maker"`
+	Taker float64 `json:"taker"`
+}
+
+// RoundTrip returns the cost of entering and exiting with taker orders.
+func (f FeeRates) RoundTrip() float64 {
+	return 2 * f.Taker
+}
+
+// RoundTripCost returns the fee cost of a taker entry and exit at the
+// configured fees, as a fraction of notional.
+func (c Config) RoundTripCost() float64 {
+	return FeeRates{Maker: c.MakerFee, Taker: c.TakerFee}.RoundTrip()
+}
+
+// SpreadEdge returns the spread as a fraction of the bid, the edge a
+// round trip must beat after fees.
+func SpreadEdge(bid, ask float64) float64 {
+	if bid <= 0 {
+		return 0
+	}
+	return (ask - bid) / bid
+}
+
+// Fees caches each pair's account fees from GetFeeInfo.
+type Fees struct {
+	client     Client
+	TTL        time.Duration // how long fetched rates are reused
+	RetryAfter time.Duration // how long a failed fetch is reused before refetching
+
+	mu    sync.Mutex
+	rates map[string]feeEntry
+}
+
+// feeEntry is a cached rate and when it was fetched, and the last failed
+// fetch if any.
+type feeEntry struct {
+	rates   FeeRates
+	fetched time.Time // zero until a fetch succeeds
+	failed  time.Time
+	err     error
+}
+
+// NewFees constructs a fee cache that refetches rates hourly and retries
+// failed fetches after a minute.
+func NewFees(client Client) *Fees {
+	return &Fees{client: client, TTL: defaultFeeTTL, RetryAfter: defaultFeeRetry, rates: make(map[string]feeEntry)}
+}
+
+// Rates returns the maker and taker fees for pair, fetching them when not
+// cached or older than TTL. A stale rate is returned if the refetch fails,
+// and a failure is returned again without a refetch until RetryAfter passes.
+func (f *Fees) Rates(ctx context.Context, pair string) (FeeRates, error) {
+	f.mu.Lock()
+	e := f.rates[pair]
+	f.mu.Unlock()
+	if !e.fetched.IsZero() && time.Since(e.fetched) < f.TTL {
+		return e.rates, nil
+	}
+	if e.err == nil || time.Since(e.failed) >= f.RetryAfter {
+		rates, err := f.fetch(ctx, pair)
+		f.mu.Lock()
+		if err == nil {
+			e = feeEntry{rates: rates, fetched: time.Now()}
+		} else {
+			e.failed, e.err = time.Now(), err
+		}
+		f.rates[pair] = e
+		f.mu.Unlock()
+	}
+	if e.fetched.IsZero() {
+		return FeeRates{}, e.err
+	}
+	return e.rates, nil
+}
+
+// fetch calls GetFeeInfo for pair and parses the rates.
+func (f *Fees) fetch(ctx context.Context, pair string) (FeeRates, error) {
+	resp, err := f.client.GetFeeInfo(ctx, &luno.GetFeeInfoRequest{Pair: pair})
+	if err != nil {
+		return FeeRates{}, fmt.Errorf("fee info %s: %w", pair, err)
+	}
+	maker, err := strconv.ParseFloat(resp.MakerFee, 64)
+	if err != nil {
+		return FeeRates{}, fmt.Errorf("fee info %s: maker fee %q: %w", pair, resp.MakerFee, err)
+	}
+	taker, err := strconv.ParseFloat(resp.TakerFee, 64)
+	if err != nil {
+		return FeeRates{}, fmt.Errorf("fee info %s: taker fee %q: %w", pair, resp.TakerFee, err)
+	}
+	return FeeRates{Maker: maker, Taker: taker}, nil
+}
+
+// Apply sets cfg.MakerFee and cfg.TakerFee to cfg.Pair's account fees,
+// keeping the configured fees when they cannot be fetched.
+func (f *Fees) Apply(ctx context.Context, cfg Config) Config {
+	if f == nil {
+		return cfg
+	}
+	rates, err := f.Rates(ctx, cfg.Pair)
+	if err != nil {
+		return cfg
+	}
+	cfg.MakerFee, cfg.TakerFee = rates.Maker, rates.Taker
+	return cfg
+}
@@ -0,0 +1,82 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	luno "github.com/luno/luno-go"
+)
+
+// feeClient serves fixed fees, or err when set, and counts calls.
+type feeClient struct {
+	Client
+	calls int
+	err   error
+}
+
+func (c *feeClient) GetFeeInfo(ctx context.Context, req *luno.GetFeeInfoRequest) (*luno.GetFeeInfoResponse, error) {
+	c.calls++
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &luno.GetFeeInfoResponse{MakerFee: "0.0002", TakerFee: "0.006"}, nil
+}
+
+func TestFeesDriveStrategyAndSimulatedPnL(t *testing.T) {
+	ctx := context.Background()
+	fc := &feeClient{}
+	fees := NewFees(fc)
+	cfg := fees.Apply(ctx, Config{Pair: "XBTZAR", StakeSize: 1, PositionLimit: 1, EntryThreshold: 0.001, MaxDrawdown: 1e9})
+	fees.Apply(ctx, cfg)
+	if cfg.MakerFee != 0.0002 || cfg.TakerFee != 0.006 || fc.calls != 1 {
+		t.Fatalf("Got fees %v/%v after %d calls", cfg.MakerFee, cfg.TakerFee, fc.calls)
+	}
+	if got := cfg.RoundTripCost(); got != 0.012 {
+		t.Errorf("RoundTripCost = %v, want 0.012", got)
+	}
+
+	// a 1% spread clears the threshold but not the 1.2% round trip
+	s := NewThresholdStrategy()
+	if sig := s.Next(MarketData{Bid: 100, Ask: 101}, cfg); sig != SignalNone {
+		t.Errorf("Expected no entry inside fees, got %v", sig)
+	}
+	if sig := s.Next(MarketData{Bid: 100, Ask: 102}, cfg); sig != SignalBuy {
+		t.Errorf("Expected entry beyond fees, got %v", sig)
+	}
+
+	sim := NewSimulatedExecutor()
+	sim.Execute(ctx, SignalBuy, MarketData{Bid: 100, Ask: 100}, cfg)
+	sim.Execute(ctx, SignalSell, MarketData{Bid: 110, Ask: 110}, cfg)
+	if want := 10 - 100*0.006 - 110*0.006; sim.TotalPnL < want-1e-9 || sim.TotalPnL > want+1e-9 {
+		t.Errorf("TotalPnL = %v, want %v", sim.TotalPnL, want)
+	}
+}
+
+func TestFeesRememberFailures(t *testing.T) {
+	ctx := context.Background()
+	fc := &feeClient{err: errors.New("unauthorised")}
+	fees := NewFees(fc)
+	for i := 0; i < 3; i++ {
+		if _, err := fees.Rates(ctx, "XBTZAR"); err == nil {
+			t.Fatal("Expected the fetch error")
+		}
+	}
+	if fc.calls != 1 {
+		t.Errorf("GetFeeInfo called %d times inside RetryAfter, want 1", fc.calls)
+	}
+
+	// once RetryAfter passes the fetch is tried again
+	fees.RetryAfter = 0
+	fc.err = nil
+	if f, err := fees.Rates(ctx, "XBTZAR"); err != nil || f.Taker != 0.006 || fc.calls != 2 {
+		t.Errorf("Retried rates %+v, %v after %d calls", f, err, fc.calls)
+	}
+
+	// a failed refetch keeps serving the stale rates
+	fees.TTL = 0
+	fc.err = errors.New("timeout")
+	if f, err := fees.Rates(ctx, "XBTZAR"); err != nil || f.Taker != 0.006 {
+		t.Errorf("Stale rates %+v, %v", f, err)
+	}
+}
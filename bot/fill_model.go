@@ -0,0 +1,216 @@
+package bot
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	luno "github.com/luno/luno-go"
+	dec "github.com/luno/luno-go/decimal"
+)
+
+// Quote is the market a simulated order is matched against.
+type Quote struct {
+	Time   time.Time
+	Bid    float64
+	Ask    float64
+	Bids   []luno.OrderBookEntry // depth, best first; synthesized by the model when empty
+	Asks   []luno.OrderBookEntry
+	High   float64 // range traded over the period, for resting orders
+	Low    float64
+	Volume float64 // base volume traded over the period
+}
+
+// SimOrder is an order presented to a FillModel.
+type SimOrder struct {
+	Type   luno.OrderType
+	Limit  float64 // limit price; 0 takes liquidity at any price
+	Volume float64 // unfilled base volume
+}
+
+// FillResult is the outcome of matching a SimOrder. Volume is 0 when nothing filled.
+type FillResult struct {
+	Price  float64 // volume-weighted average price
+	Volume float64
+	Fee    float64 // in counter currency
+	Maker  bool
+}
+
+// FillModel decides how simulated orders fill, for SimulatedExecutor and backtests.
+type FillModel interface {
+	// Touch returns the best bid and ask the model quotes for q.
+	Touch(q Quote) (bid, ask float64)
+	// Take matches an order arriving at the market, taking liquidity up to its limit.
+	Take(o SimOrder, q Quote) FillResult
+	// Rest matches a resting limit order against the trading in q.
+	Rest(o SimOrder, q Quote) FillResult
+	// Latency is the delay between placing an order and it reaching the market.
+	Latency() time.Duration
+}
+
+// FeeModel is a FillModel whose fees can be read and replaced, e.g. with the
+// account's rates from GetFeeInfo.
+type FeeModel interface {
+	FillModel
+	Fees() FeeRates
+	WithFees(FeeRates) FillModel
+}
+
+// MidFill fills orders in full at the mid price, and resting orders at their
+// limit once the period touches it. It is the optimistic default.
+type MidFill struct {
+	FeeRate float64 // fee per fill as a fraction of notional
+}
+
+// Touch returns the quote unchanged.
+func (m *MidFill) Touch(q Quote) (bid, ask float64) { return q.Bid, q.Ask }
+
+// Take fills in full at the mid when the limit allows it.
+func (m *MidFill) Take(o SimOrder, q Quote) FillResult {
+	mid := (q.Bid + q.Ask) / 2
+	if o.Limit > 0 && ((isBuyOrder(o.Type) && o.Limit < q.Ask) || (!isBuyOrder(o.Type) && o.Limit > q.Bid)) {
+		return FillResult{}
+	}
+	return FillResult{Price: mid, Volume: o.Volume, Fee: mid * o.Volume * m.FeeRate}
+}
+
+// Rest fills in full at the limit when the period's range reaches it.
+func (m *MidFill) Rest(o SimOrder, q Quote) FillResult {
+	if (isBuyOrder(o.Type) && q.Low <= o.Limit) || (!isBuyOrder(o.Type) && q.High >= o.Limit) {
+		return FillResult{Price: o.Limit, Volume: o.Volume, Fee: o.Limit * o.Volume * m.FeeRate, Maker: true}
+	}
+	return FillResult{}
+}
+
+// Latency is zero.
+func (m *MidFill) Latency() time.Duration { return 0 }
+
+// Fees returns FeeRate for both makers and takers.
+func (m *MidFill) Fees() FeeRates { return FeeRates{Maker: m.FeeRate, Taker: m.FeeRate} }
+
+// WithFees returns a MidFill charging the taker fee on every fill.
+func (m *MidFill) WithFees(f FeeRates) FillModel { return &MidFill{FeeRate: f.Taker} }
+
+// MarketFill is a conservative fill model: takers cross the spread and walk
+// the book, makers fill only when price trades through their limit and then
+// only for a share of the period's volume, and every fill pays a fee.
+type MarketFill struct {
+	SpreadBps     float64       // spread assumed when the quote has none (bid == ask)
+	MakerFee      float64       // fee rate for resting fills
+	TakerFee      float64       // fee rate for fills that take liquidity
+	Delay         time.Duration // latency between signal and the order reaching the market
+	LevelVolume   float64       // base volume per synthetic book level; 0 = unlimited at the touch
+	LevelBps      float64       // price step between synthetic book levels
+	Participation float64       // share of period volume a resting order can fill; 0 = no cap
+}
+
+// NewMarketFill constructs a MarketFill with the given spread and fees.
+func NewMarketFill(spreadBps, makerFee, takerFee float64) *MarketFill {
+	return &MarketFill{SpreadBps: spreadBps, MakerFee: makerFee, TakerFee: takerFee}
+}
+
+// Touch widens a zero-spread quote around its mid by SpreadBps.
+func (m *MarketFill) Touch(q Quote) (bid, ask float64) {
+	if q.Bid < q.Ask {
+		return q.Bid, q.Ask
+	}
+	mid := (q.Bid + q.Ask) / 2
+	half := mid * m.SpreadBps / 2 / 1e4
+	return mid - half, mid + half
+}
+
+// Take walks the opposite side of the book until the order is filled or its
+// limit is reached. Volume beyond the book's depth is left unfilled.
+func (m *MarketFill) Take(o SimOrder, q Quote) FillResult {
+	bid, ask := m.Touch(q)
+	buy := isBuyOrder(o.Type)
+	levels, touch, step := q.Asks, ask, 1.0
+	if !buy {
+		levels, touch, step = q.Bids, bid, -1.0
+	}
+	if len(levels) == 0 {
+		levels = m.synthetic(touch, step, o.Volume)
+	}
+	var base, counter float64
+	for _, l := range levels {
+		remaining := o.Volume - base
+		if remaining <= fillEpsilon {
+			break
+		}
+		price := l.Price.Float64()
+		if o.Limit > 0 && ((buy && price > o.Limit) || (!buy && price < o.Limit)) {
+			break
+		}
+		v := math.Min(remaining, l.Volume.Float64())
+		base += v
+		counter += v * price
+	}
+	if base <= fillEpsilon {
+		return FillResult{}
+	}
+	return FillResult{Price: counter / base, Volume: base, Fee: counter * m.TakerFee}
+}
+
+// synthetic builds book levels of LevelVolume spaced LevelBps apart from
+// touch, enough to cover volume. step is +1 for asks and -1 for bids.
+func (m *MarketFill) synthetic(touch, step, volume float64) []luno.OrderBookEntry {
+	if m.LevelVolume <= 0 {
+		return []luno.OrderBookEntry{bookLevel(touch, volume)}
+	}
+	n := int(math.Ceil(volume / m.LevelVolume))
+	levels := make([]luno.OrderBookEntry, n)
+	for i := range levels {
+		levels[i] = bookLevel(touch*(1+step*float64(i)*m.LevelBps/1e4), m.LevelVolume)
+	}
+	return levels
+}
+
+// Rest fills at the limit once the period trades through it, capped at
+// Participation of the period's volume.
+func (m *MarketFill) Rest(o SimOrder, q Quote) FillResult {
+	if (isBuyOrder(o.Type) && q.Low >= o.Limit) || (!isBuyOrder(o.Type) && q.High <= o.Limit) {
+		return FillResult{}
+	}
+	v := o.Volume
+	if m.Participation > 0 {
+		v = math.Min(v, q.Volume*m.Participation)
+	}
+	if v <= fillEpsilon {
+		return FillResult{}
+	}
+	return FillResult{Price: o.Limit, Volume: v, Fee: o.Limit * v * m.MakerFee, Maker: true}
+}
+
+// Latency returns Delay.
+func (m *MarketFill) Latency() time.Duration { return m.Delay }
+
+// Fees returns MakerFee and TakerFee.
+func (m *MarketFill) Fees() FeeRates { return FeeRates{Maker: m.MakerFee, Taker: m.TakerFee} }
+
+// WithFees returns a copy of m charging f.
+func (m *MarketFill) WithFees(f FeeRates) FillModel {
+	c := *m
+	c.MakerFee, c.TakerFee = f.Maker, f.Taker
+	return &c
+}
+
+// NewFillModel builds the fill model named by cfg.FillModel.
+func NewFillModel(cfg Config) (FillModel, error) {
+	switch cfg.FillModel {
+	case "", "mid":
+		return &MidFill{FeeRate: cfg.TakerFee}, nil
+	case "market":
+		m := NewMarketFill(cfg.FillSpreadBps, cfg.MakerFee, cfg.TakerFee)
+		m.Delay = time.Duration(cfg.FillLatencyMs) * time.Millisecond
+		m.Participation = cfg.FillParticipation
+		m.LevelVolume = cfg.FillLevelVolume
+		m.LevelBps = cfg.FillLevelBps
+		return m, nil
+	default:
+		return nil, fmt.Errorf("unknown fill model %q", cfg.FillModel)
+	}
+}
+
+func bookLevel(price, volume float64) luno.OrderBookEntry {
+	return luno.OrderBookEntry{Price: dec.NewFromFloat64(price, 8), Volume: dec.NewFromFloat64(volume, 8)}
+}
@@ -0,0 +1,226 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/luno/luno-bot/storage"
+	luno "github.com/luno/luno-go"
+)
+
+// HistoryStore caches candles and public trades; storage.SQLiteStore implements it.
+type HistoryStore interface {
+	SaveCandles(pair string, duration int64, candles []luno.Candle) error
+	Candles(pair string, duration int64, from, to time.Time) ([]luno.Candle, error)
+	MarkCandlesCovered(pair string, duration int64, from, to time.Time) error
+	CandleGaps(pair string, duration int64, from, to time.Time) ([]storage.TimeRange, error)
+	SavePublicTrades(pair string, trades []luno.PublicTrade) error
+	PublicTrades(pair string, from, to time.Time) ([]luno.PublicTrade, error)
+	LatestPublicTrade(pair string) (time.Time, bool, error)
+}
+
+// CandleHistory serves candles over a fixed range, e.g. for repeatable backtests.
+type CandleHistory interface {
+	CandlesBetween(ctx context.Context, pair string, duration int64, from, to time.Time) ([]luno.Candle, error)
+}
+
+// tradeHistoryWindow is how far back Luno serves public trades.
+const tradeHistoryWindow = 24 * time.Hour
+
+// Backfiller pages candles and trades from the exchange into a HistoryStore,
+// fetching only the ranges the store has not yet covered.
+type Backfiller struct {
+	Client Client
+	Store  HistoryStore
+}
+
+// NewBackfiller constructs a Backfiller.
+func NewBackfiller(client Client, store HistoryStore) *Backfiller {
+	return &Backfiller{Client: client, Store: store}
+}
+
+// BackfillCandles fetches candles for every uncovered gap in [from, to) and
+// returns how many were stored. The still-open candle is never marked covered;
+// it is refetched whenever to reaches it.
+func (b *Backfiller) BackfillCandles(ctx context.Context, pair string, duration int64, from, to time.Time) (int, error) {
+	step := time.Duration(duration) * time.Second
+	if step <= 0 {
+		return 0, fmt.Errorf("invalid candle duration %d", duration)
+	}
+	open := truncateUnix(time.Now(), step)
+	refresh := to.After(open)
+	if refresh {
+		to = open
+	}
+	gaps, err := b.Store.CandleGaps(pair, duration, truncateUnix(from, step), to)
+	if err != nil {
+		return 0, fmt.Errorf("candle gaps: %w", err)
+	}
+	stored := 0
+	for _, gap := range gaps {
+		cursor := gap.From
+		for cursor.Before(gap.To) {
+			if err := ctx.Err(); err != nil {
+				return stored, err
+			}
+			resp, err := b.Client.GetCandles(ctx, &luno.GetCandlesRequest{Pair: pair, Duration: duration, Since: luno.Time(cursor)})
+			if err != nil {
+				return stored, fmt.Errorf("get candles since %s: %w", cursor.Format(time.RFC3339), err)
+			}
+			if err := b.Store.SaveCandles(pair, duration, resp.Candles); err != nil {
+				return stored, fmt.Errorf("save candles: %w", err)
+			}
+			stored += len(resp.Candles)
+			next := gap.To
+			if n := len(resp.Candles); n > 0 {
+				if last := time.Time(resp.Candles[n-1].Timestamp).Add(step); last.Before(next) && last.After(cursor) {
+					next = last // page limit reached; continue after the last candle
+				}
+			}
+			if err := b.Store.MarkCandlesCovered(pair, duration, cursor, next); err != nil {
+				return stored, fmt.Errorf("mark candles covered: %w", err)
+			}
+			cursor = next
+		}
+	}
+	if refresh {
+		resp, err := b.Client.GetCandles(ctx, &luno.GetCandlesRequest{Pair: pair, Duration: duration, Since: luno.Time(open)})
+		if err != nil {
+			return stored, fmt.Errorf("get open candle: %w", err)
+		}
+		if err := b.Store.SaveCandles(pair, duration, resp.Candles); err != nil {
+			return stored, fmt.Errorf("save candles: %w", err)
+		}
+		stored += len(resp.Candles)
+	}
+	return stored, nil
+}
+
+// truncateUnix rounds t down to a multiple of step since the Unix epoch, as candles are aligned.
+func truncateUnix(t time.Time, step time.Duration) time.Time {
+	ms := t.UnixMilli()
+	return time.UnixMilli(ms - ms%step.Milliseconds())
+}
+
+// BackfillTrades fetches public trades newer than the latest cached one, or
+// the last 24 hours when none are cached, and returns how many were seen.
+func (b *Backfiller) BackfillTrades(ctx context.Context, pair string) (int, error) {
+	floor := time.Now().Add(-tradeHistoryWindow).Add(time.Minute)
+	since, ok, err := b.Store.LatestPublicTrade(pair)
+	if err != nil {
+		return 0, fmt.Errorf("latest trade: %w", err)
+	}
+	if !ok || since.Before(floor) {
+		since = floor
+	}
+	seen := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return seen, err
+		}
+		resp, err := b.Client.ListTrades(ctx, &luno.ListTradesRequest{Pair: pair, Since: luno.Time(since)})
+		if err != nil {
+			return seen, fmt.Errorf("list trades since %s: %w", since.Format(time.RFC3339), err)
+		}
+		if err := b.Store.SavePublicTrades(pair, resp.Trades); err != nil {
+			return seen, fmt.Errorf("save trades: %w", err)
+		}
+		seen += len(resp.Trades)
+		newest := since
+		for _, t := range resp.Trades {
+			if ts := time.Time(t.Timestamp); ts.After(newest) {
+				newest = ts
+			}
+		}
+		if !newest.After(since) {
+			return seen, nil
+		}
+		since = newest
+	}
+}
+
+// Sync backfills the last lookback of candles and any new trades for pair.
+func (b *Backfiller) Sync(ctx context.Context, pair string, duration int64, lookback time.Duration) error {
+	if _, err := b.BackfillCandles(ctx, pair, duration, time.Now().Add(-lookback), time.Now()); err != nil {
+		return err
+	}
+	_, err := b.BackfillTrades(ctx, pair)
+	return err
+}
+
+// Run syncs pairs every interval until ctx is cancelled, keeping the cache current.
+func (b *Backfiller) Run(ctx context.Context, pairs []string, duration int64, lookback, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for _, pair := range pairs {
+			if err := b.Sync(ctx, pair, duration, lookback); err != nil && ctx.Err() == nil {
+				fmt.Printf("Backfill %s: %v\n", pair, err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CachedClient is a Client whose candles and public trades go through a
+// HistoryStore. Missing ranges are backfilled from the wrapped client; when
+// it is unreachable, whatever is cached is served instead.
+type CachedClient struct {
+	Client
+	Store    HistoryStore
+	backfill *Backfiller
+}
+
+// NewCachedClient wraps client with the cache in store.
+func NewCachedClient(client Client, store HistoryStore) *CachedClient {
+	return &CachedClient{Client: client, Store: store, backfill: NewBackfiller(client, store)}
+}
+
+// GetCandles serves candles since req.Since from the cache, backfilling first.
+func (c *CachedClient) GetCandles(ctx context.Context, req *luno.GetCandlesRequest) (*luno.GetCandlesResponse, error) {
+	candles, err := c.CandlesBetween(ctx, req.Pair, req.Duration, time.Time(req.Since), time.Now().Add(time.Duration(req.Duration)*time.Second))
+	if err != nil {
+		return nil, err
+	}
+	return &luno.GetCandlesResponse{Pair: req.Pair, Duration: req.Duration, Candles: candles}, nil
+}
+
+// CandlesBetween returns candles starting in [from, to), backfilling gaps
+// first. A backfill error is returned only if nothing is cached for the range.
+func (c *CachedClient) CandlesBetween(ctx context.Context, pair string, duration int64, from, to time.Time) ([]luno.Candle, error) {
+	_, fillErr := c.backfill.BackfillCandles(ctx, pair, duration, from, to)
+	candles, err := c.Store.Candles(pair, duration, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("cached candles: %w", err)
+	}
+	if fillErr != nil && len(candles) == 0 {
+		return nil, fillErr
+	}
+	return candles, nil
+}
+
+// ListTrades lists trades from the exchange and caches them, falling back to
+// cached trades since req.Since when the exchange is unreachable.
+func (c *CachedClient) ListTrades(ctx context.Context, req *luno.ListTradesRequest) (*luno.ListTradesResponse, error) {
+	resp, err := c.Client.ListTrades(ctx, req)
+	if err == nil {
+		if serr := c.Store.SavePublicTrades(req.Pair, resp.Trades); serr != nil {
+			fmt.Printf("Cache trades %s: %v\n", req.Pair, serr)
+		}
+		return resp, nil
+	}
+	since := time.Time(req.Since)
+	if since.IsZero() {
+		since = time.Now().Add(-tradeHistoryWindow)
+	}
+	trades, cerr := c.Store.PublicTrades(req.Pair, since, time.Now().Add(time.Minute))
+	if cerr != nil || len(trades) == 0 {
+		return nil, err
+	}
+	return &luno.ListTradesResponse{Trades: trades}, nil
+}
@@ -0,0 +1,41 @@
+package indicators
+
+import "math"
+
+// ATR is Wilder's average true range, seeded with the simple average of the
+// first Period true ranges. The first bar's true range is its high-low range.
+type ATR struct {
+	period    int
+	prevClose float64
+	seen      int
+	value     float64
+}
+
+// NewATR constructs an ATR over period bars.
+func NewATR(period int) *ATR {
+	mustPeriod("ATR", period)
+	return &ATR{period: period}
+}
+
+// Update adds a bar and returns the current ATR.
+func (a *ATR) Update(high, low, close float64) float64 {
+	tr := high - low
+	if a.seen > 0 {
+		tr = math.Max(tr, math.Max(math.Abs(high-a.prevClose), math.Abs(low-a.prevClose)))
+	}
+	a.prevClose = close
+	a.seen++
+	n := float64(a.period)
+	if a.seen <= a.period {
+		a.value += (tr - a.value) / float64(a.seen) // running mean for the seed
+	} else {
+		a.value = (a.value*(n-1) + tr) / n
+	}
+	return a.value
+}
+
+// Value returns the current ATR.
+func (a *ATR) Value() float64 { return a.value }
+
+// Ready reports whether Period bars have been seen.
+func (a *ATR) Ready() bool { return a.seen >= a.period }
@@ -0,0 +1,35 @@
+package indicators
+
+// Bollinger bands are the Period SMA plus and minus Multiplier standard deviations.
+type Bollinger struct {
+	Multiplier float64
+	dev        *StdDev
+}
+
+// NewBollinger constructs Bollinger bands over period values.
+func NewBollinger(period int, multiplier float64) *Bollinger {
+	mustPeriod("Bollinger", period)
+	return &Bollinger{Multiplier: multiplier, dev: NewStdDev(period)}
+}
+
+// Update adds a price and returns the middle band.
+func (b *Bollinger) Update(price float64) float64 {
+	b.dev.Update(price)
+	return b.Value()
+}
+
+// Value returns the middle band.
+func (b *Bollinger) Value() float64 { return b.dev.Mean() }
+
+// Bands returns the lower, middle and upper bands.
+func (b *Bollinger) Bands() (lower, middle, upper float64) {
+	middle = b.dev.Mean()
+	width := b.Multiplier * b.dev.Value()
+	return middle - width, middle, middle + width
+}
+
+// StdDev returns the standard deviation of the window.
+func (b *Bollinger) StdDev() float64 { return b.dev.Value() }
+
+// Ready reports whether Period prices have been seen.
+func (b *Bollinger) Ready() bool { return b.dev.Ready() }
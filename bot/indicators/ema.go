@@ -0,0 +1,35 @@
+package indicators
+
+// EMA is an exponential moving average with smoothing 2/(Period+1),
+// seeded with the simple average of the first Period values.
+type EMA struct {
+	period int
+	alpha  float64
+	seen   int
+	sum    float64
+	value  float64
+}
+
+// NewEMA constructs an EMA over period values.
+func NewEMA(period int) *EMA {
+	mustPeriod("EMA", period)
+	return &EMA{period: period, alpha: 2 / float64(period+1)}
+}
+
+// Update adds v and returns the current average.
+func (e *EMA) Update(v float64) float64 {
+	if e.seen < e.period {
+		e.seen++
+		e.sum += v
+		e.value = e.sum / float64(e.seen)
+		return e.value
+	}
+	e.value += e.alpha * (v - e.value)
+	return e.value
+}
+
+// Value returns the current average.
+func (e *EMA) Value() float64 { return e.value }
+
+// Ready reports whether the SMA seed is complete.
+func (e *EMA) Ready() bool { return e.seen >= e.period }
@@ -0,0 +1,47 @@
+// Package indicators provides streaming technical indicators. Each indicator
+// is updated one sample at a time in O(1) (amortized for windowed extremes)
+// and keeps only as many samples as its period needs.
+package indicators
+
+// Indicator is a single-input indicator fed with one value per period.
+type Indicator interface {
+	// Update adds v and returns the current value.
+	Update(v float64) float64
+	// Value returns the current value; it is meaningless until Ready.
+	Value() float64
+	// Ready reports whether enough samples have been seen.
+	Ready() bool
+}
+
+// ring is a fixed-size FIFO of the most recent samples.
+type ring struct {
+	buf  []float64
+	head int // index of the oldest sample
+	n    int
+}
+
+func newRing(size int) ring {
+	return ring{buf: make([]float64, size)}
+}
+
+// push appends v, returning the evicted sample when the ring was full.
+func (r *ring) push(v float64) (old float64, evicted bool) {
+	if r.n < len(r.buf) {
+		r.buf[(r.head+r.n)%len(r.buf)] = v
+		r.n++
+		return 0, false
+	}
+	old = r.buf[r.head]
+	r.buf[r.head] = v
+	r.head = (r.head + 1) % len(r.buf)
+	return old, true
+}
+
+func (r *ring) full() bool { return r.n == len(r.buf) }
+
+// mustPeriod panics on a non-positive period, like the strategy constructors.
+func mustPeriod(name string, period int) {
+	if period <= 0 {
+		panic("indicators: invalid " + name + " period")
+	}
+}
@@ -0,0 +1,128 @@
+package indicators
+
+import (
+	"math"
+	"testing"
+)
+
+// closes is the 20-day sample from Wilder's RSI worked example.
+var closes = []float64{
+	44.34, 44.09, 44.15, 43.61, 44.33, 44.83, 45.10, 45.42, 45.84, 46.08,
+	45.89, 46.03, 45.61, 46.28, 46.28, 46.00, 46.03, 46.41, 46.22, 45.64,
+}
+
+// bars derives deterministic highs, lows and volumes around closes.
+func bars() (highs, lows, volumes []float64) {
+	for i, c := range closes {
+		highs = append(highs, c+0.5+0.1*float64(i%3))
+		lows = append(lows, c-0.4-0.1*float64(i%2))
+		volumes = append(volumes, 1000+37*float64(i))
+	}
+	return
+}
+
+func near(t *testing.T, name string, got, want float64) {
+	t.Helper()
+	if math.Abs(got-want) > 1e-4 {
+		t.Errorf("%s = %.6f, expected %.6f", name, got, want)
+	}
+}
+
+func TestSMAAndStdDev(t *testing.T) {
+	sma := NewSMA(5)
+	dev := NewStdDev(20)
+	for i, c := range closes {
+		sma.Update(c)
+		dev.Update(c)
+		if i == 3 && sma.Ready() {
+			t.Errorf("SMA ready after %d values", i+1)
+		}
+	}
+	near(t, "SMA(5)", sma.Value(), 46.06)
+	near(t, "mean(20)", dev.Mean(), 45.409)
+	near(t, "stddev(20)", dev.Value(), (47.115328-43.702672)/4)
+}
+
+func TestEMA(t *testing.T) {
+	ema := NewEMA(10)
+	for _, c := range closes {
+		ema.Update(c)
+	}
+	near(t, "EMA(10)", ema.Value(), 45.870366)
+}
+
+func TestRSI(t *testing.T) {
+	// StockCharts reports 70.53, 66.32, 66.55, 69.41, 66.36, 57.97 using rounded intermediates
+	want := []float64{70.4641, 66.2496, 66.4809, 69.3469, 66.2947, 57.9150}
+	rsi := NewRSI(14)
+	var got []float64
+	for _, c := range closes {
+		v := rsi.Update(c)
+		if rsi.Ready() {
+			got = append(got, v)
+		}
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d RSI values, expected %d", len(got), len(want))
+	}
+	for i := range want {
+		near(t, "RSI(14)", got[i], want[i])
+	}
+}
+
+func TestMACD(t *testing.T) {
+	macd := NewMACD(3, 6, 4)
+	for _, c := range closes {
+		macd.Update(c)
+	}
+	if !macd.Ready() {
+		t.Fatal("MACD not ready")
+	}
+	near(t, "MACD", macd.Value(), -0.063549)
+	near(t, "signal", macd.Signal(), 0.041704)
+	near(t, "histogram", macd.Histogram(), -0.105253)
+}
+
+func TestBollinger(t *testing.T) {
+	bb := NewBollinger(20, 2)
+	for _, c := range closes {
+		bb.Update(c)
+	}
+	lower, middle, upper := bb.Bands()
+	near(t, "lower", lower, 43.702672)
+	near(t, "middle", middle, 45.409)
+	near(t, "upper", upper, 47.115328)
+}
+
+func TestATRStochasticOBV(t *testing.T) {
+	highs, lows, volumes := bars()
+	atr := NewATR(14)
+	stoch := NewStochastic(14, 3)
+	obv := NewOBV()
+	for i, c := range closes {
+		atr.Update(highs[i], lows[i], c)
+		stoch.Update(highs[i], lows[i], c)
+		obv.Update(c, volumes[i])
+	}
+	near(t, "ATR(14)", atr.Value(), 1.070417)
+	near(t, "%K", stoch.Value(), 39.004149)
+	near(t, "%D", stoch.D(), 61.659061)
+	near(t, "OBV", obv.Value(), 4740)
+}
+
+func TestVWAP(t *testing.T) {
+	w := NewVWAP(2)
+	w.Update(12, 8, 10, 1) // typical 10
+	if w.Ready() {
+		t.Fatal("VWAP ready after one bar")
+	}
+	w.Update(21, 19, 20, 3) // typical 20
+	near(t, "VWAP", w.Value(), 17.5)
+	w.Update(31, 29, 30, 1) // drops the first bar
+	near(t, "VWAP rolled", w.Value(), 22.5)
+	w.Update(40, 40, 40, 0)
+	w.Update(40, 40, 40, 0)
+	if w.Ready() {
+		t.Error("VWAP ready without volume")
+	}
+}
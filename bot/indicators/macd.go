@@ -0,0 +1,37 @@
+package indicators
+
+// MACD is the difference of a fast and slow EMA, with an EMA signal line of
+// that difference. The signal line starts once the slow EMA is ready.
+type MACD struct {
+	fast, slow, signal *EMA
+	macd               float64
+}
+
+// NewMACD constructs a MACD from fast, slow and signal periods.
+func NewMACD(fast, slow, signal int) *MACD {
+	mustPeriod("MACD fast", fast)
+	mustPeriod("MACD slow", slow)
+	mustPeriod("MACD signal", signal)
+	return &MACD{fast: NewEMA(fast), slow: NewEMA(slow), signal: NewEMA(signal)}
+}
+
+// Update adds a price and returns the MACD line.
+func (m *MACD) Update(price float64) float64 {
+	m.macd = m.fast.Update(price) - m.slow.Update(price)
+	if m.slow.Ready() {
+		m.signal.Update(m.macd)
+	}
+	return m.macd
+}
+
+// Value returns the MACD line.
+func (m *MACD) Value() float64 { return m.macd }
+
+// Signal returns the signal line.
+func (m *MACD) Signal() float64 { return m.signal.Value() }
+
+// Histogram returns the MACD line minus the signal line.
+func (m *MACD) Histogram() float64 { return m.macd - m.signal.Value() }
+
+// Ready reports whether the signal line is seeded.
+func (m *MACD) Ready() bool { return m.signal.Ready() }
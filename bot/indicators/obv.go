@@ -0,0 +1,34 @@
+package indicators
+
+// OBV is on-balance volume: volume is added on up closes and subtracted on down closes.
+type OBV struct {
+	prevClose float64
+	seen      bool
+	value     float64
+}
+
+// NewOBV constructs an OBV starting at zero.
+func NewOBV() *OBV {
+	return &OBV{}
+}
+
+// Update adds a bar and returns the running OBV.
+func (o *OBV) Update(close, volume float64) float64 {
+	if o.seen {
+		switch {
+		case close > o.prevClose:
+			o.value += volume
+		case close < o.prevClose:
+			o.value -= volume
+		}
+	}
+	o.prevClose = close
+	o.seen = true
+	return o.value
+}
+
+// Value returns the running OBV.
+func (o *OBV) Value() float64 { return o.value }
+
+// Ready reports whether a prior close is known.
+func (o *OBV) Ready() bool { return o.seen }
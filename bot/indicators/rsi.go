@@ -0,0 +1,61 @@
+package indicators
+
+// RSI is Wilder's relative strength index. The first average gain and loss
+// are simple averages over Period changes; later ones use Wilder smoothing.
+type RSI struct {
+	period           int
+	prev             float64
+	seen             int // prices seen
+	avgGain, avgLoss float64
+}
+
+// NewRSI constructs an RSI over period price changes.
+func NewRSI(period int) *RSI {
+	mustPeriod("RSI", period)
+	return &RSI{period: period}
+}
+
+// Update adds a price and returns the current RSI.
+func (r *RSI) Update(price float64) float64 {
+	r.seen++
+	if r.seen == 1 {
+		r.prev = price
+		return r.Value()
+	}
+	delta := price - r.prev
+	r.prev = price
+	gain, loss := 0.0, 0.0
+	if delta > 0 {
+		gain = delta
+	} else {
+		loss = -delta
+	}
+	n := float64(r.period)
+	if r.seen <= r.period+1 {
+		// accumulate the seed averages
+		r.avgGain += gain / n
+		r.avgLoss += loss / n
+	} else {
+		r.avgGain = (r.avgGain*(n-1) + gain) / n
+		r.avgLoss = (r.avgLoss*(n-1) + loss) / n
+	}
+	return r.Value()
+}
+
+// Value returns the RSI in [0, 100]; 50 until Ready or when prices are flat.
+func (r *RSI) Value() float64 {
+	if !r.Ready() {
+		return 50
+	}
+	if r.avgLoss == 0 {
+		if r.avgGain == 0 {
+			return 50
+		}
+		return 100
+	}
+	rs := r.avgGain / r.avgLoss
+	return 100 - 100/(1+rs)
+}
+
+// Ready reports whether Period changes have been seen.
+func (r *RSI) Ready() bool { return r.seen > r.period }
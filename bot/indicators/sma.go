@@ -0,0 +1,84 @@
+package indicators
+
+import "math"
+
+// SMA is a simple moving average over the last Period values.
+type SMA struct {
+	period int
+	window ring
+	sum    float64
+}
+
+// NewSMA constructs an SMA over period values.
+func NewSMA(period int) *SMA {
+	mustPeriod("SMA", period)
+	return &SMA{period: period, window: newRing(period)}
+}
+
+// Update adds v and returns the average of the values seen so far, up to Period.
+func (s *SMA) Update(v float64) float64 {
+	if old, ok := s.window.push(v); ok {
+		s.sum -= old
+	}
+	s.sum += v
+	return s.Value()
+}
+
+// Value returns the current average.
+func (s *SMA) Value() float64 {
+	if s.window.n == 0 {
+		return 0
+	}
+	return s.sum / float64(s.window.n)
+}
+
+// Ready reports whether Period values have been seen.
+func (s *SMA) Ready() bool { return s.window.full() }
+
+// StdDev is the population standard deviation over the last Period values.
+type StdDev struct {
+	period     int
+	window     ring
+	sum, sumSq float64
+}
+
+// NewStdDev constructs a rolling standard deviation over period values.
+func NewStdDev(period int) *StdDev {
+	mustPeriod("StdDev", period)
+	return &StdDev{period: period, window: newRing(period)}
+}
+
+// Update adds v and returns the current standard deviation.
+func (s *StdDev) Update(v float64) float64 {
+	if old, ok := s.window.push(v); ok {
+		s.sum -= old
+		s.sumSq -= old * old
+	}
+	s.sum += v
+	s.sumSq += v * v
+	return s.Value()
+}
+
+// Mean returns the average of the window.
+func (s *StdDev) Mean() float64 {
+	if s.window.n == 0 {
+		return 0
+	}
+	return s.sum / float64(s.window.n)
+}
+
+// Value returns the population standard deviation of the window.
+func (s *StdDev) Value() float64 {
+	if s.window.n == 0 {
+		return 0
+	}
+	mean := s.Mean()
+	variance := s.sumSq/float64(s.window.n) - mean*mean
+	if variance < 0 {
+		variance = 0 // rounding on flat series
+	}
+	return math.Sqrt(variance)
+}
+
+// Ready reports whether Period values have been seen.
+func (s *StdDev) Ready() bool { return s.window.full() }
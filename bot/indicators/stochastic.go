@@ -0,0 +1,74 @@
+package indicators
+
+// Stochastic is the stochastic oscillator: %K places the close within the
+// high-low range of the last KPeriod bars, and %D is the DPeriod SMA of %K.
+type Stochastic struct {
+	kPeriod int
+	bar     int
+	highs   extremes
+	lows    extremes
+	d       *SMA
+	k       float64
+}
+
+// NewStochastic constructs a stochastic oscillator.
+func NewStochastic(kPeriod, dPeriod int) *Stochastic {
+	mustPeriod("stochastic %K", kPeriod)
+	mustPeriod("stochastic %D", dPeriod)
+	return &Stochastic{
+		kPeriod: kPeriod,
+		highs:   extremes{max: true},
+		lows:    extremes{},
+		d:       NewSMA(dPeriod),
+	}
+}
+
+// Update adds a bar and returns %K.
+func (s *Stochastic) Update(high, low, close float64) float64 {
+	s.highs.push(s.bar, high, s.kPeriod)
+	s.lows.push(s.bar, low, s.kPeriod)
+	s.bar++
+	hh, ll := s.highs.front(), s.lows.front()
+	s.k = 50
+	if hh > ll {
+		s.k = 100 * (close - ll) / (hh - ll)
+	}
+	if s.bar >= s.kPeriod {
+		s.d.Update(s.k)
+	}
+	return s.k
+}
+
+// Value returns %K.
+func (s *Stochastic) Value() float64 { return s.k }
+
+// D returns %D.
+func (s *Stochastic) D() float64 { return s.d.Value() }
+
+// Ready reports whether %D has a full window.
+func (s *Stochastic) Ready() bool { return s.d.Ready() }
+
+// extremes is a monotonic deque holding the running max (or min) of a window.
+type extremes struct {
+	max bool
+	idx []int
+	val []float64
+}
+
+// push adds the value for bar i and drops bars older than period.
+func (e *extremes) push(i int, v float64, period int) {
+	for n := len(e.val); n > 0; n = len(e.val) {
+		last := e.val[n-1]
+		if (e.max && last > v) || (!e.max && last < v) {
+			break
+		}
+		e.idx, e.val = e.idx[:n-1], e.val[:n-1]
+	}
+	e.idx = append(e.idx, i)
+	e.val = append(e.val, v)
+	for e.idx[0] <= i-period {
+		e.idx, e.val = e.idx[1:], e.val[1:]
+	}
+}
+
+func (e *extremes) front() float64 { return e.val[0] }
@@ -0,0 +1,40 @@
+package indicators
+
+// VWAP is the volume-weighted average of each bar's typical price
+// (high+low+close)/3 over the last Period bars.
+type VWAP struct {
+	period      int
+	pv, vol     ring
+	sumPV, sumV float64
+}
+
+// NewVWAP constructs a VWAP over period bars.
+func NewVWAP(period int) *VWAP {
+	mustPeriod("VWAP", period)
+	return &VWAP{period: period, pv: newRing(period), vol: newRing(period)}
+}
+
+// Update adds a bar and returns the current VWAP.
+func (w *VWAP) Update(high, low, close, volume float64) float64 {
+	pv := (high + low + close) / 3 * volume
+	if old, ok := w.pv.push(pv); ok {
+		w.sumPV -= old
+	}
+	if old, ok := w.vol.push(volume); ok {
+		w.sumV -= old
+	}
+	w.sumPV += pv
+	w.sumV += volume
+	return w.Value()
+}
+
+// Value returns the current VWAP, or zero while no volume is in the window.
+func (w *VWAP) Value() float64 {
+	if w.sumV <= 0 {
+		return 0
+	}
+	return w.sumPV / w.sumV
+}
+
+// Ready reports whether Period bars with some volume have been seen.
+func (w *VWAP) Ready() bool { return w.pv.full() && w.sumV > 0 }
@@ -2,6 +2,7 @@ package bot
 
 import (
 	"context"
+	"encoding/json"
 	"time"
 
 	"github.com/luno/luno-go"
@@ -14,12 +15,22 @@ type Client interface {
 	GetTickers(ctx context.Context, req *luno.GetTickersRequest) (*luno.GetTickersResponse, error)
 	GetOrderBook(ctx context.Context, req *luno.GetOrderBookRequest) (*luno.GetOrderBookResponse, error)
 	PostLimitOrder(ctx context.Context, req *luno.PostLimitOrderRequest) (*luno.PostLimitOrderResponse, error)
+	// PostMarketOrder buys with a counter amount or sells a base amount at market
+	PostMarketOrder(ctx context.Context, req *luno.PostMarketOrderRequest) (*luno.PostMarketOrderResponse, error)
 	// Fetch historical trades for backtesting
 	ListTrades(ctx context.Context, req *luno.ListTradesRequest) (*luno.ListTradesResponse, error)
 	// Fetch historical candles for backtesting
 	GetCandles(ctx context.Context, req *luno.GetCandlesRequest) (*luno.GetCandlesResponse, error)
 	// GetBalances retrieves account balances from Luno API
 	GetBalances(ctx context.Context, req *luno.GetBalancesRequest) (*luno.GetBalancesResponse, error)
+	// Order lifecycle: fetch, list and cancel placed orders
+	GetOrderV2(ctx context.Context, req *luno.GetOrderV2Request) (*luno.GetOrderV2Response, error)
+	ListOrders(ctx context.Context, req *luno.ListOrdersRequest) (*luno.ListOrdersResponse, error)
+	StopOrder(ctx context.Context, req *luno.StopOrderRequest) (*luno.StopOrderResponse, error)
+	// Markets lists trading rules (scales, limits, status) per pair
+	Markets(ctx context.Context, req *luno.MarketsRequest) (*luno.MarketsResponse, error)
+	// GetFeeInfo returns the account's maker and taker fees for a pair
+	GetFeeInfo(ctx context.Context, req *luno.GetFeeInfoRequest) (*luno.GetFeeInfoResponse, error)
 }
 
 // Strategy generates trading signals.
@@ -28,6 +39,19 @@ type Strategy interface {
 	Next(data MarketData, cfg Config) Signal
 }
 
+// MarketDataSource supplies current prices and order book depth for a pair.
+type MarketDataSource interface {
+	MarketData(ctx context.Context, pair string) (MarketData, error)
+	OrderBook(ctx context.Context, pair string) (bids, asks []luno.OrderBookEntry, err error)
+}
+
+// VolumeSource is a MarketDataSource that can also report traded volume, which
+// the engine puts on each tick event.
+type VolumeSource interface {
+	// TradedVolume returns the base volume traded on pair since the previous call.
+	TradedVolume(ctx context.Context, pair string) (float64, error)
+}
+
 // Executor places and manages orders based on signals.
 type Executor interface {
 	Execute(ctx context.Context, sig Signal, md MarketData, cfg Config) error
@@ -43,6 +67,18 @@ const (
 	SignalSell
 )
 
+// String returns the lowercase name of the signal.
+func (s Signal) String() string {
+	switch s {
+	case SignalBuy:
+		return "buy"
+	case SignalSell:
+		return "sell"
+	default:
+		return "none"
+	}
+}
+
 // Config holds adjustable parameters for a strategy.
 type Config struct {
 	Pair             string        // e.g. "XBTZAR"
@@ -51,11 +87,12 @@ type Config struct {
 	StakeSize        float64       // amount per trade
 	Cooldown         time.Duration // min time between trades
 	PositionLimit    float64       // max allowed position size
-	MaxDrawdown      float64       // max permitted drawdown
+	MaxDrawdown      float64       // max permitted drawdown from the equity high, in quote currency
 	ShortWindow      int           // SMA short window
 	LongWindow       int           // SMA long window
 	BaseAccountId    int64         // base currency account ID for trades
 	CounterAccountId int64         // counter currency account ID for trades
+	BaseHoldings     float64       // base balance held outside the bot, expected on top of its position
 	// RSI indicator parameters
 	RSIPeriod       int           // number of periods for RSI
 	RSIOverBought   float64       // RSI level above which to sell
@@ -69,7 +106,7 @@ type Config struct {
 	BBMultiplier     float64      // stddev multiplier for Bollinger Bands
 	// Risk & execution parameters
 	InitialEquity       float64      // starting capital for sizing
-	PositionSizerType   string       // "fixed" or "kelly"
+	PositionSizerType   string       // "fixed", "kelly", "kelly_history", "fixed_fractional" or "vol_target"
 	KellyWinProb        float64      // win probability for Kelly sizing
 	KellyWinLossRatio   float64      // average win/loss ratio for Kelly sizing
 	TWAPSlices          int          // number of slices for TWAP execution
@@ -79,12 +116,70 @@ type Config struct {
 	VWAPHistoryWindowMinutes int         // window in minutes for historical VWAP
 	VWAPOrderbookDepthLevels int         // depth levels for orderbook VWAP
 	VWAPHybridWeight         float64     // weight factor for hybrid VWAP combination
+	// Simulated fill parameters
+	FillModel         string  // simulated fill model: "mid" (default) or "market"
+	FillSpreadBps     float64 // spread assumed when quotes have none
+	MakerFee          float64 // fee rate for resting fills
+	TakerFee          float64 // fee rate for fills taking liquidity
+	FillLatencyMs     int     // delay between signal and fill
+	FillParticipation float64 // share of traded volume a resting order can fill
+	FillLevelVolume   float64 // base volume per synthetic book level
+	FillLevelBps      float64 // price step between synthetic book levels
+	// Pre-trade risk limits; zero disables a check
+	MaxOrderNotional float64 // max order value in quote currency
+	MaxDailyVolume   float64 // max quote value traded per UTC day
+	PriceBandBps     float64 // max order price deviation from the reference mid
+	MaxOpenOrders    int     // max resting orders per pair
+	MaxPairExposure  float64 // max position value per pair in quote currency
+	// Circuit breaker; zero disables a trigger
+	MaxConsecutiveErrors int // execution errors in a row that trip the kill switch
+	StaleDataSeconds     int // market data age that trips the kill switch
+	// Exit manager; zero disables a rule
+	StopLossPct       float64 // exit when price falls this % below entry
+	TakeProfitPct     float64 // exit when price rises this % above entry
+	TrailingStopPct   float64 // exit when price falls this % below its high since entry
+	ATRStopMultiplier float64 // exit when price falls this many ATRs below entry
+	ATRPeriod         int     // ticks in the ATR for the ATR stop (default 14)
+	MaxHoldSeconds    int     // exit after holding a position this long
+	// Market rules
+	BumpMinVolume bool // raise buys below the market minimum volume instead of rejecting them
+	// Live order placement
+	OrderMode           string  // live order placement: "limit" (default), "maker" or "market"
+	MakerRepriceTicks   int     // ticks the touch may move before a maker order is replaced
+	MakerTimeoutSeconds int     // how long a maker order works before giving up (default 60)
+	MakerCrossOnTimeout bool    // cross the spread for the unfilled remainder on timeout
+	MakerTickSize       float64 // price tick; defaults to the market price scale
+	MaxSlippageBps      float64 // largest expected market order slippage from the mid, in bps; 0 = no limit
+	// Execution algorithms
+	ExecutionAlgo       string  // order slicing: "vwap" (default), "twap" or "pov"
+	TWAPJitter          float64 // random variation of TWAP slice sizes and gaps, 0-1
+	TWAPDeadlineSeconds int     // complete a TWAP schedule within this time; 0 = none
+	POVRate             float64 // share of traded volume each POV slice takes, 0-1
+	POVMaxSeconds       int     // stop a POV order after this time; required for pov
+	// Strategy selection
+	Strategy       string          // registered strategy name; "" is "multitimeframe"
+	StrategyParams json.RawMessage // JSON parameters for Strategy, overriding the indicator fields above
+	// Composite voting
+	VoteMode      string             // how composite children combine: "unanimous" (default), "majority", "weighted", "at_least" or "veto"
+	VoteMin       int                // votes an "at_least" signal needs
+	VoteThreshold float64            // share of total weight a "weighted" signal needs; default 0.5
+	VoteWeights   map[string]float64 // "weighted" weight per child strategy name; default 1
+	VoteVeto      string             // child strategy whose opposing signal blocks a "veto" vote
+	// Timeframes
+	Timeframe     string // bar interval the strategy runs on, e.g. "5m" or "1h"; "" runs it on every tick
+	FastTimeframe string // multitimeframe: bar interval of the fast composite; "" is every tick
+	SlowTimeframe string // multitimeframe: bar interval of the slow composite; "" doubles the fast periods instead
+	// Strategy events
+	StrategyDepth bool // fetch order-book depth for every strategy event
+	// Set by slicing executors, not loaded from config
+	SliceShare float64 // share of the order's unexecuted remainder this slice works; 0 is a whole order
 }
 
 // MarketData packages latest market metrics.
 type MarketData struct {
 	Bid       float64
 	Ask       float64
+	Last      float64 // last trade price; zero when unknown
 	Timestamp time.Time
 }
 
@@ -0,0 +1,644 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/luno/luno-bot/storage"
+	luno "github.com/luno/luno-go"
+)
+
+// Execution job states.
+const (
+	JobRunning   = "running"
+	JobPaused    = "paused"
+	JobCancelled = "cancelled"
+	JobDone      = "done"
+	JobFailed    = "failed"
+)
+
+// Slice states within a job.
+const (
+	SlicePending   = "pending"
+	SliceDone      = "done"
+	SliceFailed    = "failed"
+	SliceCancelled = "cancelled"
+)
+
+var (
+	// ErrJobNotFound is returned for an unknown job ID.
+	ErrJobNotFound = errors.New("job not found")
+	// ErrJobState is returned when a job cannot move to the requested state.
+	ErrJobState = errors.New("job cannot change state")
+)
+
+// JobStore persists execution jobs and their slices; storage.SQLiteStore implements it.
+type JobStore interface {
+	CreateJob(j storage.JobRecord, weights []float64) (int64, error)
+	UpdateJob(id int64, status, errMsg string) error
+	UpdateSlice(id int64, index int, status string, filled float64, at time.Time) error
+	AddSlice(id int64, s storage.SliceRecord) error
+	ListJobs(executor string) ([]storage.JobRecord, error)
+}
+
+// JobRunner runs sliced execution schedules from TWAPExecutor, VWAPExecutor
+// and POVExecutor in the background. Each job executes one slice through
+// Inner per interval, records what each slice filled, and can be paused,
+// resumed or cancelled between slices. With a Store, jobs survive a restart:
+// Restore picks running jobs up at their next pending slice.
+type JobRunner struct {
+	Name      string           // executor chain label persisted with each job
+	Inner     Executor         // executes each slice
+	Positions PositionSource   // measures fills; nil counts a successful slice as filled
+	Store     JobStore         // optional
+	Market    MarketDataSource // optional; refreshes prices before each slice
+	Halted    func() bool      // optional; cancels jobs while trading is halted
+	Pair      string           // optional; restores only this pair's jobs when chains are per pair
+	Trades    Client           // market trades for percent-of-volume jobs
+
+	mu   sync.Mutex
+	ctx  context.Context
+	seq  int64 // job IDs when there is no store
+	jobs map[int64]*job
+}
+
+// job is a schedule and the goroutine working it.
+type job struct {
+	rec    storage.JobRecord
+	params jobParams
+	cancel context.CancelFunc
+	done   chan struct{} // closed when the goroutine exits
+	since  time.Time     // percent-of-volume jobs: newest market trade counted
+}
+
+// jobParams is what a job needs to resume, persisted as JSON.
+type jobParams struct {
+	Signal Signal
+	Market MarketData
+	Config Config
+	Delays []time.Duration `json:",omitempty"` // wait before each slice; the job interval when unset
+	Rate   float64         `json:",omitempty"` // percent-of-volume jobs: share of market volume per slice
+	// Until is the job's deadline: percent-of-volume jobs leave any remainder
+	// after it, and schedules send all of it in one slice.
+	Until time.Time `json:",omitempty"`
+}
+
+// NewJobRunner constructs a JobRunner; positions and store may be nil.
+func NewJobRunner(name string, inner Executor, positions PositionSource, store JobStore) *JobRunner {
+	return &JobRunner{Name: name, Inner: inner, Positions: positions, Store: store, ctx: context.Background(), jobs: make(map[int64]*job)}
+}
+
+// Submit starts a job executing cfg.StakeSize for sig in slices sized by
+// weights, interval apart, and returns it.
+func (r *JobRunner) Submit(kind string, sig Signal, md MarketData, cfg Config, weights []float64, interval time.Duration) (storage.JobRecord, error) {
+	return r.SubmitSchedule(kind, sig, md, cfg, weights, nil, interval, 0)
+}
+
+// SubmitSchedule is Submit with its own wait before each slice; slices
+// beyond delays wait interval. With a deadline, whatever is left when it
+// passes goes out in one slice, even if the job was paused or restored.
+func (r *JobRunner) SubmitSchedule(kind string, sig Signal, md MarketData, cfg Config, weights []float64, delays []time.Duration, interval, deadline time.Duration) (storage.JobRecord, error) {
+	p := jobParams{Signal: sig, Market: md, Config: cfg, Delays: delays}
+	if deadline > 0 {
+		p.Until = time.Now().Add(deadline)
+	}
+	return r.submit(kind, p, weights, interval)
+}
+
+// SubmitPOV starts a job executing cfg.StakeSize for sig as rate of the
+// market volume traded on the pair, one slice every interval, until the
+// stake is done or maxDuration has passed. Slices are added as they run.
+func (r *JobRunner) SubmitPOV(sig Signal, md MarketData, cfg Config, rate float64, interval, maxDuration time.Duration) (storage.JobRecord, error) {
+	if r.Trades == nil {
+		return storage.JobRecord{}, fmt.Errorf("pov job: no trade source")
+	}
+	p := jobParams{Signal: sig, Market: md, Config: cfg, Rate: rate, Until: time.Now().Add(maxDuration)}
+	return r.submit("pov", p, nil, interval)
+}
+
+// submit saves and starts a job. A sell works the position held when it is
+// submitted rather than the stake.
+func (r *JobRunner) submit(kind string, p jobParams, weights []float64, interval time.Duration) (storage.JobRecord, error) {
+	if p.Signal == SignalSell && r.Positions != nil {
+		if position, _ := r.Positions.CurrentPosition(); position > 0 {
+			p.Config.StakeSize = position
+		}
+	}
+	sig, md, cfg := p.Signal, p.Market, p.Config
+	params, err := json.Marshal(p)
+	if err != nil {
+		return storage.JobRecord{}, err
+	}
+	now := time.Now()
+	rec := storage.JobRecord{
+		Executor: r.Name,
+		Kind:     kind,
+		Pair:     cfg.Pair,
+		Side:     sig.String(),
+		Price:    (md.Bid + md.Ask) / 2,
+		Volume:   cfg.StakeSize,
+		Status:   JobRunning,
+		Interval: interval,
+		Params:   string(params),
+		Created:  now,
+		Updated:  now,
+	}
+	for i, w := range weights {
+		rec.Slices = append(rec.Slices, storage.SliceRecord{Index: i, Size: cfg.StakeSize * w, Weight: w, Status: SlicePending})
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Store != nil {
+		if rec.ID, err = r.Store.CreateJob(rec, weights); err != nil {
+			return storage.JobRecord{}, fmt.Errorf("save job: %w", err)
+		}
+	} else {
+		r.seq++
+		rec.ID = r.seq
+	}
+	for i := range rec.Slices {
+		rec.Slices[i].TradeID = rec.ID
+	}
+	j := &job{rec: rec, params: p, since: now}
+	r.jobs[rec.ID] = j
+	r.start(j)
+	return copyJob(j.rec), nil
+}
+
+// Restore loads persisted jobs for this runner and restarts those that were
+// running. Jobs run under ctx.
+func (r *JobRunner) Restore(ctx context.Context) error {
+	r.mu.Lock()
+	r.ctx = ctx
+	r.mu.Unlock()
+	if r.Store == nil {
+		return nil
+	}
+	recs, err := r.Store.ListJobs(r.Name)
+	if err != nil {
+		return fmt.Errorf("list jobs: %w", err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, rec := range recs {
+		if r.Pair != "" && rec.Pair != r.Pair {
+			continue
+		}
+		j := &job{rec: rec, since: rec.Created}
+		if err := json.Unmarshal([]byte(rec.Params), &j.params); err != nil {
+			fmt.Printf("Job %d: bad parameters: %v\n", rec.ID, err)
+			continue
+		}
+		if n := len(rec.Slices); n > 0 && rec.Slices[n-1].ExecutedAt.After(j.since) {
+			j.since = rec.Slices[n-1].ExecutedAt
+		}
+		r.jobs[rec.ID] = j
+		if rec.Status == JobRunning {
+			fmt.Printf("Resuming %s job %d on %s\n", rec.Kind, rec.ID, rec.Pair)
+			r.start(j)
+		}
+	}
+	return nil
+}
+
+// Active reports whether a running or paused job is working pair.
+func (r *JobRunner) Active(pair string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, j := range r.jobs {
+		if j.rec.Pair == pair && (j.rec.Status == JobRunning || j.rec.Status == JobPaused) {
+			return true
+		}
+	}
+	return false
+}
+
+// Preempt cancels the running or paused jobs on pair that work the side
+// opposite sig, so an exit such as a stop-loss is not held behind the entry
+// it closes, and waits for any slice they have in flight. It reports whether
+// a job on pair is still working sig's side, in which case sig is redundant.
+func (r *JobRunner) Preempt(pair string, sig Signal) bool {
+	r.mu.Lock()
+	busy := false
+	var stopped []chan struct{}
+	for _, j := range r.jobs {
+		if j.rec.Pair != pair || (j.rec.Status != JobRunning && j.rec.Status != JobPaused) {
+			continue
+		}
+		if j.rec.Side == sig.String() {
+			busy = true
+			continue
+		}
+		fmt.Printf("Job %d: cancelling %s job on %s for a %s signal\n", j.rec.ID, j.rec.Side, pair, sig)
+		r.stop(j, JobCancelled, fmt.Sprintf("preempted by %s signal", sig))
+		if j.done != nil {
+			stopped = append(stopped, j.done)
+		}
+	}
+	r.mu.Unlock()
+	for _, done := range stopped {
+		<-done
+	}
+	return busy
+}
+
+// Jobs returns all known jobs, newest first.
+func (r *JobRunner) Jobs() []storage.JobRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]storage.JobRecord, 0, len(r.jobs))
+	for _, j := range r.jobs {
+		out = append(out, copyJob(j.rec))
+	}
+	sort.Slice(out, func(a, b int) bool { return out[a].ID > out[b].ID })
+	return out
+}
+
+// Job returns the job with id.
+func (r *JobRunner) Job(id int64) (storage.JobRecord, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	j, ok := r.jobs[id]
+	if !ok {
+		return storage.JobRecord{}, false
+	}
+	return copyJob(j.rec), true
+}
+
+// Pause stops a running job before its next slice.
+func (r *JobRunner) Pause(id int64) (storage.JobRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	j, ok := r.jobs[id]
+	if !ok {
+		return storage.JobRecord{}, ErrJobNotFound
+	}
+	if j.rec.Status != JobRunning {
+		return copyJob(j.rec), fmt.Errorf("%w: %s job cannot be paused", ErrJobState, j.rec.Status)
+	}
+	j.cancel()
+	r.setStatus(j, JobPaused, "")
+	return copyJob(j.rec), nil
+}
+
+// Resume restarts a paused job at its next pending slice.
+func (r *JobRunner) Resume(id int64) (storage.JobRecord, error) {
+	r.mu.Lock()
+	j, ok := r.jobs[id]
+	if !ok {
+		r.mu.Unlock()
+		return storage.JobRecord{}, ErrJobNotFound
+	}
+	if j.rec.Status != JobPaused {
+		r.mu.Unlock()
+		return copyJob(j.rec), fmt.Errorf("%w: %s job cannot be resumed", ErrJobState, j.rec.Status)
+	}
+	done := j.done
+	r.mu.Unlock()
+	if done != nil {
+		<-done // let a slice in flight when the job was paused finish
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if j.rec.Status != JobPaused {
+		return copyJob(j.rec), fmt.Errorf("%w: %s job cannot be resumed", ErrJobState, j.rec.Status)
+	}
+	r.setStatus(j, JobRunning, "")
+	r.start(j)
+	return copyJob(j.rec), nil
+}
+
+// Cancel stops a running or paused job and drops its pending slices.
+func (r *JobRunner) Cancel(id int64) (storage.JobRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	j, ok := r.jobs[id]
+	if !ok {
+		return storage.JobRecord{}, ErrJobNotFound
+	}
+	if j.rec.Status != JobRunning && j.rec.Status != JobPaused {
+		return copyJob(j.rec), fmt.Errorf("%w: %s job cannot be cancelled", ErrJobState, j.rec.Status)
+	}
+	r.stop(j, JobCancelled, "")
+	return copyJob(j.rec), nil
+}
+
+// CancelAll cancels every running or paused job.
+func (r *JobRunner) CancelAll() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, j := range r.jobs {
+		if j.rec.Status == JobRunning || j.rec.Status == JobPaused {
+			r.stop(j, JobCancelled, "")
+		}
+	}
+}
+
+// start launches the goroutine working j. r.mu must be held.
+func (r *JobRunner) start(j *job) {
+	ctx, cancel := context.WithCancel(r.ctx)
+	j.cancel = cancel
+	j.done = make(chan struct{})
+	go r.run(ctx, j)
+}
+
+// stop ends j with status and marks its pending slices cancelled. r.mu must be held.
+func (r *JobRunner) stop(j *job, status, errMsg string) {
+	if j.cancel != nil {
+		j.cancel()
+	}
+	for i := range j.rec.Slices {
+		if j.rec.Slices[i].Status == SlicePending {
+			r.setSlice(j, i, SliceCancelled, 0, time.Time{})
+		}
+	}
+	r.setStatus(j, status, errMsg)
+}
+
+// run executes j's pending slices until it finishes or ctx is cancelled.
+func (r *JobRunner) run(ctx context.Context, j *job) {
+	defer close(j.done)
+	if j.params.Rate > 0 {
+		r.participate(ctx, j)
+		return
+	}
+	first := true
+	for i := 0; ; i++ {
+		r.mu.Lock()
+		if i >= len(j.rec.Slices) {
+			if j.rec.Status == JobRunning {
+				r.setStatus(j, JobDone, "")
+			}
+			r.mu.Unlock()
+			return
+		}
+		pending := j.rec.Slices[i].Status == SlicePending
+		size := j.rec.Slices[i].Size
+		left := 0.0 // volume of this and the later pending slices
+		for _, s := range j.rec.Slices[i:] {
+			if s.Status == SlicePending {
+				left += s.Size
+			}
+		}
+		interval := j.rec.Interval
+		if i < len(j.params.Delays) {
+			interval = j.params.Delays[i]
+		}
+		r.mu.Unlock()
+		if !pending {
+			continue
+		}
+		until := j.params.Until
+		if !first {
+			if d := time.Until(until); !until.IsZero() && d < interval {
+				interval = max(d, 0)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+		}
+		first = false
+		if ctx.Err() != nil || r.halt(j) {
+			return
+		}
+
+		// past the deadline the rest of the schedule goes out now
+		late := !until.IsZero() && !time.Now().Before(until)
+		share := size / left
+		if late {
+			size, share = left, 1
+		}
+		filled, err := r.slice(j, size, share)
+		status := SliceDone
+		if err != nil {
+			status = SliceFailed
+		}
+		r.mu.Lock()
+		r.setSlice(j, i, status, filled, time.Now())
+		if late && err == nil {
+			fmt.Printf("Job %d: deadline reached, sent the remainder in slice %d\n", j.rec.ID, i+1)
+			for k := i + 1; k < len(j.rec.Slices); k++ {
+				if j.rec.Slices[k].Status == SlicePending {
+					r.setSlice(j, k, SliceCancelled, 0, time.Time{})
+				}
+			}
+		}
+		if err != nil && j.rec.Status == JobRunning {
+			fmt.Printf("Job %d slice %d failed: %v\n", j.rec.ID, i, err)
+			r.stop(j, JobFailed, err.Error())
+		}
+		r.mu.Unlock()
+		if err != nil {
+			return
+		}
+	}
+}
+
+// participate runs a percent-of-volume job: every interval it executes the
+// job's rate of the volume traded since the last slice, capped at what is
+// left of the stake, until the stake is filled or the job's time is up.
+func (r *JobRunner) participate(ctx context.Context, j *job) {
+	for {
+		r.mu.Lock()
+		remaining := j.rec.Volume
+		for _, s := range j.rec.Slices {
+			remaining -= s.Filled
+		}
+		interval, since := j.rec.Interval, j.since
+		r.mu.Unlock()
+		if remaining <= fillEpsilon {
+			r.finish(j, "")
+			return
+		}
+		if !time.Now().Before(j.params.Until) {
+			r.finish(j, fmt.Sprintf("time up with %.8f of %.8f unexecuted", remaining, j.rec.Volume))
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+		if ctx.Err() != nil || r.halt(j) {
+			return
+		}
+
+		traded, newest, err := tradedSince(ctx, r.Trades, j.rec.Pair, since)
+		if err != nil {
+			r.mu.Lock()
+			if j.rec.Status == JobRunning {
+				fmt.Printf("Job %d failed: %v\n", j.rec.ID, err)
+				r.stop(j, JobFailed, err.Error())
+			}
+			r.mu.Unlock()
+			return
+		}
+		r.mu.Lock()
+		j.since = newest
+		r.mu.Unlock()
+		size := math.Min(traded*j.params.Rate, remaining)
+		if size <= fillEpsilon {
+			continue
+		}
+
+		filled, err := r.slice(j, size, size/remaining)
+		s := storage.SliceRecord{TradeID: j.rec.ID, Size: size, Weight: size / j.rec.Volume, Status: SliceDone, Filled: filled, ExecutedAt: time.Now()}
+		if err != nil {
+			s.Status = SliceFailed
+		}
+		r.mu.Lock()
+		s.Index = len(j.rec.Slices)
+		j.rec.Slices = append(j.rec.Slices, s)
+		if r.Store != nil {
+			if err := r.Store.AddSlice(j.rec.ID, s); err != nil {
+				fmt.Printf("Save job %d slice %d: %v\n", j.rec.ID, s.Index, err)
+			}
+		}
+		if err != nil && j.rec.Status == JobRunning {
+			fmt.Printf("Job %d slice %d failed: %v\n", j.rec.ID, s.Index, err)
+			r.stop(j, JobFailed, err.Error())
+		}
+		r.mu.Unlock()
+		if err != nil {
+			return
+		}
+	}
+}
+
+// finish marks a running job done, noting why any remainder was left.
+func (r *JobRunner) finish(j *job, note string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if j.rec.Status == JobRunning {
+		if note != "" {
+			fmt.Printf("Job %d: %s\n", j.rec.ID, note)
+		}
+		r.setStatus(j, JobDone, note)
+	}
+}
+
+// halt cancels j and reports true while trading is halted.
+func (r *JobRunner) halt(j *job) bool {
+	if r.Halted == nil || !r.Halted() {
+		return false
+	}
+	r.mu.Lock()
+	if j.rec.Status == JobRunning {
+		r.stop(j, JobCancelled, ErrHalted.Error())
+	}
+	r.mu.Unlock()
+	return true
+}
+
+// tradesPage is the most trades ListTrades returns per call.
+const tradesPage = 100
+
+// tradedSince sums the base volume of pair's trades after since and returns
+// it with the newest trade time seen, or since when there were none.
+func tradedSince(ctx context.Context, client Client, pair string, since time.Time) (float64, time.Time, error) {
+	trades, err := tradesSince(ctx, client, pair, since)
+	if err != nil {
+		return 0, since, err
+	}
+	var volume float64
+	newest := since
+	for _, t := range trades {
+		volume += t.Volume.Float64()
+		if ts := time.Time(t.Timestamp); ts.After(newest) {
+			newest = ts
+		}
+	}
+	return volume, newest, nil
+}
+
+// tradesSince pages through ListTrades for pair's trades after since.
+func tradesSince(ctx context.Context, client Client, pair string, since time.Time) ([]luno.PublicTrade, error) {
+	var trades []luno.PublicTrade
+	for {
+		resp, err := client.ListTrades(ctx, &luno.ListTradesRequest{Pair: pair, Since: luno.Time(since)})
+		if err != nil {
+			return nil, fmt.Errorf("list trades %s: %w", pair, err)
+		}
+		newest := since
+		for _, t := range resp.Trades {
+			if ts := time.Time(t.Timestamp); ts.After(since) {
+				trades = append(trades, t)
+				if ts.After(newest) {
+					newest = ts
+				}
+			}
+		}
+		if len(resp.Trades) < tradesPage || !newest.After(since) {
+			return trades, nil
+		}
+		since = newest
+	}
+}
+
+// slice executes size of j's signal, share of what the job has left, and
+// returns the base volume it filled. It runs under the runner's context so
+// pausing does not abandon an order that is already working.
+func (r *JobRunner) slice(j *job, size, share float64) (float64, error) {
+	r.mu.Lock()
+	ctx := r.ctx
+	r.mu.Unlock()
+	p := j.params
+	md := p.Market
+	if r.Market != nil {
+		if fresh, err := r.Market.MarketData(ctx, p.Config.Pair); err == nil && fresh.Bid > 0 && fresh.Ask > 0 {
+			fresh.Timestamp = md.Timestamp // slices of one signal share its timestamp
+			md = fresh
+		}
+	}
+	cfg := p.Config
+	cfg.StakeSize, cfg.SliceShare = size, share
+	var before float64
+	if r.Positions != nil {
+		before, _ = r.Positions.CurrentPosition()
+	}
+	if err := r.Inner.Execute(ctx, p.Signal, md, cfg); err != nil {
+		return 0, err
+	}
+	if r.Positions == nil {
+		return size, nil
+	}
+	after, _ := r.Positions.CurrentPosition()
+	return math.Abs(after - before), nil
+}
+
+// setStatus updates and persists j's status. r.mu must be held.
+func (r *JobRunner) setStatus(j *job, status, errMsg string) {
+	j.rec.Status, j.rec.Error, j.rec.Updated = status, errMsg, time.Now()
+	if r.Store != nil {
+		if err := r.Store.UpdateJob(j.rec.ID, status, errMsg); err != nil {
+			fmt.Printf("Save job %d: %v\n", j.rec.ID, err)
+		}
+	}
+}
+
+// setSlice updates and persists slice i of j. r.mu must be held.
+func (r *JobRunner) setSlice(j *job, i int, status string, filled float64, at time.Time) {
+	s := &j.rec.Slices[i]
+	s.Status, s.Filled, s.ExecutedAt = status, filled, at
+	if r.Store != nil {
+		if err := r.Store.UpdateSlice(j.rec.ID, i, status, filled, at); err != nil {
+			fmt.Printf("Save job %d slice %d: %v\n", j.rec.ID, i, err)
+		}
+	}
+}
+
+// copyJob returns rec with its own slice list.
+func copyJob(rec storage.JobRecord) storage.JobRecord {
+	rec.Slices = append([]storage.SliceRecord(nil), rec.Slices...)
+	return rec
+}
@@ -0,0 +1,155 @@
+package bot
+
+import (
+	"context"
+	"math"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/luno/luno-bot/storage"
+)
+
+// stakeRecorder records each slice's stake and counts it as filled.
+type stakeRecorder struct {
+	mu     sync.Mutex
+	stakes []float64
+	filled float64
+}
+
+func (s *stakeRecorder) Execute(ctx context.Context, sig Signal, md MarketData, cfg Config) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stakes = append(s.stakes, cfg.StakeSize)
+	s.filled += cfg.StakeSize
+	return nil
+}
+
+func (s *stakeRecorder) CancelAll(ctx context.Context) error { return nil }
+
+func (s *stakeRecorder) CurrentPosition() (position, entryPrice float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.filled, 100
+}
+
+// waitFor polls cond until it holds or a second passes.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if cond() {
+			return
+		}
+	}
+	t.Fatal("Timed out waiting for job")
+}
+
+func TestJobRunnerPauseResumeAndRestore(t *testing.T) {
+	store, err := storage.NewSQLiteStore(filepath.Join(t.TempDir(), "jobs.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	md := MarketData{Bid: 100, Ask: 100, Timestamp: time.Now()}
+	cfg := Config{Pair: "XBTZAR", StakeSize: 1, PositionLimit: 1}
+	weights := []float64{0.5, 0.25, 0.25}
+
+	sim := NewSimulatedExecutor()
+	r := NewJobRunner("sim", sim, sim, store)
+	job, err := r.Submit("vwap", SignalBuy, md, cfg, weights, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { j, _ := r.Job(job.ID); return j.Slices[0].Status == SliceDone })
+	if !r.Active("XBTZAR") {
+		t.Error("Expected an active job")
+	}
+	if job, err = r.Pause(job.ID); err != nil || job.Status != JobPaused {
+		t.Fatalf("Pause: %v, status %s", err, job.Status)
+	}
+	if _, err := r.Pause(job.ID); err == nil {
+		t.Error("Expected pausing a paused job to fail")
+	}
+
+	// a new runner on the same store sees the paused job and resumes it,
+	// adding to the position the first slice opened
+	sim2 := NewSimulatedExecutor()
+	sim2.Restore(0.5, 100, nil)
+	r2 := NewJobRunner("sim", sim2, sim2, store)
+	if err := r2.Restore(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	restored, ok := r2.Job(job.ID)
+	if !ok || restored.Status != JobPaused || restored.Slices[0].Status != SliceDone || restored.Slices[0].Filled != 0.5 {
+		t.Fatalf("Restored job %+v", restored)
+	}
+	if _, err := r2.Resume(job.ID); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { j, _ := r2.Job(job.ID); return j.Slices[1].Status == SliceDone })
+	if j, _ := r2.Job(job.ID); j.Slices[1].Filled != 0.25 {
+		t.Errorf("Resumed slice filled %v, want 0.25", j.Slices[1].Filled)
+	}
+	if pos, _ := sim2.CurrentPosition(); pos != 0.75 {
+		t.Errorf("Position %v after two slices, want 0.75", pos)
+	}
+
+	if job, err = r2.Cancel(job.ID); err != nil || job.Status != JobCancelled {
+		t.Fatalf("Cancel: %v, status %s", err, job.Status)
+	}
+	jobs, err := store.ListJobs("sim")
+	if err != nil || len(jobs) != 1 {
+		t.Fatalf("ListJobs: %v, %d jobs", err, len(jobs))
+	}
+	var states []string
+	for _, s := range jobs[0].Slices {
+		states = append(states, s.Status)
+	}
+	if jobs[0].Status != JobCancelled || states[0] != SliceDone || states[1] != SliceDone || states[2] != SliceCancelled {
+		t.Errorf("Persisted job %s with slices %v", jobs[0].Status, states)
+	}
+}
+
+func TestJobRunnerFillsEverySlice(t *testing.T) {
+	md := MarketData{Bid: 100, Ask: 100, Timestamp: time.Now()}
+	cfg := Config{Pair: "XBTZAR", StakeSize: 1, PositionLimit: 1, Cooldown: time.Hour}
+	sim := NewSimulatedExecutor()
+	r := NewJobRunner("sim", sim, sim, nil)
+	ctx := context.Background()
+
+	// ticks keep reaching the executor while the job works
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				sim.Execute(ctx, SignalNone, md, cfg)
+				sim.CurrentPosition()
+			}
+		}
+	}()
+
+	for _, step := range []struct {
+		sig  Signal
+		want float64
+	}{{SignalBuy, 1}, {SignalSell, 0}} {
+		job, err := r.Submit("twap", step.sig, md, cfg, []float64{0.5, 0.25, 0.25}, time.Millisecond)
+		if err != nil {
+			t.Fatal(err)
+		}
+		waitFor(t, func() bool { j, _ := r.Job(job.ID); return j.Status == JobDone })
+		j, _ := r.Job(job.ID)
+		for i, s := range j.Slices {
+			if want := j.Volume * s.Weight; math.Abs(s.Filled-want) > 1e-9 {
+				t.Errorf("%v slice %d filled %v, want %v", step.sig, i, s.Filled, want)
+			}
+		}
+		if pos, _ := sim.CurrentPosition(); math.Abs(pos-step.want) > 1e-9 {
+			t.Errorf("Position %v after %v job, want %v", pos, step.sig, step.want)
+		}
+	}
+}
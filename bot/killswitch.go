@@ -0,0 +1,218 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/luno/luno-bot/storage"
+)
+
+// ErrHalted is returned by BreakerExecutor while the kill switch is tripped.
+var ErrHalted = errors.New("trading halted by kill switch")
+
+// KillSwitchStore persists the halted state; storage.SQLiteStore implements it.
+type KillSwitchStore interface {
+	LoadKillSwitch() (storage.KillSwitchState, error)
+	SaveKillSwitch(storage.KillSwitchState) error
+}
+
+// KillSwitch is a global trading halt shared by every BreakerExecutor. Once
+// tripped it stays halted, across restarts, until Reset is called.
+type KillSwitch struct {
+	store KillSwitchStore
+
+	mu        sync.Mutex
+	state     storage.KillSwitchState
+	executors []Executor // cancelled when the switch trips
+}
+
+// NewKillSwitch loads the persisted state from store, which may be nil to keep state in memory.
+func NewKillSwitch(store KillSwitchStore) (*KillSwitch, error) {
+	k := &KillSwitch{store: store}
+	if store != nil {
+		state, err := store.LoadKillSwitch()
+		if err != nil {
+			return nil, fmt.Errorf("load kill switch: %w", err)
+		}
+		k.state = state
+	}
+	return k, nil
+}
+
+// Register adds executors whose open orders are cancelled when the switch trips.
+func (k *KillSwitch) Register(execs ...Executor) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.executors = append(k.executors, execs...)
+}
+
+// State returns the current halt state.
+func (k *KillSwitch) State() storage.KillSwitchState {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.state
+}
+
+// Halted reports whether trading is halted.
+func (k *KillSwitch) Halted() bool {
+	return k.State().Halted
+}
+
+// Trip halts trading, persists the halt and cancels open orders on every
+// registered executor. Tripping an already halted switch keeps the first reason.
+func (k *KillSwitch) Trip(ctx context.Context, reason string) error {
+	k.mu.Lock()
+	if k.state.Halted {
+		k.mu.Unlock()
+		return nil
+	}
+	k.state = storage.KillSwitchState{Halted: true, Reason: reason, TrippedAt: time.Now()}
+	state := k.state
+	execs := append([]Executor(nil), k.executors...)
+	k.mu.Unlock()
+
+	fmt.Printf("Kill switch tripped: %s\n", reason)
+	var firstErr error
+	if k.store != nil {
+		if err := k.store.SaveKillSwitch(state); err != nil {
+			firstErr = fmt.Errorf("save kill switch: %w", err)
+		}
+	}
+	for _, e := range execs {
+		if err := e.CancelAll(ctx); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("cancel orders: %w", err)
+		}
+	}
+	return firstErr
+}
+
+// Reset clears the halt so trading can resume.
+func (k *KillSwitch) Reset() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.store != nil {
+		if err := k.store.SaveKillSwitch(storage.KillSwitchState{}); err != nil {
+			return fmt.Errorf("save kill switch: %w", err)
+		}
+	}
+	k.state = storage.KillSwitchState{}
+	return nil
+}
+
+// EquityMarks reports the latest equity mark; Portfolio implements it.
+type EquityMarks interface {
+	Snapshot() storage.EquitySnapshot
+}
+
+// BreakerExecutor blocks an Executor while the kill switch is halted and trips
+// it when equity falls Config.MaxDrawdown below its high-water mark,
+// Config.MaxConsecutiveErrors execution errors in a row, or market data older
+// than Config.StaleDataSeconds.
+type BreakerExecutor struct {
+	Inner  Executor
+	Switch *KillSwitch
+	// Equity is the chain's marked equity, normally the Portfolio wrapping
+	// the breaker; its drawdown trips the switch on the next signal. Without
+	// it only ErrMaxDrawdown from the inner executor trips on drawdown.
+	Equity EquityMarks
+
+	mu       sync.Mutex
+	failures int // consecutive execution errors
+}
+
+// NewBreakerExecutor constructs a BreakerExecutor and registers inner with ks for cancellation.
+func NewBreakerExecutor(inner Executor, ks *KillSwitch) *BreakerExecutor {
+	ks.Register(inner)
+	return &BreakerExecutor{Inner: inner, Switch: ks}
+}
+
+// Execute delegates unless halted, tripping the switch when a breaker condition is met.
+func (b *BreakerExecutor) Execute(ctx context.Context, sig Signal, md MarketData, cfg Config) error {
+	return b.execute(ctx, b.Inner, sig, md, cfg)
+}
+
+// Through returns an Executor that sends orders to inner instead of Inner
+// behind this breaker, sharing its error count and drawdown checks, e.g. for
+// orders that skip sizing and exits but must trip the same breaker.
+func (b *BreakerExecutor) Through(inner Executor) Executor {
+	b.Switch.Register(inner)
+	return breakerRoute{b: b, inner: inner}
+}
+
+// breakerRoute is a BreakerExecutor sending orders to another executor.
+type breakerRoute struct {
+	b     *BreakerExecutor
+	inner Executor
+}
+
+// Execute applies the breaker to an order for inner.
+func (r breakerRoute) Execute(ctx context.Context, sig Signal, md MarketData, cfg Config) error {
+	return r.b.execute(ctx, r.inner, sig, md, cfg)
+}
+
+// CancelAll delegates cancellation.
+func (r breakerRoute) CancelAll(ctx context.Context) error {
+	return r.inner.CancelAll(ctx)
+}
+
+// execute runs the breaker's checks around an order sent to inner.
+func (b *BreakerExecutor) execute(ctx context.Context, inner Executor, sig Signal, md MarketData, cfg Config) error {
+	if st := b.Switch.State(); st.Halted {
+		return fmt.Errorf("%w: %s", ErrHalted, st.Reason)
+	}
+	if cfg.StaleDataSeconds > 0 && !md.Timestamp.IsZero() {
+		if age := time.Since(md.Timestamp); age > time.Duration(cfg.StaleDataSeconds)*time.Second {
+			reason := fmt.Sprintf("stale market data for %s: %s old", cfg.Pair, age.Round(time.Second))
+			return b.trip(ctx, reason)
+		}
+	}
+	if cfg.MaxDrawdown > 0 && b.Equity != nil {
+		if snap := b.Equity.Snapshot(); !snap.Time.IsZero() && snap.HighWater-snap.Equity > cfg.MaxDrawdown {
+			reason := fmt.Sprintf("%s equity %.2f is %.2f below its high of %.2f, limit %.2f", cfg.Pair, snap.Equity, snap.HighWater-snap.Equity, snap.HighWater, cfg.MaxDrawdown)
+			return b.trip(ctx, reason)
+		}
+	}
+
+	err := inner.Execute(ctx, sig, md, cfg)
+	if err == nil {
+		b.mu.Lock()
+		b.failures = 0
+		b.mu.Unlock()
+		return nil
+	}
+	if errors.Is(err, ErrMaxDrawdown) && cfg.MaxDrawdown > 0 {
+		b.trip(ctx, err.Error())
+		return err
+	}
+	var re *RiskError
+	if errors.As(err, &re) {
+		return err // rejected before reaching the exchange
+	}
+	b.mu.Lock()
+	b.failures++
+	n := b.failures
+	b.mu.Unlock()
+	if cfg.MaxConsecutiveErrors > 0 && n >= cfg.MaxConsecutiveErrors {
+		b.trip(ctx, fmt.Sprintf("%d consecutive execution errors, last: %v", n, err))
+	}
+	return err
+}
+
+// trip halts trading for reason and returns ErrHalted.
+func (b *BreakerExecutor) trip(ctx context.Context, reason string) error {
+	if err := b.Switch.Trip(ctx, reason); err != nil {
+		fmt.Printf("Kill switch: %v\n", err)
+	}
+	b.mu.Lock()
+	b.failures = 0
+	b.mu.Unlock()
+	return fmt.Errorf("%w: %s", ErrHalted, reason)
+}
+
+// CancelAll delegates cancellation.
+func (b *BreakerExecutor) CancelAll(ctx context.Context) error {
+	return b.Inner.CancelAll(ctx)
+}
@@ -0,0 +1,140 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/luno/luno-go/streaming"
+)
+
+// failingExecutor always fails with err.
+type failingExecutor struct{ err error }
+
+func (f failingExecutor) Execute(ctx context.Context, sig Signal, md MarketData, cfg Config) error {
+	return f.err
+}
+func (f failingExecutor) CancelAll(ctx context.Context) error { return nil }
+
+func TestBreakerExecutorTrips(t *testing.T) {
+	ctx := context.Background()
+	md := MarketData{Bid: 99, Ask: 101, Timestamp: time.Now()}
+
+	ks, _ := NewKillSwitch(nil)
+	b := NewBreakerExecutor(failingExecutor{errors.New("timeout")}, ks)
+	cfg := Config{Pair: "XBTZAR", MaxConsecutiveErrors: 3}
+	for i := 0; i < 3; i++ {
+		b.Execute(ctx, SignalBuy, md, cfg)
+	}
+	if !ks.Halted() {
+		t.Fatal("Expected halt after 3 consecutive errors")
+	}
+
+	// risk rejections never reach the exchange and do not count
+	ks, _ = NewKillSwitch(nil)
+	b = NewBreakerExecutor(failingExecutor{&RiskError{Check: ErrCooldown}}, ks)
+	for i := 0; i < 5; i++ {
+		b.Execute(ctx, SignalBuy, md, cfg)
+	}
+	if ks.Halted() {
+		t.Error("Risk rejections should not trip the breaker")
+	}
+
+	ks, _ = NewKillSwitch(nil)
+	b = NewBreakerExecutor(&countingExecutor{}, ks)
+	stale := MarketData{Bid: 99, Ask: 101, Timestamp: time.Now().Add(-time.Minute)}
+	if err := b.Execute(ctx, SignalBuy, stale, Config{StaleDataSeconds: 30}); !errors.Is(err, ErrHalted) {
+		t.Errorf("Expected ErrHalted on stale data, got %v", err)
+	}
+
+	ks, _ = NewKillSwitch(nil)
+	b = NewBreakerExecutor(failingExecutor{ErrMaxDrawdown}, ks)
+	b.Execute(ctx, SignalBuy, md, Config{MaxDrawdown: 10})
+	if !ks.Halted() {
+		t.Error("Expected halt on max drawdown")
+	}
+
+	// a live chain trips on the drawdown its portfolio marks
+	ks, _ = NewKillSwitch(nil)
+	b = NewBreakerExecutor(&countingExecutor{}, ks)
+	held := NewSimulatedExecutor()
+	held.Restore(1, 100, nil)
+	p := NewPortfolio("live", b, held, nil)
+	b.Equity = p
+	cfg = Config{Pair: "XBTZAR", InitialEquity: 1000, MaxDrawdown: 10}
+	for _, mid := range []float64{100, 95, 85} {
+		if err := p.Execute(ctx, SignalNone, MarketData{Bid: mid, Ask: mid, Timestamp: time.Now()}, cfg); err != nil {
+			t.Fatalf("Mid %v: %v", mid, err)
+		}
+	}
+	if err := p.Execute(ctx, SignalNone, md, cfg); !errors.Is(err, ErrHalted) || !ks.Halted() {
+		t.Errorf("Expected halt 15 below the high, got %v", err)
+	}
+}
+
+func TestBreakerThroughSharesErrorCount(t *testing.T) {
+	ctx := context.Background()
+	md := MarketData{Bid: 99, Ask: 101, Timestamp: time.Now()}
+	cfg := Config{Pair: "XBTZAR", MaxConsecutiveErrors: 2}
+	ks, _ := NewKillSwitch(nil)
+	b := NewBreakerExecutor(failingExecutor{errors.New("timeout")}, ks)
+	direct := b.Through(failingExecutor{errors.New("rejected")})
+	b.Execute(ctx, SignalBuy, md, cfg)
+	direct.Execute(ctx, SignalBuy, md, cfg)
+	if !ks.Halted() {
+		t.Fatal("Expected errors on both routes to trip the breaker")
+	}
+	if err := direct.Execute(ctx, SignalBuy, md, cfg); !errors.Is(err, ErrHalted) {
+		t.Errorf("Expected ErrHalted, got %v", err)
+	}
+}
+
+func TestBreakerTripsOnStaleStream(t *testing.T) {
+	ctx := context.Background()
+	s := NewStreamingMarketData(&restBookClient{}, "", "")
+	s.dial = func(string, ...streaming.DialOption) (bookStream, error) {
+		return &fakeStream{snap: book(100, 101)}, nil
+	}
+	if err := s.Subscribe("XBTZAR"); err != nil {
+		t.Fatal(err)
+	}
+	ps := s.streams["XBTZAR"]
+	s.onUpdate("XBTZAR", ps)
+
+	ks, _ := NewKillSwitch(nil)
+	b := NewBreakerExecutor(&stakeRecorder{}, ks)
+	cfg := Config{Pair: "XBTZAR", StaleDataSeconds: 10}
+	md, err := s.MarketData(ctx, "XBTZAR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Execute(ctx, SignalBuy, md, cfg); err != nil || ks.Halted() {
+		t.Fatalf("Fresh quote: %v, halted %v", err, ks.Halted())
+	}
+
+	// the stream has gone quiet for longer than the limit but not StaleAfter
+	s.mu.Lock()
+	ps.lastUpdate = time.Now().Add(-30 * time.Second)
+	s.mu.Unlock()
+	if md, err = s.MarketData(ctx, "XBTZAR"); err != nil || md.Bid != 100 {
+		t.Fatalf("Quiet stream %+v, %v", md, err)
+	}
+	if err := b.Execute(ctx, SignalBuy, md, cfg); !errors.Is(err, ErrHalted) {
+		t.Errorf("Expected ErrHalted on a quiet stream, got %v", err)
+	}
+}
+
+func TestTripKeepsPaperPositions(t *testing.T) {
+	ctx := context.Background()
+	sim := NewSimulatedExecutor()
+	sim.Restore(1, 100, nil)
+	ks, _ := NewKillSwitch(nil)
+	NewBreakerExecutor(sim, ks)
+	if err := ks.Trip(ctx, "manual"); err != nil {
+		t.Fatal(err)
+	}
+	if pos, entry := sim.CurrentPosition(); pos != 1 || entry != 100 {
+		t.Errorf("Trip changed the paper position to %v at %v", pos, entry)
+	}
+}
@@ -0,0 +1,130 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	luno "github.com/luno/luno-go"
+	dec "github.com/luno/luno-go/decimal"
+)
+
+// ErrInsufficientFunds is returned when a paper account cannot pay for an order.
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
+// PaperLedger is a virtual multi-currency account for paper trading. Fills
+// move both the base and counter balances of their pair, with fees paid in
+// counter currency, and orders the balances cannot cover are refused. It
+// serves GetBalances like the live client, so paper and live accounts can be
+// shown side by side. Several simulated executors may share one ledger.
+type PaperLedger struct {
+	mu       sync.Mutex
+	balances map[string]float64 // by asset
+	accounts map[string]string  // account IDs by asset
+}
+
+// NewPaperLedger constructs a ledger holding balances, keyed by asset.
+func NewPaperLedger(balances map[string]float64) *PaperLedger {
+	l := &PaperLedger{balances: make(map[string]float64), accounts: make(map[string]string)}
+	for asset, v := range balances {
+		l.balances[asset] = v
+	}
+	return l
+}
+
+// SnapshotLedger constructs a ledger seeded with the account's current
+// balances, available plus reserved, keeping their account IDs.
+func SnapshotLedger(ctx context.Context, client Client) (*PaperLedger, error) {
+	resp, err := client.GetBalances(ctx, &luno.GetBalancesRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("get balances: %w", err)
+	}
+	l := NewPaperLedger(nil)
+	for _, b := range resp.Balance {
+		l.balances[b.Asset] += decFloat(b.Balance) + decFloat(b.Reserved)
+		if _, ok := l.accounts[b.Asset]; !ok {
+			l.accounts[b.Asset] = b.AccountId
+		}
+	}
+	return l, nil
+}
+
+// Balance returns the holding of asset.
+func (l *PaperLedger) Balance(asset string) float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.balances[asset]
+}
+
+// Balances returns all holdings by asset.
+func (l *PaperLedger) Balances() map[string]float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make(map[string]float64, len(l.balances))
+	for asset, v := range l.balances {
+		out[asset] = v
+	}
+	return out
+}
+
+// Trade books a fill on pair: a buy adds volume of base and pays
+// price*volume plus fee in counter, a sell does the reverse. It changes
+// nothing and returns ErrInsufficientFunds when the account cannot pay.
+func (l *PaperLedger) Trade(pair string, typ luno.OrderType, price, volume, fee float64) error {
+	base, counter := splitPair(pair)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if isBuyOrder(typ) {
+		cost := price*volume + fee
+		if have := l.balances[counter]; cost > have+fillEpsilon {
+			return fmt.Errorf("%w: buying %.8f %s costs %.8f %s, have %.8f", ErrInsufficientFunds, volume, base, cost, counter, have)
+		}
+		l.balances[base] += volume
+		l.balances[counter] -= cost
+		return nil
+	}
+	if have := l.balances[base]; volume > have+fillEpsilon {
+		return fmt.Errorf("%w: selling %.8f %s, have %.8f", ErrInsufficientFunds, volume, base, have)
+	}
+	l.balances[base] -= volume
+	l.balances[counter] += price*volume - fee
+	return nil
+}
+
+// GetBalances reports the ledger in the live client's format, optionally
+// limited to req.Assets.
+func (l *PaperLedger) GetBalances(ctx context.Context, req *luno.GetBalancesRequest) (*luno.GetBalancesResponse, error) {
+	want := make(map[string]bool)
+	if req != nil {
+		for _, a := range req.Assets {
+			want[a] = true
+		}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	assets := make([]string, 0, len(l.balances))
+	for asset := range l.balances {
+		if len(want) == 0 || want[asset] {
+			assets = append(assets, asset)
+		}
+	}
+	sort.Strings(assets)
+	resp := &luno.GetBalancesResponse{}
+	for _, asset := range assets {
+		id := l.accounts[asset]
+		if id == "" {
+			id = "paper-" + asset
+		}
+		resp.Balance = append(resp.Balance, luno.AccountBalance{
+			AccountId:   id,
+			Asset:       asset,
+			Balance:     dec.NewFromFloat64(l.balances[asset], 8),
+			Name:        "Paper " + asset,
+			Reserved:    dec.Zero(),
+			Unconfirmed: dec.Zero(),
+		})
+	}
+	return resp, nil
+}
@@ -0,0 +1,57 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	luno "github.com/luno/luno-go"
+)
+
+func TestPaperLedgerWithSimulatedExecutor(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	cfg := Config{Pair: "XBTZAR", StakeSize: 1, PositionLimit: 1, MaxDrawdown: 1e9}
+
+	poor := NewSimulatedExecutor()
+	poor.Ledger = NewPaperLedger(map[string]float64{"ZAR": 50})
+	if err := poor.Execute(ctx, SignalBuy, MarketData{Bid: 100, Ask: 100, Timestamp: now}, cfg); !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("Expected ErrInsufficientFunds, got %v", err)
+	}
+	if poor.Position != 0 || poor.Ledger.Balance("ZAR") != 50 {
+		t.Errorf("Rejected buy changed state: position %v, ZAR %v", poor.Position, poor.Ledger.Balance("ZAR"))
+	}
+
+	e := NewSimulatedExecutor()
+	e.Ledger = NewPaperLedger(map[string]float64{"ZAR": 1000})
+	if err := e.Execute(ctx, SignalBuy, MarketData{Bid: 100, Ask: 100, Timestamp: now}, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if xbt, zar := e.Ledger.Balance("XBT"), e.Ledger.Balance("ZAR"); xbt != 1 || zar != 900 {
+		t.Errorf("After buy: XBT %v, ZAR %v, want 1 and 900", xbt, zar)
+	}
+	if err := e.Execute(ctx, SignalSell, MarketData{Bid: 110, Ask: 110, Timestamp: now.Add(time.Minute)}, cfg); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := e.Ledger.GetBalances(ctx, &luno.GetBalancesRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Balance) != 2 || resp.Balance[0].Asset != "XBT" || resp.Balance[0].Balance.Float64() != 0 ||
+		resp.Balance[1].Asset != "ZAR" || resp.Balance[1].Balance.Float64() != 1010 {
+		t.Errorf("Balances after round trip: %+v", resp.Balance)
+	}
+
+	// a sell past the drawdown limit is still booked, closing the position
+	cfg.MaxDrawdown = 5
+	if err := e.Execute(ctx, SignalBuy, MarketData{Bid: 100, Ask: 100, Timestamp: now.Add(2 * time.Minute)}, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Execute(ctx, SignalSell, MarketData{Bid: 90, Ask: 90, Timestamp: now.Add(3 * time.Minute)}, cfg); !errors.Is(err, ErrMaxDrawdown) {
+		t.Fatalf("Expected ErrMaxDrawdown, got %v", err)
+	}
+	if e.Position != 0 || e.Ledger.Balance("XBT") != 0 || e.TotalPnL != 0 {
+		t.Errorf("After the breach: position %v, XBT %v, PnL %v", e.Position, e.Ledger.Balance("XBT"), e.TotalPnL)
+	}
+}
@@ -36,6 +36,11 @@ func (c *LunoClient) PostLimitOrder(ctx context.Context, req *luno.PostLimitOrde
 	return c.cli.PostLimitOrder(ctx, req)
 }
 
+// PostMarketOrder places a new market order.
+func (c *LunoClient) PostMarketOrder(ctx context.Context, req *luno.PostMarketOrderRequest) (*luno.PostMarketOrderResponse, error) {
+	return c.cli.PostMarketOrder(ctx, req)
+}
+
 // ListTrades fetches recent trades for backtesting.
 func (c *LunoClient) ListTrades(ctx context.Context, req *luno.ListTradesRequest) (*luno.ListTradesResponse, error) {
 	return c.cli.ListTrades(ctx, req)
@@ -50,3 +55,28 @@ func (c *LunoClient) GetCandles(ctx context.Context, req *luno.GetCandlesRequest
 func (c *LunoClient) GetBalances(ctx context.Context, req *luno.GetBalancesRequest) (*luno.GetBalancesResponse, error) {
 	return c.cli.GetBalances(ctx, req)
 }
+
+// GetOrderV2 fetches the current state of an order.
+func (c *LunoClient) GetOrderV2(ctx context.Context, req *luno.GetOrderV2Request) (*luno.GetOrderV2Response, error) {
+	return c.cli.GetOrderV2(ctx, req)
+}
+
+// ListOrders lists recent orders, optionally filtered by pair and state.
+func (c *LunoClient) ListOrders(ctx context.Context, req *luno.ListOrdersRequest) (*luno.ListOrdersResponse, error) {
+	return c.cli.ListOrders(ctx, req)
+}
+
+// StopOrder cancels an open order.
+func (c *LunoClient) StopOrder(ctx context.Context, req *luno.StopOrderRequest) (*luno.StopOrderResponse, error) {
+	return c.cli.StopOrder(ctx, req)
+}
+
+// Markets lists each market's precision, order limits and trading status.
+func (c *LunoClient) Markets(ctx context.Context, req *luno.MarketsRequest) (*luno.MarketsResponse, error) {
+	return c.cli.Markets(ctx, req)
+}
+
+// GetFeeInfo fetches the account's maker and taker fees for a pair.
+func (c *LunoClient) GetFeeInfo(ctx context.Context, req *luno.GetFeeInfoRequest) (*luno.GetFeeInfoResponse, error) {
+	return c.cli.GetFeeInfo(ctx, req)
+}
@@ -2,73 +2,194 @@ package bot
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
+	"time"
+
 	"github.com/google/uuid"
 	luno "github.com/luno/luno-go"
-	dec "github.com/luno/luno-go/decimal"
 )
 
 // LunoExecutor places real orders via Luno API with simple risk checks.
 // Uses local Client interface from this package.
 type LunoExecutor struct {
-	client     Client
+	client Client
+	orders *OrderManager
+
+	// PollInterval is the delay between order status checks while waiting for a fill.
+	PollInterval time.Duration
+	// FillTimeout bounds how long Execute waits for an order before stopping the remainder.
+	// Zero leaves the order resting; later calls pick up its fills.
+	FillTimeout time.Duration
+	// Markets rounds orders to each pair's precision and enforces its limits and status; nil applies none.
+	Markets *Markets
+
+	exec       sync.Mutex // held while an order is decided and worked, so chains and jobs take turns
+	mu         sync.Mutex
 	position   float64
 	entryPrice float64
+	realized   float64 // profit on closed volume, less fees
 }
 
 // NewLunoExecutor constructs a live executor using the given client.
 func NewLunoExecutor(client Client) *LunoExecutor {
-	return &LunoExecutor{client: client}
+	e := &LunoExecutor{client: client, PollInterval: 2 * time.Second, FillTimeout: 30 * time.Second}
+	e.orders = NewOrderManager(client, e.applyFill)
+	return e
 }
 
-// Execute sends a limit order based on signal, tracking position and enforcing limits.
+// Orders exposes the order manager tracking this executor's orders.
+func (e *LunoExecutor) Orders() *OrderManager {
+	return e.orders
+}
+
+// CurrentPosition returns the filled base position and its average entry price.
+func (e *LunoExecutor) CurrentPosition() (position, entryPrice float64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.position, e.entryPrice
+}
+
+// Execute sends a limit order based on signal, tracking position from actual fills.
 func (e *LunoExecutor) Execute(ctx context.Context, sig Signal, md MarketData, cfg Config) error {
-	price := (md.Bid + md.Ask) / 2
+	e.exec.Lock()
+	defer e.exec.Unlock()
+	typ, volume, ok, err := e.prepare(ctx, sig, cfg)
+	if !ok || err != nil {
+		return err
+	}
+	id, err := e.post(ctx, cfg, typ, (md.Bid+md.Ask)/2, volume, false)
+	if errors.Is(err, ErrMarketInactive) {
+		return nil // skip until trading resumes
+	}
+	if err != nil {
+		return err
+	}
+	return e.await(ctx, id)
+}
+
+// prepare syncs fills and returns the order side and volume for sig, with ok
+// false when there is nothing to place. A whole order buys only when flat,
+// counting a dust position as flat, and sells the position; a slice (Config.SliceShare) adds its stake to the
+// position or sells its share of it. Callers hold e.exec.
+func (e *LunoExecutor) prepare(ctx context.Context, sig Signal, cfg Config) (typ luno.OrderType, volume float64, ok bool, err error) {
+	// sync fills of earlier orders before deciding
+	if err := e.orders.Poll(ctx); err != nil {
+		return "", 0, false, fmt.Errorf("poll orders: %w", err)
+	}
+	if sig == SignalNone || len(e.orders.Open(cfg.Pair)) > 0 {
+		return "", 0, false, nil // nothing to do, or a resting order is still working
+	}
+	position, _ := e.CurrentPosition()
 	switch sig {
 	case SignalBuy:
-		if e.position != 0 {
-			return nil // already in position
-		}
-		if cfg.StakeSize > cfg.PositionLimit {
-			return fmt.Errorf("stake %.2f exceeds position limit %.2f", cfg.StakeSize, cfg.PositionLimit)
-		}
-		req := &luno.PostLimitOrderRequest{
-			Pair:             cfg.Pair,
-			Price:            dec.NewFromFloat64(price, 8),
-			Type:             luno.OrderTypeBid,
-			Volume:           dec.NewFromFloat64(cfg.StakeSize, 8),
-			BaseAccountId:    cfg.BaseAccountId,
-			CounterAccountId: cfg.CounterAccountId,
-			ClientOrderId:    uuid.New().String(),
+		if position != 0 && !e.Markets.Dust(cfg.Pair, position) && cfg.SliceShare == 0 {
+			return "", 0, false, nil // already in position
 		}
-		if _, err := e.client.PostLimitOrder(ctx, req); err != nil {
-			return err
+		if position+cfg.StakeSize > cfg.PositionLimit+fillEpsilon {
+			return "", 0, false, fmt.Errorf("stake %.2f on position %.2f exceeds position limit %.2f", cfg.StakeSize, position, cfg.PositionLimit)
 		}
-		e.position = cfg.StakeSize
-		e.entryPrice = price
+		return luno.OrderTypeBid, cfg.StakeSize, true, nil
 	case SignalSell:
-		if e.position == 0 {
-			return nil // no position to exit
-		}
-		req := &luno.PostLimitOrderRequest{
-			Pair:             cfg.Pair,
-			Price:            dec.NewFromFloat64(price, 8),
-			Type:             luno.OrderTypeAsk,
-			Volume:           dec.NewFromFloat64(e.position, 8),
-			BaseAccountId:    cfg.BaseAccountId,
-			CounterAccountId: cfg.CounterAccountId,
-			ClientOrderId:    uuid.New().String(),
+		volume := exitVolume(e.Markets, cfg.Pair, position, cfg.SliceShare)
+		if volume == 0 {
+			return "", 0, false, nil // no position to exit, or only dust
 		}
-		if _, err := e.client.PostLimitOrder(ctx, req); err != nil {
+		return luno.OrderTypeAsk, volume, true, nil
+	}
+	return "", 0, false, nil
+}
+
+// post places a limit order rounded to the pair's market rules and tracks it.
+func (e *LunoExecutor) post(ctx context.Context, cfg Config, typ luno.OrderType, price, volume float64, postOnly bool) (string, error) {
+	p, v, err := e.Markets.Order(cfg.Pair, typ, price, volume, cfg.BumpMinVolume)
+	if err != nil {
+		return "", err
+	}
+	if typ == luno.OrderTypeBid && v.Float64() > cfg.PositionLimit {
+		return "", fmt.Errorf("minimum volume %s exceeds position limit %.2f", v, cfg.PositionLimit)
+	}
+	req := &luno.PostLimitOrderRequest{
+		Pair:             cfg.Pair,
+		Price:            p,
+		Type:             typ,
+		Volume:           v,
+		PostOnly:         postOnly,
+		BaseAccountId:    cfg.BaseAccountId,
+		CounterAccountId: cfg.CounterAccountId,
+		ClientOrderId:    uuid.New().String(),
+	}
+	resp, err := e.client.PostLimitOrder(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	e.orders.Track(resp.OrderId, cfg.Pair, typ, p.Float64(), v.Float64())
+	return resp.OrderId, nil
+}
+
+// await waits up to FillTimeout for an order and stops whatever remains unfilled.
+func (e *LunoExecutor) await(ctx context.Context, id string) error {
+	if e.FillTimeout <= 0 {
+		return nil
+	}
+	o, err := e.orders.Wait(ctx, id, e.PollInterval, e.FillTimeout)
+	if err != nil {
+		return err
+	}
+	if !o.Done() {
+		if _, err := e.orders.Cancel(ctx, id); err != nil {
 			return err
 		}
-		e.position = 0
 	}
 	return nil
 }
 
-// CancelAll does nothing for live executor.
+// applyFill moves position by newly filled base volume, net of base fees,
+// and charges the fill's fees, valued at its price, to realized PnL.
+func (e *LunoExecutor) applyFill(o TrackedOrder, f Fill) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.realized -= f.FeeCounter + f.FeeBase*f.Counter/f.Base
+	if isBuyOrder(o.Type) {
+		total := e.position + f.Base - f.FeeBase
+		e.entryPrice = (e.entryPrice*e.position + f.Counter) / (e.position + f.Base)
+		e.position = total
+		return
+	}
+	e.realized += f.Counter - e.entryPrice*f.Base
+	e.position -= f.Base + f.FeeBase
+	if e.position <= fillEpsilon {
+		e.position, e.entryPrice = 0, 0
+	}
+}
+
+// RealizedPnL returns profit on volume sold, less all fees paid.
+func (e *LunoExecutor) RealizedPnL() float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.realized
+}
+
+// RestoreRealized resumes realized PnL from a value saved before a restart.
+func (e *LunoExecutor) RestoreRealized(realized float64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.realized = realized
+}
+
+// Restore replaces position state with values rebuilt from the exchange and
+// tracks the given open orders so their fills and cancels are managed.
+func (e *LunoExecutor) Restore(position, entryPrice float64, open []luno.Order) {
+	e.mu.Lock()
+	e.position, e.entryPrice = position, entryPrice
+	e.mu.Unlock()
+	for _, o := range open {
+		e.orders.Adopt(o)
+	}
+}
+
+// CancelAll stops all open orders on the exchange.
 func (e *LunoExecutor) CancelAll(ctx context.Context) error {
-	// implement if needed to cancel outstanding orders
-	return nil
+	return e.orders.CancelAll(ctx)
 }
@@ -0,0 +1,86 @@
+package bot
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/luno/luno-bot/storage"
+	luno "github.com/luno/luno-go"
+	dec "github.com/luno/luno-go/decimal"
+)
+
+// feeFillClient fills limit orders in full at their price, charging buys a base
+// fee and sells a counter fee.
+type feeFillClient struct {
+	Client
+	posts               []luno.PostLimitOrderRequest
+	feeBase, feeCounter float64
+}
+
+func (c *feeFillClient) PostLimitOrder(ctx context.Context, req *luno.PostLimitOrderRequest) (*luno.PostLimitOrderResponse, error) {
+	c.posts = append(c.posts, *req)
+	return &luno.PostLimitOrderResponse{OrderId: strconv.Itoa(len(c.posts) - 1)}, nil
+}
+
+func (c *feeFillClient) GetOrderV2(ctx context.Context, req *luno.GetOrderV2Request) (*luno.GetOrderV2Response, error) {
+	i, _ := strconv.Atoi(req.Id)
+	p := c.posts[i]
+	resp := &luno.GetOrderV2Response{OrderId: req.Id, Status: luno.StatusComplete, Base: p.Volume, Counter: p.Price.Mul(p.Volume), FeeBase: dec.Zero(), FeeCounter: dec.Zero()}
+	if p.Type == luno.OrderTypeBid {
+		resp.FeeBase = dec.NewFromFloat64(c.feeBase, 8)
+	} else {
+		resp.FeeCounter = dec.NewFromFloat64(c.feeCounter, 8)
+	}
+	return resp, nil
+}
+
+func (c *feeFillClient) ListOrders(ctx context.Context, req *luno.ListOrdersRequest) (*luno.ListOrdersResponse, error) {
+	return &luno.ListOrdersResponse{}, nil
+}
+
+func TestLunoExecutorChargesFees(t *testing.T) {
+	ctx := context.Background()
+	fc := &feeFillClient{feeBase: 0.01, feeCounter: 0.5}
+	e := NewLunoExecutor(fc)
+	cfg := Config{Pair: "XBTZAR", StakeSize: 1, PositionLimit: 1}
+	if err := e.Execute(ctx, SignalBuy, MarketData{Bid: 100, Ask: 100}, cfg); err != nil {
+		t.Fatal(err)
+	}
+	// the base fee leaves 0.99 held and costs 0.01 at 100
+	if pos, entry := e.CurrentPosition(); math.Abs(pos-0.99) > 1e-9 || entry != 100 {
+		t.Errorf("Position %v at %v, want 0.99 at 100", pos, entry)
+	}
+	if got := e.RealizedPnL(); math.Abs(got+1) > 1e-9 {
+		t.Errorf("Realized %v after the buy, want -1", got)
+	}
+	if err := e.Execute(ctx, SignalSell, MarketData{Bid: 110, Ask: 110}, cfg); err != nil {
+		t.Fatal(err)
+	}
+	// 0.99 sold 10 above entry, less both fees
+	if got := e.RealizedPnL(); math.Abs(got-(9.9-1-0.5)) > 1e-9 {
+		t.Errorf("Realized %v after the sell, want 8.4", got)
+	}
+}
+
+func TestPortfolioRestoresLiveMark(t *testing.T) {
+	saved := storage.EquitySnapshot{Time: time.Now().Add(-time.Hour), Executor: "live", Pair: "XBTZAR", Realized: 50, Equity: 1050, HighWater: 1200}
+	cfg := Config{Pair: "XBTZAR", InitialEquity: 1000}
+
+	live := NewLunoExecutor(&feeFillClient{})
+	p := NewPortfolio("live", live, live, nil)
+	p.Restore(saved)
+	if s := p.Mark(MarketData{Bid: 100, Ask: 100}, cfg); s.Realized != 50 || s.Equity != 1050 || s.HighWater != 1200 {
+		t.Errorf("Restored live mark: %+v", s)
+	}
+
+	// the simulator cannot restore realized PnL, so it starts afresh
+	sim := NewSimulatedExecutor()
+	p = NewPortfolio("sim", sim, sim, nil)
+	p.Restore(saved)
+	if s := p.Mark(MarketData{Bid: 100, Ask: 100}, cfg); s.Realized != 0 || s.HighWater != 1000 {
+		t.Errorf("Restored sim mark: %+v", s)
+	}
+}
@@ -0,0 +1,77 @@
+package bot
+
+import (
+	"time"
+
+	luno "github.com/luno/luno-go"
+)
+
+// MarketEvent is one update of a market for strategies that need more than
+// the touch: the bar it closes, with open, high, low, close and traded
+// volume, the quote and last trade, and order-book depth when requested
+// (Config.StrategyDepth). A bare tick is a bar with a zero Interval whose
+// prices all equal the tick price; its volume is what traded since the pair's
+// previous tick when the engine's market data is a VolumeSource, else zero.
+type MarketEvent struct {
+	MarketData
+	Bar
+	Interval time.Duration         // bar length; zero for a tick
+	Bids     []luno.OrderBookEntry // best first; nil unless depth was fetched
+	Asks     []luno.OrderBookEntry
+}
+
+// TickEvent wraps md as a tick event priced at its last trade, or at the mid
+// when no trade is known.
+func TickEvent(md MarketData) MarketEvent {
+	price := md.Last
+	if price <= 0 {
+		price = (md.Bid + md.Ask) / 2
+	}
+	return MarketEvent{
+		MarketData: md,
+		Bar:        Bar{Time: md.Timestamp, Open: price, High: price, Low: price, Close: price},
+	}
+}
+
+// BarEvent is a closed bar of interval quoted bid/ask around its close; a
+// zero bid or ask is taken as the close. The event is timestamped at the
+// bar's end.
+func BarEvent(b Bar, interval time.Duration, bid, ask float64) MarketEvent {
+	if bid <= 0 {
+		bid = b.Close
+	}
+	if ask <= 0 {
+		ask = b.Close
+	}
+	return MarketEvent{
+		MarketData: MarketData{Bid: bid, Ask: ask, Last: b.Close, Timestamp: b.Time.Add(interval)},
+		Bar:        b,
+		Interval:   interval,
+	}
+}
+
+// EventStrategy is a Strategy that reads whole market events, e.g. to use
+// ranges or volume. Its Next should treat md as TickEvent(md).
+type EventStrategy interface {
+	Strategy
+	OnEvent(ev MarketEvent, cfg Config) Signal
+}
+
+// AdaptStrategy returns s as an EventStrategy. Strategies that only read
+// MarketData are wrapped to receive each event's quote and last trade.
+func AdaptStrategy(s Strategy) EventStrategy {
+	if es, ok := s.(EventStrategy); ok {
+		return es
+	}
+	return tickStrategy{s}
+}
+
+// tickStrategy adapts a MarketData strategy to events.
+type tickStrategy struct {
+	Strategy
+}
+
+// OnEvent passes the event's MarketData to the strategy.
+func (t tickStrategy) OnEvent(ev MarketEvent, cfg Config) Signal {
+	return t.Next(ev.MarketData, cfg)
+}
@@ -0,0 +1,340 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	luno "github.com/luno/luno-go"
+	"github.com/luno/luno-go/streaming"
+)
+
+// RESTMarketData reads market data from the REST order book on every call.
+type RESTMarketData struct {
+	Client Client
+}
+
+// NewRESTMarketData constructs a polling MarketDataSource.
+func NewRESTMarketData(client Client) *RESTMarketData {
+	return &RESTMarketData{Client: client}
+}
+
+// MarketData returns best bid and ask from GetOrderBook, timestamped when
+// they were fetched.
+func (r *RESTMarketData) MarketData(ctx context.Context, pair string) (MarketData, error) {
+	bids, asks, err := r.OrderBook(ctx, pair)
+	if err != nil {
+		return MarketData{}, err
+	}
+	return topOfBook(pair, bids, asks, time.Now())
+}
+
+// OrderBook returns the top of the order book from GetOrderBook.
+func (r *RESTMarketData) OrderBook(ctx context.Context, pair string) ([]luno.OrderBookEntry, []luno.OrderBookEntry, error) {
+	ob, err := r.Client.GetOrderBook(ctx, &luno.GetOrderBookRequest{Pair: pair})
+	if err != nil {
+		return nil, nil, fmt.Errorf("get order book %s: %w", pair, err)
+	}
+	return ob.Bids, ob.Asks, nil
+}
+
+// bookStream is the part of streaming.Conn used by StreamingMarketData.
+type bookStream interface {
+	Snapshot() streaming.Snapshot
+	Close()
+}
+
+// pairStream is the streaming state for one pair.
+type pairStream struct {
+	conn        bookStream
+	lastUpdate  time.Time
+	lastPublish time.Time
+	last        MarketData
+}
+
+// pairVolume is the base volume traded on a pair since it was last read.
+type pairVolume struct {
+	streamed float64   // summed from streamed trades
+	since    time.Time // when the volume was last read; zero before the first read
+}
+
+// StreamingMarketData keeps one luno-go streaming connection per active pair and
+// publishes MarketData on every top-of-book or trade change. Pairs whose stream
+// is missing or stale are served from REST instead. It also sums the streamed
+// trades, so ticks can carry the volume traded since the previous one.
+type StreamingMarketData struct {
+	rest    *RESTMarketData
+	dial    func(pair string, opts ...streaming.DialOption) (bookStream, error)
+	updates chan MarketUpdate
+
+	// StaleAfter is how long a stream may go without updates before REST is used.
+	StaleAfter time.Duration
+	// PollInterval is how often Run polls REST for stale pairs.
+	PollInterval time.Duration
+	// MinPublishInterval throttles published updates per pair.
+	MinPublishInterval time.Duration
+
+	mu      sync.Mutex
+	streams map[string]*pairStream
+	volumes map[string]*pairVolume
+}
+
+// NewStreamingMarketData constructs a provider that streams with the given API
+// credentials and falls back to REST calls on client.
+func NewStreamingMarketData(client Client, keyID, keySecret string) *StreamingMarketData {
+	return &StreamingMarketData{
+		rest: NewRESTMarketData(client),
+		dial: func(pair string, opts ...streaming.DialOption) (bookStream, error) {
+			return streaming.Dial(keyID, keySecret, pair, opts...)
+		},
+		updates:            make(chan MarketUpdate, 64),
+		StaleAfter:         time.Minute,
+		PollInterval:       10 * time.Second,
+		MinPublishInterval: time.Second,
+		streams:            make(map[string]*pairStream),
+		volumes:            make(map[string]*pairVolume),
+	}
+}
+
+// Updates returns the channel on which fresh market data is published.
+// Updates are dropped when the channel is full.
+func (s *StreamingMarketData) Updates() <-chan MarketUpdate {
+	return s.updates
+}
+
+// Subscribe opens a streaming connection for pair if one is not already open.
+func (s *StreamingMarketData) Subscribe(pair string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.streams[pair]; ok {
+		return nil
+	}
+	ps := &pairStream{}
+	conn, err := s.dial(pair,
+		streaming.WithConnectCallback(func(*streaming.Conn) { s.onUpdate(pair, ps) }),
+		streaming.WithUpdateCallback(func(u streaming.Update) {
+			s.onTrades(pair, u.TradeUpdates)
+			s.onUpdate(pair, ps)
+		}),
+	)
+	if err != nil {
+		return fmt.Errorf("dial stream %s: %w", pair, err)
+	}
+	ps.conn = conn
+	s.streams[pair] = ps
+	return nil
+}
+
+// Unsubscribe closes the stream for pair.
+func (s *StreamingMarketData) Unsubscribe(pair string) {
+	s.mu.Lock()
+	ps, ok := s.streams[pair]
+	delete(s.streams, pair)
+	s.mu.Unlock()
+	if ok && ps.conn != nil {
+		ps.conn.Close()
+	}
+}
+
+// Close closes every open stream.
+func (s *StreamingMarketData) Close() {
+	for _, pair := range s.Pairs() {
+		s.Unsubscribe(pair)
+	}
+}
+
+// Pairs returns the pairs with an open stream.
+func (s *StreamingMarketData) Pairs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pairs := make([]string, 0, len(s.streams))
+	for p := range s.streams {
+		pairs = append(pairs, p)
+	}
+	return pairs
+}
+
+// MarketData returns the streamed top of book for pair, subscribing on first
+// use. Stale or not-yet-connected streams fall back to REST.
+func (s *StreamingMarketData) MarketData(ctx context.Context, pair string) (MarketData, error) {
+	if md, ok := s.fresh(pair); ok {
+		return md, nil
+	}
+	// a failed subscribe (e.g. no credentials) leaves REST as the only source
+	_ = s.Subscribe(pair)
+	return s.rest.MarketData(ctx, pair)
+}
+
+// OrderBook returns the streamed order book for pair, or the REST book when stale.
+func (s *StreamingMarketData) OrderBook(ctx context.Context, pair string) ([]luno.OrderBookEntry, []luno.OrderBookEntry, error) {
+	if conn, _, ok := s.freshConn(pair); ok {
+		snap := conn.Snapshot()
+		if len(snap.Bids) > 0 && len(snap.Asks) > 0 {
+			return snap.Bids, snap.Asks, nil
+		}
+	}
+	_ = s.Subscribe(pair)
+	return s.rest.OrderBook(ctx, pair)
+}
+
+// Run polls REST for stale pairs every PollInterval and publishes the result,
+// so subscribers keep receiving data while a stream reconnects.
+func (s *StreamingMarketData) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for _, pair := range s.Pairs() {
+			if _, ok := s.fresh(pair); ok {
+				continue
+			}
+			md, err := s.rest.MarketData(ctx, pair)
+			if err != nil {
+				continue
+			}
+			s.publish(MarketUpdate{Pair: pair, Data: md})
+		}
+	}
+}
+
+// TradedVolume returns the base volume traded on pair since the previous call,
+// summed from streamed trades, or read from REST trades while the stream is
+// stale. The first call for a pair starts the count and returns zero.
+func (s *StreamingMarketData) TradedVolume(ctx context.Context, pair string) (float64, error) {
+	_, _, live := s.freshConn(pair)
+	now := time.Now()
+	s.mu.Lock()
+	v := s.volume(pair)
+	streamed, since := v.streamed, v.since
+	v.streamed, v.since = 0, now
+	s.mu.Unlock()
+	if since.IsZero() {
+		return 0, nil
+	}
+	if live {
+		return streamed, nil
+	}
+	// the stream missed trades while it was down, so count them all from REST
+	traded, _, err := tradedSince(ctx, s.rest.Client, pair, since)
+	if err != nil {
+		return 0, err
+	}
+	return traded, nil
+}
+
+// volume returns the traded volume for pair, creating it on first use. The
+// caller must hold s.mu.
+func (s *StreamingMarketData) volume(pair string) *pairVolume {
+	v, ok := s.volumes[pair]
+	if !ok {
+		v = &pairVolume{}
+		s.volumes[pair] = v
+	}
+	return v
+}
+
+// onTrades adds streamed trades to pair's traded volume.
+func (s *StreamingMarketData) onTrades(pair string, trades []*streaming.TradeUpdate) {
+	var base float64
+	for _, t := range trades {
+		if t != nil {
+			base += t.Base.Float64()
+		}
+	}
+	if base <= 0 {
+		return
+	}
+	s.mu.Lock()
+	s.volume(pair).streamed += base
+	s.mu.Unlock()
+}
+
+// onUpdate records stream activity and publishes changed top-of-book data.
+func (s *StreamingMarketData) onUpdate(pair string, ps *pairStream) {
+	s.mu.Lock()
+	conn := ps.conn
+	s.mu.Unlock()
+	if conn == nil {
+		return
+	}
+	now := time.Now()
+	md, err := snapshotMarketData(pair, conn.Snapshot(), now)
+
+	s.mu.Lock()
+	ps.lastUpdate = now
+	changed := err == nil && (md.Bid != ps.last.Bid || md.Ask != ps.last.Ask || md.Last != ps.last.Last)
+	due := now.Sub(ps.lastPublish) >= s.MinPublishInterval
+	if changed {
+		ps.last = md
+	}
+	publish := changed && due
+	if publish {
+		ps.lastPublish = now
+	}
+	s.mu.Unlock()
+
+	if publish {
+		s.publish(MarketUpdate{Pair: pair, Data: md})
+	}
+}
+
+// publish sends u without blocking the stream.
+func (s *StreamingMarketData) publish(u MarketUpdate) {
+	select {
+	case s.updates <- u:
+	default:
+	}
+}
+
+// fresh returns current streamed data for pair if the stream is live,
+// timestamped at the stream's last update.
+func (s *StreamingMarketData) fresh(pair string) (MarketData, bool) {
+	conn, updated, ok := s.freshConn(pair)
+	if !ok {
+		return MarketData{}, false
+	}
+	md, err := snapshotMarketData(pair, conn.Snapshot(), updated)
+	return md, err == nil
+}
+
+// freshConn returns the stream for pair and when it last updated, if that was
+// within StaleAfter.
+func (s *StreamingMarketData) freshConn(pair string) (bookStream, time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ps, ok := s.streams[pair]
+	if !ok || ps.conn == nil || ps.lastUpdate.IsZero() || time.Since(ps.lastUpdate) > s.StaleAfter {
+		return nil, time.Time{}, false
+	}
+	return ps.conn, ps.lastUpdate, true
+}
+
+// snapshotMarketData converts a stream snapshot taken at ts to MarketData;
+// the book is empty while the connection is resetting.
+func snapshotMarketData(pair string, snap streaming.Snapshot, ts time.Time) (MarketData, error) {
+	md, err := topOfBook(pair, snap.Bids, snap.Asks, ts)
+	if err != nil {
+		return md, err
+	}
+	if base := snap.LastTrade.Base.Float64(); base > 0 {
+		md.Last = snap.LastTrade.Counter.Float64() / base
+	}
+	return md, nil
+}
+
+// topOfBook builds MarketData from the best bid and ask.
+func topOfBook(pair string, bids, asks []luno.OrderBookEntry, ts time.Time) (MarketData, error) {
+	if len(bids) == 0 || len(asks) == 0 {
+		return MarketData{}, fmt.Errorf("empty order book for %s", pair)
+	}
+	return MarketData{
+		Bid:       bids[0].Price.Float64(),
+		Ask:       asks[0].Price.Float64(),
+		Timestamp: ts,
+	}, nil
+}
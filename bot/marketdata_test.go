@@ -0,0 +1,183 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	luno "github.com/luno/luno-go"
+	dec "github.com/luno/luno-go/decimal"
+	"github.com/luno/luno-go/streaming"
+)
+
+// fakeStream is a bookStream serving a settable snapshot.
+type fakeStream struct {
+	mu     sync.Mutex
+	snap   streaming.Snapshot
+	closed bool
+}
+
+func (f *fakeStream) Snapshot() streaming.Snapshot {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.snap
+}
+
+func (f *fakeStream) Close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+}
+
+// book returns a one-level snapshot quoting bid and ask.
+func book(bid, ask float64) streaming.Snapshot {
+	return streaming.Snapshot{
+		Bids: []luno.OrderBookEntry{{Price: dec.NewFromFloat64(bid, 2), Volume: dec.NewFromInt64(1)}},
+		Asks: []luno.OrderBookEntry{{Price: dec.NewFromFloat64(ask, 2), Volume: dec.NewFromInt64(1)}},
+	}
+}
+
+func TestStreamingMarketDataTradedVolume(t *testing.T) {
+	ctx := context.Background()
+	trades := &tradesClient{}
+	s := NewStreamingMarketData(trades, "", "")
+	s.dial = func(string, ...streaming.DialOption) (bookStream, error) {
+		return &fakeStream{snap: book(100, 101)}, nil
+	}
+	if err := s.Subscribe("XBTZAR"); err != nil {
+		t.Fatal(err)
+	}
+	ps := s.streams["XBTZAR"]
+	s.onUpdate("XBTZAR", ps)
+
+	// the first read starts the count
+	if v, err := s.TradedVolume(ctx, "XBTZAR"); err != nil || v != 0 {
+		t.Fatalf("First volume %v, %v", v, err)
+	}
+	s.onTrades("XBTZAR", []*streaming.TradeUpdate{{Base: dec.NewFromFloat64(0.5, 8)}, {Base: dec.NewFromFloat64(0.25, 8)}})
+	if v, err := s.TradedVolume(ctx, "XBTZAR"); err != nil || v != 0.75 {
+		t.Errorf("Streamed volume %v, %v, want 0.75", v, err)
+	}
+
+	// a stale stream missed trades, so they are counted from REST instead
+	s.mu.Lock()
+	ps.lastUpdate = time.Now().Add(-2 * s.StaleAfter)
+	s.mu.Unlock()
+	trades.add(time.Now().Add(time.Hour), 0.1, 3)
+	s.onTrades("XBTZAR", []*streaming.TradeUpdate{{Base: dec.NewFromInt64(5)}})
+	if v, err := s.TradedVolume(ctx, "XBTZAR"); err != nil || math.Abs(v-0.3) > 1e-9 {
+		t.Errorf("Stale volume %v, %v, want 0.3", v, err)
+	}
+}
+
+// restBookClient serves a fixed REST order book and counts the calls.
+type restBookClient struct {
+	Client
+	mu    sync.Mutex
+	calls int
+}
+
+func (c *restBookClient) GetOrderBook(ctx context.Context, req *luno.GetOrderBookRequest) (*luno.GetOrderBookResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls++
+	snap := book(90, 91)
+	return &luno.GetOrderBookResponse{Bids: snap.Bids, Asks: snap.Asks}, nil
+}
+
+func (c *restBookClient) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls
+}
+
+func TestStreamingMarketDataFallsBackToREST(t *testing.T) {
+	ctx := context.Background()
+	rest := &restBookClient{}
+	s := NewStreamingMarketData(rest, "", "")
+	s.MinPublishInterval = 0
+	var dials int
+	var conn *fakeStream
+	s.dial = func(string, ...streaming.DialOption) (bookStream, error) {
+		dials++
+		if dials == 1 {
+			return nil, errors.New("no credentials")
+		}
+		conn = &fakeStream{snap: book(100, 101)}
+		return conn, nil
+	}
+
+	// a failed dial leaves REST serving and is retried on the next read
+	if md, err := s.MarketData(ctx, "XBTZAR"); err != nil || md.Bid != 90 {
+		t.Fatalf("Undialled data %+v, %v", md, err)
+	}
+	if _, err := s.MarketData(ctx, "XBTZAR"); err != nil || dials != 2 || len(s.Pairs()) != 1 {
+		t.Fatalf("Redial: %d dials, pairs %v, %v", dials, s.Pairs(), err)
+	}
+
+	// the stream serves once it has updated, publishing the change
+	s.onUpdate("XBTZAR", s.streams["XBTZAR"])
+	calls := rest.count()
+	if md, err := s.MarketData(ctx, "XBTZAR"); err != nil || md.Bid != 100 || rest.count() != calls {
+		t.Errorf("Streamed data %+v, %v, %d REST calls", md, err, rest.count()-calls)
+	}
+	select {
+	case u := <-s.Updates():
+		if u.Pair != "XBTZAR" || u.Data.Ask != 101 {
+			t.Errorf("Published %+v", u)
+		}
+	default:
+		t.Error("Expected a published update")
+	}
+	if err := s.Subscribe("XBTZAR"); err != nil || dials != 2 {
+		t.Errorf("Subscribing again dialled %d times, %v", dials, err)
+	}
+
+	// a stale stream falls back to REST, and Run polls it for subscribers
+	s.mu.Lock()
+	s.streams["XBTZAR"].lastUpdate = time.Now().Add(-2 * s.StaleAfter)
+	s.mu.Unlock()
+	if md, err := s.MarketData(ctx, "XBTZAR"); err != nil || md.Bid != 90 {
+		t.Errorf("Stale data %+v, %v", md, err)
+	}
+	s.PollInterval = time.Millisecond
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		s.Run(runCtx)
+		close(done)
+	}()
+	select {
+	case u := <-s.Updates():
+		if u.Data.Bid != 90 {
+			t.Errorf("Polled %+v", u)
+		}
+	case <-time.After(time.Second):
+		t.Error("Run did not poll the stale pair")
+	}
+	cancel()
+	<-done
+
+	// a reconnected stream serves again; a resetting book falls back to REST
+	conn.mu.Lock()
+	conn.snap = book(102, 103)
+	conn.mu.Unlock()
+	s.onUpdate("XBTZAR", s.streams["XBTZAR"])
+	if md, err := s.MarketData(ctx, "XBTZAR"); err != nil || md.Bid != 102 {
+		t.Errorf("Reconnected data %+v, %v", md, err)
+	}
+	conn.mu.Lock()
+	conn.snap = streaming.Snapshot{}
+	conn.mu.Unlock()
+	if bids, _, err := s.OrderBook(ctx, "XBTZAR"); err != nil || bids[0].Price.Float64() != 90 {
+		t.Errorf("Resetting book %v, %v", bids, err)
+	}
+
+	s.Close()
+	if !conn.closed || len(s.Pairs()) != 0 {
+		t.Errorf("Close left the stream open: closed %v, pairs %v", conn.closed, s.Pairs())
+	}
+}
@@ -0,0 +1,152 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sync"
+	"time"
+
+	luno "github.com/luno/luno-go"
+	dec "github.com/luno/luno-go/decimal"
+)
+
+// Market rule failures returned by Markets.Order.
+var (
+	ErrMarketInactive = errors.New("market not active")
+	ErrBelowMinVolume = errors.New("order volume below market minimum")
+)
+
+// defaultScale is the precision used for pairs with no market info.
+const defaultScale = 8
+
+// Markets caches each pair's trading rules from the Markets endpoint. A nil
+// *Markets applies no rules and formats orders with 8 decimal places.
+type Markets struct {
+	client Client
+
+	mu   sync.RWMutex
+	info map[string]luno.MarketInfo
+}
+
+// NewMarkets constructs an empty cache; call Refresh or Run to load it.
+func NewMarkets(client Client) *Markets {
+	return &Markets{client: client, info: make(map[string]luno.MarketInfo)}
+}
+
+// Refresh reloads the rules for every market.
+func (m *Markets) Refresh(ctx context.Context) error {
+	resp, err := m.client.Markets(ctx, &luno.MarketsRequest{})
+	if err != nil {
+		return fmt.Errorf("markets: %w", err)
+	}
+	info := make(map[string]luno.MarketInfo, len(resp.Markets))
+	for _, mi := range resp.Markets {
+		info[mi.MarketId] = mi
+	}
+	m.mu.Lock()
+	m.info = info
+	m.mu.Unlock()
+	return nil
+}
+
+// Run refreshes the rules every interval until ctx is cancelled.
+func (m *Markets) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.Refresh(ctx); err != nil && ctx.Err() == nil {
+				fmt.Printf("Refresh markets: %v\n", err)
+			}
+		}
+	}
+}
+
+// Info returns the rules for pair, if loaded.
+func (m *Markets) Info(pair string) (luno.MarketInfo, bool) {
+	if m == nil {
+		return luno.MarketInfo{}, false
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	mi, ok := m.info[pair]
+	return mi, ok
+}
+
+// Order rounds price to the pair's price scale and volume down to its volume
+// scale, capped at the maximum volume. It returns ErrMarketInactive unless the
+// market is active, and ErrBelowMinVolume for orders under the minimum volume,
+// except buys when bump is set, which are raised to the minimum. Pairs with
+// no market info keep 8 decimal places and no limits.
+func (m *Markets) Order(pair string, typ luno.OrderType, price, volume float64, bump bool) (dec.Decimal, dec.Decimal, error) {
+	mi, ok := m.Info(pair)
+	if !ok {
+		return roundScale(price, defaultScale, false), roundScale(volume, defaultScale, true), nil
+	}
+	if mi.TradingStatus != luno.TradingStatusActive {
+		return dec.Decimal{}, dec.Decimal{}, fmt.Errorf("%w: %s is %s", ErrMarketInactive, pair, mi.TradingStatus)
+	}
+	if max := decFloat(mi.MaxVolume); max > 0 && volume > max {
+		volume = max
+	}
+	p := roundScale(price, mi.PriceScale, false)
+	v := roundScale(volume, mi.VolumeScale, true)
+	if min := decFloat(mi.MinVolume); min > 0 && v.Float64() < min {
+		if !bump || !isBuyOrder(typ) {
+			return dec.Decimal{}, dec.Decimal{}, fmt.Errorf("%w: %s volume %s, minimum %s", ErrBelowMinVolume, pair, v, mi.MinVolume)
+		}
+		v = mi.MinVolume.ToScale(int(mi.VolumeScale))
+	}
+	return p, v, nil
+}
+
+// Dust reports whether a position of volume is too small to sell on pair:
+// rounded down to the volume scale it is under the minimum volume. Pairs with
+// no market info have no dust.
+func (m *Markets) Dust(pair string, volume float64) bool {
+	mi, ok := m.Info(pair)
+	if !ok {
+		return false
+	}
+	min := decFloat(mi.MinVolume)
+	return min > 0 && roundScale(volume, mi.VolumeScale, true).Float64() < min
+}
+
+// exitVolume returns the volume a sell of position places: the slice's share
+// of it, or all of it for a whole order or a share too small to sell. It is
+// zero when the position is dust, which is left in place and treated as flat
+// rather than failing every sell with ErrBelowMinVolume.
+func exitVolume(m *Markets, pair string, position, share float64) float64 {
+	if position <= 0 || m.Dust(pair, position) {
+		return 0
+	}
+	if share > 0 && share < 1 && !m.Dust(pair, position*share) {
+		return position * share
+	}
+	return position
+}
+
+// roundScale converts v to a decimal with scale places, rounding to nearest or down.
+func roundScale(v float64, scale int64, down bool) dec.Decimal {
+	x := v * math.Pow10(int(scale))
+	if down {
+		x = math.Floor(x + 1e-6) // absorb float error such as 0.29*1e8 = 28999999.99
+	} else {
+		x = math.Round(x)
+	}
+	return dec.New(big.NewInt(int64(x)), int(scale))
+}
+
+// decFloat converts d to a float, treating an unset decimal as zero.
+func decFloat(d dec.Decimal) float64 {
+	if d.Sign() == 0 {
+		return 0
+	}
+	return d.Float64()
+}
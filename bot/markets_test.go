@@ -0,0 +1,73 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	luno "github.com/luno/luno-go"
+	dec "github.com/luno/luno-go/decimal"
+)
+
+// marketsClient serves fixed market info.
+type marketsClient struct {
+	Client
+	markets []luno.MarketInfo
+}
+
+func (c marketsClient) Markets(ctx context.Context, req *luno.MarketsRequest) (*luno.MarketsResponse, error) {
+	return &luno.MarketsResponse{Markets: c.markets}, nil
+}
+
+func TestMarketsOrder(t *testing.T) {
+	minVol, _ := dec.NewFromString("0.0005")
+	m := NewMarkets(marketsClient{markets: []luno.MarketInfo{
+		{MarketId: "XBTZAR", PriceScale: 0, VolumeScale: 6, MinVolume: minVol, TradingStatus: luno.TradingStatusActive},
+		{MarketId: "ETHZAR", PriceScale: 0, VolumeScale: 4, TradingStatus: luno.TradingStatusSuspended},
+	}})
+	if err := m.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	p, v, err := m.Order("XBTZAR", luno.OrderTypeBid, 1234567.89, 0.29, false)
+	if err != nil || p.String() != "1234568" || v.String() != "0.290000" {
+		t.Errorf("Got price %s volume %s err %v", p, v, err)
+	}
+	if _, _, err := m.Order("XBTZAR", luno.OrderTypeBid, 100, 0.0001234, false); !errors.Is(err, ErrBelowMinVolume) {
+		t.Errorf("Expected ErrBelowMinVolume, got %v", err)
+	}
+	if _, v, err := m.Order("XBTZAR", luno.OrderTypeBid, 100, 0.0001234, true); err != nil || v.String() != "0.000500" {
+		t.Errorf("Expected bump to 0.000500, got %s %v", v, err)
+	}
+	if _, _, err := m.Order("XBTZAR", luno.OrderTypeAsk, 100, 0.0001234, true); !errors.Is(err, ErrBelowMinVolume) {
+		t.Errorf("Sells must not be bumped, got %v", err)
+	}
+	if _, _, err := m.Order("ETHZAR", luno.OrderTypeBid, 100, 1, false); !errors.Is(err, ErrMarketInactive) {
+		t.Errorf("Expected ErrMarketInactive, got %v", err)
+	}
+	if p, v, err := m.Order("LTCZAR", luno.OrderTypeBid, 1.5, 2, false); err != nil || p.String() != "1.50000000" || v.String() != "2.00000000" {
+		t.Errorf("Unknown pair got %s %s %v", p, v, err)
+	}
+
+	sim := NewSimulatedExecutor()
+	sim.Markets = m
+	cfg := Config{Pair: "ETHZAR", StakeSize: 1, PositionLimit: 1}
+	if err := sim.Execute(context.Background(), SignalBuy, MarketData{Bid: 99, Ask: 101}, cfg); err != nil || sim.Position != 0 {
+		t.Errorf("Expected inactive market to be skipped, got position %v err %v", sim.Position, err)
+	}
+
+	// a position under the minimum volume is dust: sells skip it and buys
+	// treat it as flat
+	sim.Position = 0.0003
+	cfg = Config{Pair: "XBTZAR", StakeSize: 0.5, PositionLimit: 1}
+	if err := sim.Execute(context.Background(), SignalSell, MarketData{Bid: 99, Ask: 101}, cfg); err != nil || sim.Position != 0.0003 {
+		t.Errorf("Dust sell: position %v, err %v", sim.Position, err)
+	}
+	if err := sim.Execute(context.Background(), SignalBuy, MarketData{Bid: 99, Ask: 101}, cfg); err != nil || sim.Position != 0.5003 {
+		t.Errorf("Dust buy: position %v, err %v", sim.Position, err)
+	}
+	// a slice too small to sell takes the whole position
+	if v := exitVolume(m, "XBTZAR", 0.001, 0.25); v != 0.001 {
+		t.Errorf("Small slice sells %v, want the whole 0.001", v)
+	}
+}
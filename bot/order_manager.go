@@ -0,0 +1,283 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	luno "github.com/luno/luno-go"
+)
+
+// OrderState is the lifecycle state of a tracked order.
+type OrderState string
+
+const (
+	OrderOpen    OrderState = "open"             // resting, nothing filled yet
+	OrderPartial OrderState = "partially_filled" // resting with some volume filled
+	OrderFilled  OrderState = "filled"           // complete, full volume filled
+	OrderExpired OrderState = "expired"          // complete without a full fill (stopped or expired)
+)
+
+// fillEpsilon absorbs decimal rounding when comparing filled and limit volume.
+const fillEpsilon = 1e-12
+
+// TrackedOrder is the local view of an order posted to the exchange.
+type TrackedOrder struct {
+	ID            string         `json:"id"`
+	Pair          string         `json:"pair"`
+	Type          luno.OrderType `json:"type"`
+	LimitPrice    float64        `json:"limit_price"`
+	LimitVolume   float64        `json:"limit_volume"`
+	FilledBase    float64        `json:"filled_base"`
+	FilledCounter float64        `json:"filled_counter"`
+	FeeBase       float64        `json:"fee_base"`
+	FeeCounter    float64        `json:"fee_counter"`
+	State         OrderState     `json:"state"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+}
+
+// Done reports whether the order can no longer fill.
+func (o TrackedOrder) Done() bool {
+	return o.State == OrderFilled || o.State == OrderExpired
+}
+
+// Fill is the amount newly filled on an order and the fees charged on it.
+type Fill struct {
+	Base       float64
+	Counter    float64
+	FeeBase    float64
+	FeeCounter float64
+}
+
+// FillHandler is called with each new fill on an order.
+type FillHandler func(o TrackedOrder, f Fill)
+
+// OrderManager tracks posted orders and polls the exchange for their fills.
+type OrderManager struct {
+	client Client
+	onFill FillHandler
+
+	mu     sync.Mutex
+	orders map[string]*TrackedOrder
+}
+
+// NewOrderManager constructs an OrderManager that reports new fills to onFill.
+func NewOrderManager(client Client, onFill FillHandler) *OrderManager {
+	return &OrderManager{client: client, onFill: onFill, orders: make(map[string]*TrackedOrder)}
+}
+
+// Track starts tracking a newly posted order.
+func (m *OrderManager) Track(id, pair string, typ luno.OrderType, price, volume float64) {
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.orders[id] = &TrackedOrder{
+		ID:          id,
+		Pair:        pair,
+		Type:        typ,
+		LimitPrice:  price,
+		LimitVolume: volume,
+		State:       OrderOpen,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+// Adopt tracks an order listed on the exchange that this process did not post,
+// e.g. one left resting across a restart. Existing fills are not reported.
+func (m *OrderManager) Adopt(lo luno.Order) {
+	now := time.Now()
+	o := &TrackedOrder{
+		ID:            lo.OrderId,
+		Pair:          lo.Pair,
+		Type:          lo.Type,
+		LimitPrice:    lo.LimitPrice.Float64(),
+		LimitVolume:   lo.LimitVolume.Float64(),
+		FilledBase:    lo.Base.Float64(),
+		FilledCounter: lo.Counter.Float64(),
+		FeeBase:       lo.FeeBase.Float64(),
+		FeeCounter:    lo.FeeCounter.Float64(),
+		State:         OrderOpen,
+		CreatedAt:     time.Time(lo.CreationTimestamp),
+		UpdatedAt:     now,
+	}
+	if o.FilledBase > 0 {
+		o.State = OrderPartial
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.orders[o.ID] = o
+}
+
+// Get returns a tracked order by ID.
+func (m *OrderManager) Get(id string) (TrackedOrder, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	o, ok := m.orders[id]
+	if !ok {
+		return TrackedOrder{}, false
+	}
+	return *o, true
+}
+
+// Open returns orders for pair that can still fill; an empty pair matches all.
+func (m *OrderManager) Open(pair string) []TrackedOrder {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var open []TrackedOrder
+	for _, o := range m.orders {
+		if !o.Done() && (pair == "" || o.Pair == pair) {
+			open = append(open, *o)
+		}
+	}
+	return open
+}
+
+// Orders returns all tracked orders.
+func (m *OrderManager) Orders() []TrackedOrder {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	all := make([]TrackedOrder, 0, len(m.orders))
+	for _, o := range m.orders {
+		all = append(all, *o)
+	}
+	return all
+}
+
+// Refresh fetches one order via GetOrderV2 and applies any new fill.
+func (m *OrderManager) Refresh(ctx context.Context, id string) (TrackedOrder, error) {
+	resp, err := m.client.GetOrderV2(ctx, &luno.GetOrderV2Request{Id: id})
+	if err != nil {
+		return TrackedOrder{}, fmt.Errorf("get order %s: %w", id, err)
+	}
+	complete := resp.Status == luno.StatusComplete || resp.Status == luno.StatusCompleted
+	o, ok := m.update(id, Fill{Base: resp.Base.Float64(), Counter: resp.Counter.Float64(), FeeBase: resp.FeeBase.Float64(), FeeCounter: resp.FeeCounter.Float64()}, complete)
+	if !ok {
+		return TrackedOrder{}, fmt.Errorf("order %s not tracked", id)
+	}
+	return o, nil
+}
+
+// Poll refreshes every open order, using one ListOrders call per pair and
+// falling back to GetOrderV2 for orders the listing does not include.
+func (m *OrderManager) Poll(ctx context.Context) error {
+	byPair := make(map[string][]string)
+	for _, o := range m.Open("") {
+		byPair[o.Pair] = append(byPair[o.Pair], o.ID)
+	}
+	for pair, ids := range byPair {
+		listed := make(map[string]luno.Order)
+		resp, err := m.client.ListOrders(ctx, &luno.ListOrdersRequest{Pair: pair})
+		if err == nil {
+			for _, lo := range resp.Orders {
+				listed[lo.OrderId] = lo
+			}
+		}
+		for _, id := range ids {
+			if lo, ok := listed[id]; ok {
+				m.update(id, orderFill(lo), lo.State == luno.OrderStateComplete)
+				continue
+			}
+			if _, err := m.Refresh(ctx, id); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Wait polls an order every interval until it is done or timeout elapses.
+func (m *OrderManager) Wait(ctx context.Context, id string, interval, timeout time.Duration) (TrackedOrder, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		o, err := m.Refresh(ctx, id)
+		if err != nil || o.Done() || time.Now().After(deadline) {
+			return o, err
+		}
+		select {
+		case <-ctx.Done():
+			return o, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Cancel stops an open order and records its final fill.
+func (m *OrderManager) Cancel(ctx context.Context, id string) (TrackedOrder, error) {
+	if _, err := m.client.StopOrder(ctx, &luno.StopOrderRequest{OrderId: id}); err != nil {
+		return TrackedOrder{}, fmt.Errorf("stop order %s: %w", id, err)
+	}
+	o, err := m.Refresh(ctx, id)
+	if err != nil {
+		return o, err
+	}
+	if !o.Done() {
+		// the exchange accepted the stop; treat the remainder as expired
+		m.mu.Lock()
+		m.orders[id].State = OrderExpired
+		o = *m.orders[id]
+		m.mu.Unlock()
+	}
+	return o, nil
+}
+
+// CancelAll stops every open order, returning the first error encountered.
+func (m *OrderManager) CancelAll(ctx context.Context) error {
+	var firstErr error
+	for _, o := range m.Open("") {
+		if _, err := m.Cancel(ctx, o.ID); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// update records cumulative fill amounts and fees for an order and notifies
+// onFill of the delta.
+func (m *OrderManager) update(id string, total Fill, complete bool) (TrackedOrder, bool) {
+	m.mu.Lock()
+	o, ok := m.orders[id]
+	if !ok {
+		m.mu.Unlock()
+		return TrackedOrder{}, false
+	}
+	delta := Fill{
+		Base:       total.Base - o.FilledBase,
+		Counter:    total.Counter - o.FilledCounter,
+		FeeBase:    total.FeeBase - o.FeeBase,
+		FeeCounter: total.FeeCounter - o.FeeCounter,
+	}
+	base := total.Base
+	o.FilledBase, o.FilledCounter = total.Base, total.Counter
+	o.FeeBase, o.FeeCounter = total.FeeBase, total.FeeCounter
+	o.UpdatedAt = time.Now()
+	switch {
+	case complete && base >= o.LimitVolume-fillEpsilon:
+		o.State = OrderFilled
+	case complete:
+		o.State = OrderExpired
+	case base > 0:
+		o.State = OrderPartial
+	default:
+		o.State = OrderOpen
+	}
+	snapshot := *o
+	m.mu.Unlock()
+
+	if delta.Base > fillEpsilon && m.onFill != nil {
+		m.onFill(snapshot, delta)
+	}
+	return snapshot, true
+}
+
+// orderFill returns the cumulative fill and fees of a listed order.
+func orderFill(lo luno.Order) Fill {
+	return Fill{Base: lo.Base.Float64(), Counter: lo.Counter.Float64(), FeeBase: lo.FeeBase.Float64(), FeeCounter: lo.FeeCounter.Float64()}
+}
+
+// isBuyOrder reports whether an order type adds base currency.
+func isBuyOrder(t luno.OrderType) bool {
+	return t == luno.OrderTypeBid || t == luno.OrderTypeBuy
+}
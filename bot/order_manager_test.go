@@ -0,0 +1,168 @@
+package bot
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+
+	luno "github.com/luno/luno-go"
+	dec "github.com/luno/luno-go/decimal"
+)
+
+// orderClient is an exchange whose orders fill only when the test says so.
+// Orders in unlisted are left out of ListOrders.
+type orderClient struct {
+	Client
+	mu       sync.Mutex
+	orders   []luno.Order
+	unlisted map[string]bool
+	stopped  []string
+	gets     int
+}
+
+func (c *orderClient) PostLimitOrder(ctx context.Context, req *luno.PostLimitOrderRequest) (*luno.PostLimitOrderResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	id := strconv.Itoa(len(c.orders))
+	c.orders = append(c.orders, luno.Order{
+		OrderId: id, Pair: req.Pair, Type: req.Type, State: luno.OrderStatePending,
+		LimitPrice: req.Price, LimitVolume: req.Volume,
+		Base: dec.Zero(), Counter: dec.Zero(), FeeBase: dec.Zero(), FeeCounter: dec.Zero(),
+	})
+	return &luno.PostLimitOrderResponse{OrderId: id}, nil
+}
+
+// fill sets order id's cumulative fill and whether it is complete.
+func (c *orderClient) fill(id string, base, feeBase float64, complete bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i, _ := strconv.Atoi(id)
+	o := &c.orders[i]
+	o.Base = dec.NewFromFloat64(base, 8)
+	o.Counter = o.LimitPrice.Mul(o.Base)
+	o.FeeBase = dec.NewFromFloat64(feeBase, 8)
+	if complete {
+		o.State = luno.OrderStateComplete
+	}
+}
+
+func (c *orderClient) GetOrderV2(ctx context.Context, req *luno.GetOrderV2Request) (*luno.GetOrderV2Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gets++
+	i, _ := strconv.Atoi(req.Id)
+	o := c.orders[i]
+	status := luno.StatusActive
+	if o.State == luno.OrderStateComplete {
+		status = luno.StatusComplete
+	}
+	return &luno.GetOrderV2Response{OrderId: o.OrderId, Status: status, Base: o.Base, Counter: o.Counter, FeeBase: o.FeeBase, FeeCounter: o.FeeCounter}, nil
+}
+
+func (c *orderClient) ListOrders(ctx context.Context, req *luno.ListOrdersRequest) (*luno.ListOrdersResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var listed []luno.Order
+	for _, o := range c.orders {
+		if o.Pair == req.Pair && !c.unlisted[o.OrderId] {
+			listed = append(listed, o)
+		}
+	}
+	return &luno.ListOrdersResponse{Orders: listed}, nil
+}
+
+func (c *orderClient) StopOrder(ctx context.Context, req *luno.StopOrderRequest) (*luno.StopOrderResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stopped = append(c.stopped, req.OrderId)
+	return &luno.StopOrderResponse{Success: true}, nil
+}
+
+func TestOrderManagerTracksFills(t *testing.T) {
+	ctx := context.Background()
+	oc := &orderClient{unlisted: map[string]bool{}}
+	var fills []Fill
+	m := NewOrderManager(oc, func(o TrackedOrder, f Fill) { fills = append(fills, f) })
+
+	// placement through the executor tracks the posted order
+	e := NewLunoExecutor(oc)
+	e.orders = m
+	id, err := e.post(ctx, Config{Pair: "XBTZAR", PositionLimit: 5}, luno.OrderTypeBid, 100, 2, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o, ok := m.Get(id)
+	if !ok || o.State != OrderOpen || o.LimitPrice != 100 || o.LimitVolume != 2 {
+		t.Fatalf("Placed order %+v, tracked %v", o, ok)
+	}
+
+	// each refresh reports only the newly filled volume and fees
+	oc.fill(o.ID, 0.5, 0.001, false)
+	if o, err = m.Refresh(ctx, o.ID); err != nil || o.State != OrderPartial {
+		t.Fatalf("Partial fill: %+v, %v", o, err)
+	}
+	oc.fill(o.ID, 2, 0.004, true)
+	if o, err = m.Refresh(ctx, o.ID); err != nil || o.State != OrderFilled {
+		t.Fatalf("Full fill: %+v, %v", o, err)
+	}
+	if len(fills) != 2 || fills[0].Base != 0.5 || fills[1].Base != 1.5 || fills[1].Counter != 150 || fills[1].FeeBase != 0.003 {
+		t.Errorf("Fills %+v", fills)
+	}
+	if _, err := m.Refresh(ctx, o.ID); err != nil || len(fills) != 2 {
+		t.Errorf("Refreshing a done order reported %d fills, %v", len(fills), err)
+	}
+	if open := m.Open(""); len(open) != 0 {
+		t.Errorf("Open orders %+v", open)
+	}
+	if _, err := m.Refresh(ctx, "missing"); err == nil {
+		t.Error("Expected an error refreshing an untracked order")
+	}
+}
+
+func TestOrderManagerPollAndCancel(t *testing.T) {
+	ctx := context.Background()
+	oc := &orderClient{unlisted: map[string]bool{}}
+	var filled float64
+	m := NewOrderManager(oc, func(o TrackedOrder, f Fill) { filled += f.Base })
+	for i := 0; i < 3; i++ {
+		resp, _ := oc.PostLimitOrder(ctx, &luno.PostLimitOrderRequest{Pair: "XBTZAR", Type: luno.OrderTypeAsk, Price: dec.NewFromInt64(100), Volume: dec.NewFromInt64(1)})
+		m.Track(resp.OrderId, "XBTZAR", luno.OrderTypeAsk, 100, 1)
+	}
+
+	// listed orders update from ListOrders, the rest through GetOrderV2
+	oc.unlisted["2"] = true
+	oc.fill("0", 1, 0, true)
+	oc.fill("2", 0.25, 0, false)
+	if err := m.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if oc.gets != 1 || filled != 1.25 {
+		t.Errorf("Poll: %d GetOrderV2 calls, filled %v", oc.gets, filled)
+	}
+	if o, _ := m.Get("0"); o.State != OrderFilled {
+		t.Errorf("Listed order state %s", o.State)
+	}
+	if o, _ := m.Get("2"); o.State != OrderPartial {
+		t.Errorf("Unlisted order state %s", o.State)
+	}
+
+	// cancelling stops only open orders and expires their remainder
+	if err := m.CancelAll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(oc.stopped) != 2 {
+		t.Errorf("Stopped %v, want orders 1 and 2", oc.stopped)
+	}
+	for _, id := range []string{"1", "2"} {
+		if o, _ := m.Get(id); o.State != OrderExpired {
+			t.Errorf("Cancelled order %s state %s", id, o.State)
+		}
+	}
+
+	// adopted orders do not report what filled before they were adopted
+	m.Adopt(luno.Order{OrderId: "9", Pair: "XBTZAR", Type: luno.OrderTypeBid, LimitVolume: dec.NewFromInt64(1), Base: dec.NewFromFloat64(0.5, 8), Counter: dec.NewFromInt64(50)})
+	if o, _ := m.Get("9"); o.State != OrderPartial || filled != 1.25 {
+		t.Errorf("Adopted %+v, filled %v", o, filled)
+	}
+}
@@ -0,0 +1,147 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrUnknownPair is returned for a pair without its own executor chain.
+var ErrUnknownPair = errors.New("pair not configured")
+
+// seedTimeout bounds fetching history to seed a new strategy.
+const seedTimeout = 30 * time.Second
+
+// PairStrategy keeps a separate strategy instance per pair, so each pair's
+// indicators only see its own prices. Instances are built with New on a
+// pair's first tick and rebuilt when its cfg.Strategy or cfg.StrategyParams
+// change. With History, a new instance that is a Seeder is warmed up on the
+// pair's candles or trades before it sees the tick.
+type PairStrategy struct {
+	New     func(cfg Config) (Strategy, error)
+	History Client // optional
+
+	mu     sync.Mutex
+	byPair map[string]pairStrategy
+}
+
+// pairStrategy is a pair's strategy and the name and params it was built from.
+type pairStrategy struct {
+	name   string
+	params string
+	strat  Strategy
+}
+
+// NewPairStrategy constructs a PairStrategy; a nil newStrat uses NewStrategy.
+func NewPairStrategy(newStrat func(cfg Config) (Strategy, error)) *PairStrategy {
+	if newStrat == nil {
+		newStrat = NewStrategy
+	}
+	return &PairStrategy{New: newStrat, byPair: make(map[string]pairStrategy)}
+}
+
+// Next treats data as a tick event.
+func (p *PairStrategy) Next(data MarketData, cfg Config) Signal {
+	return p.OnEvent(TickEvent(data), cfg)
+}
+
+// OnEvent delegates to cfg.Pair's strategy. A strategy that cannot be built
+// signals nothing.
+func (p *PairStrategy) OnEvent(ev MarketEvent, cfg Config) Signal {
+	p.mu.Lock()
+	ps, ok := p.byPair[cfg.Pair]
+	if !ok || ps.name != cfg.Strategy || ps.params != string(cfg.StrategyParams) {
+		s, err := p.New(cfg)
+		if err != nil {
+			p.mu.Unlock()
+			fmt.Printf("Strategy for %s: %v\n", cfg.Pair, err)
+			return SignalNone
+		}
+		if sd, ok := s.(Seeder); ok && p.History != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), seedTimeout)
+			if err := sd.Seed(ctx, p.History, cfg); err != nil {
+				fmt.Printf("Strategy for %s: %v, starting cold\n", cfg.Pair, err)
+			}
+			cancel()
+		}
+		ps = pairStrategy{name: cfg.Strategy, params: string(cfg.StrategyParams), strat: s}
+		p.byPair[cfg.Pair] = ps
+	}
+	p.mu.Unlock()
+	return AdaptStrategy(ps.strat).OnEvent(ev, cfg)
+}
+
+// Votes returns the child signals behind pair's last signal when its
+// strategy is a Voter.
+func (p *PairStrategy) Votes(pair string) []ChildVote {
+	p.mu.Lock()
+	ps, ok := p.byPair[pair]
+	p.mu.Unlock()
+	if v, isVoter := ps.strat.(Voter); ok && isVoter {
+		return v.Votes()
+	}
+	return nil
+}
+
+// PairExecutor routes each call to the executor chain registered for
+// cfg.Pair, so every pair keeps its own position, orders, exits and jobs.
+type PairExecutor struct {
+	mu     sync.RWMutex
+	byPair map[string]Executor
+}
+
+// NewPairExecutor constructs an empty PairExecutor.
+func NewPairExecutor() *PairExecutor {
+	return &PairExecutor{byPair: make(map[string]Executor)}
+}
+
+// Add registers exec as pair's chain.
+func (p *PairExecutor) Add(pair string, exec Executor) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.byPair[pair] = exec
+}
+
+// Get returns pair's chain.
+func (p *PairExecutor) Get(pair string) (Executor, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	exec, ok := p.byPair[pair]
+	return exec, ok
+}
+
+// Pairs returns the registered pairs in order.
+func (p *PairExecutor) Pairs() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	pairs := make([]string, 0, len(p.byPair))
+	for pair := range p.byPair {
+		pairs = append(pairs, pair)
+	}
+	sort.Strings(pairs)
+	return pairs
+}
+
+// Execute delegates to cfg.Pair's chain.
+func (p *PairExecutor) Execute(ctx context.Context, sig Signal, md MarketData, cfg Config) error {
+	exec, ok := p.Get(cfg.Pair)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownPair, cfg.Pair)
+	}
+	return exec.Execute(ctx, sig, md, cfg)
+}
+
+// CancelAll cancels on every pair, returning the first error.
+func (p *PairExecutor) CancelAll(ctx context.Context) error {
+	var first error
+	for _, pair := range p.Pairs() {
+		exec, _ := p.Get(pair)
+		if err := exec.CancelAll(ctx); err != nil && first == nil {
+			first = fmt.Errorf("cancel %s: %w", pair, err)
+		}
+	}
+	return first
+}
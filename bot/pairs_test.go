@@ -0,0 +1,100 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/luno/luno-bot/config"
+)
+
+// memStore serves a fixed config.
+type memStore struct{ cfg config.Config }
+
+func (s *memStore) LoadConfig() (*config.Config, error) { c := s.cfg; return &c, nil }
+func (s *memStore) SaveConfig(c *config.Config) error   { s.cfg = *c; return nil }
+
+// tickCounter signals buy and counts the ticks it has seen.
+type tickCounter struct{ ticks int }
+
+func (t *tickCounter) Next(data MarketData, cfg Config) Signal {
+	t.ticks++
+	return SignalBuy
+}
+
+// ptr returns a pointer to v, for market overrides.
+func ptr[T any](v T) *T { return &v }
+
+func TestEngineKeepsStatePerPair(t *testing.T) {
+	store := &memStore{cfg: config.Config{
+		Pair: "XBTZAR", StakeSize: 1, Strategy: "threshold",
+		Markets: []config.MarketConfig{
+			{Pair: "XBTZAR"},
+			{Pair: "ETHZAR", StakeSize: ptr(5.0), Strategy: "sma", ShortWindow: ptr(2), LongWindow: ptr(3)},
+		},
+	}}
+	built := make(map[string]*tickCounter)
+	var names []string
+	strat := NewPairStrategy(func(cfg Config) (Strategy, error) {
+		names = append(names, cfg.Pair+":"+cfg.Strategy)
+		built[cfg.Pair] = &tickCounter{}
+		return built[cfg.Pair], nil
+	})
+	execs := NewPairExecutor()
+	xbt, eth := &stakeRecorder{}, &stakeRecorder{}
+	execs.Add("XBTZAR", xbt)
+	execs.Add("ETHZAR", eth)
+
+	e := NewEngine(store, nil, strat, execs, time.Hour)
+	e.SetMarketData(depthMarket{})
+	if err := e.Start(); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return len(e.Status().Markets) == 2 })
+	if err := e.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(names) != 2 || names[0] != "XBTZAR:threshold" || names[1] != "ETHZAR:sma" {
+		t.Errorf("Built strategies %v", names)
+	}
+	if built["XBTZAR"].ticks != 1 || built["ETHZAR"].ticks != 1 {
+		t.Errorf("Each pair's strategy should see one tick, got XBT %d ETH %d", built["XBTZAR"].ticks, built["ETHZAR"].ticks)
+	}
+	if len(xbt.stakes) != 1 || xbt.stakes[0] != 1 || len(eth.stakes) != 1 || eth.stakes[0] != 5 {
+		t.Errorf("Stakes XBT %v, ETH %v", xbt.stakes, eth.stakes)
+	}
+	if st := e.Status().Markets["ETHZAR"]; st.Ticks != 1 || st.LastSignal != "buy" || st.LastError != "" {
+		t.Errorf("ETHZAR status %+v", st)
+	}
+
+	cfg := Config{Pair: "XRPZAR"}
+	if err := execs.Execute(context.Background(), SignalBuy, MarketData{}, cfg); !errors.Is(err, ErrUnknownPair) {
+		t.Errorf("Expected ErrUnknownPair, got %v", err)
+	}
+}
+
+func TestForPairOverridesAndSplitsEquity(t *testing.T) {
+	c := config.Config{
+		Pair: "XBTZAR", InitialEquity: 1000, MaxDailyVolume: 500, StakeSize: 1, ExecutionAlgo: "vwap", TWAPSlices: 4,
+		Markets: []config.MarketConfig{
+			{Pair: "XBTZAR", InitialEquity: ptr(400.0), ExecutionAlgo: "pov", POVRate: ptr(0.1), OrderMode: "maker"},
+			{Pair: "ETHZAR", MaxDailyVolume: ptr(0.0)},
+			{Pair: "XRPZAR"},
+		},
+	}
+	var total float64
+	for _, pair := range c.Pairs() {
+		total += c.ForPair(pair).InitialEquity
+	}
+	if total != 1000 || c.ForPair("XBTZAR").InitialEquity != 400 || c.ForPair("XRPZAR").InitialEquity != 300 {
+		t.Errorf("Equity XBT %v, XRP %v, total %v", c.ForPair("XBTZAR").InitialEquity, c.ForPair("XRPZAR").InitialEquity, total)
+	}
+	if eth := c.ForPair("ETHZAR"); eth.MaxDailyVolume != 0 || eth.StakeSize != 1 {
+		t.Errorf("ETHZAR daily volume %v, stake %v; want 0 override and inherited stake", eth.MaxDailyVolume, eth.StakeSize)
+	}
+	if xbt := c.ForPair("XBTZAR"); xbt.ExecutionAlgo != "pov" || xbt.POVRate != 0.1 || xbt.OrderMode != "maker" || xbt.TWAPSlices != 4 {
+		t.Errorf("XBTZAR execution %q at %v, order mode %q, %d slices", xbt.ExecutionAlgo, xbt.POVRate, xbt.OrderMode, xbt.TWAPSlices)
+	}
+}
@@ -0,0 +1,190 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/luno/luno-bot/storage"
+)
+
+// defaultEquityInterval spaces equity snapshots when Portfolio.Interval is unset.
+const defaultEquityInterval = time.Minute
+
+// PnLSource reports profit realized on closed positions, in quote currency.
+// SimulatedExecutor and LunoExecutor implement it.
+type PnLSource interface {
+	RealizedPnL() float64
+}
+
+// EquityStore persists equity snapshots; storage.SQLiteStore implements it.
+type EquityStore interface {
+	SaveEquity(storage.EquitySnapshot) error
+}
+
+// RoundTripStore persists closed round trips; storage.SQLiteStore implements it.
+type RoundTripStore interface {
+	SaveRoundTrip(storage.RoundTrip) error
+}
+
+// Portfolio wraps an Executor and marks the open position to market on
+// every tick: realized PnL comes from Positions when it is a PnLSource,
+// unrealized PnL is the position valued at the mid against its entry price,
+// and equity is Config.InitialEquity plus both. It keeps the equity
+// high-water mark for percentage drawdown and saves a snapshot to Store at
+// most once per Interval. With Trips, each position is recorded from the
+// mark that first sees it open to the one that sees it flat, with the PnL
+// the executor realized on its fills in between. Wrap the whole chain with
+// it so it sees every tick.
+type Portfolio struct {
+	Name      string // executor chain label saved with snapshots
+	Inner     Executor
+	Positions PositionSource
+	Store     EquityStore    // optional
+	Interval  time.Duration  // between saved snapshots
+	Trips     RoundTripStore // optional
+
+	mu           sync.Mutex
+	last         storage.EquitySnapshot
+	saved        time.Time
+	trip         *storage.RoundTrip // position open since its first mark
+	tripRealized float64            // realized PnL when the trip opened
+}
+
+// NewPortfolio constructs a Portfolio saving a snapshot a minute; store may be nil.
+func NewPortfolio(name string, inner Executor, positions PositionSource, store EquityStore) *Portfolio {
+	return &Portfolio{Name: name, Inner: inner, Positions: positions, Store: store, Interval: defaultEquityInterval}
+}
+
+// Execute delegates and then marks the position to md.
+func (p *Portfolio) Execute(ctx context.Context, sig Signal, md MarketData, cfg Config) error {
+	err := p.Inner.Execute(ctx, sig, md, cfg)
+	p.Mark(md, cfg)
+	return err
+}
+
+// Mark values the position at md's mid and records a snapshot when one is due.
+func (p *Portfolio) Mark(md MarketData, cfg Config) storage.EquitySnapshot {
+	now := md.Timestamp
+	if now.IsZero() {
+		now = time.Now()
+	}
+	price := (md.Bid + md.Ask) / 2
+	if md.Bid <= 0 || md.Ask <= 0 {
+		price = md.Last
+	}
+	position, entry := p.Positions.CurrentPosition()
+	var realized float64
+	if src, ok := p.Positions.(PnLSource); ok {
+		realized = src.RealizedPnL()
+	}
+
+	p.mu.Lock()
+	if price <= 0 {
+		price = p.last.Price // keep the last mark without a usable quote
+	}
+	snap := storage.EquitySnapshot{
+		Time:     now,
+		Executor: p.Name,
+		Pair:     cfg.Pair,
+		Price:    price,
+		Position: position,
+		Realized: realized,
+	}
+	if position != 0 {
+		snap.EntryPrice = entry
+		if price > 0 {
+			snap.Unrealized = position * (price - entry)
+		}
+	}
+	snap.Equity = cfg.InitialEquity + snap.Realized + snap.Unrealized
+	snap.HighWater = p.last.HighWater
+	if p.last.Time.IsZero() || snap.Equity > snap.HighWater {
+		snap.HighWater = snap.Equity
+	}
+	if snap.HighWater > 0 {
+		snap.DrawdownPct = (snap.HighWater - snap.Equity) / snap.HighWater * 100
+	}
+	p.last = snap
+	due := p.Store != nil && (p.saved.IsZero() || now.Sub(p.saved) >= p.Interval)
+	if due {
+		p.saved = now
+	}
+	closed := p.track(snap, entry)
+	p.mu.Unlock()
+
+	if due {
+		if err := p.Store.SaveEquity(snap); err != nil {
+			fmt.Printf("Save equity: %v\n", err)
+		}
+	}
+	if closed != nil && p.Trips != nil {
+		if err := p.Trips.SaveRoundTrip(*closed); err != nil {
+			fmt.Printf("Save round trip: %v\n", err)
+		}
+	}
+	return snap
+}
+
+// track follows the open round trip through snap and returns it once the
+// position is flat again. p.mu must be held.
+func (p *Portfolio) track(snap storage.EquitySnapshot, entry float64) *storage.RoundTrip {
+	switch {
+	case snap.Position > fillEpsilon && p.trip == nil:
+		p.trip = &storage.RoundTrip{Executor: p.Name, Pair: snap.Pair, Opened: snap.Time, EntryPrice: entry, Volume: snap.Position}
+		p.tripRealized = snap.Realized
+	case snap.Position > fillEpsilon:
+		p.trip.EntryPrice = entry
+		if snap.Position > p.trip.Volume {
+			p.trip.Volume = snap.Position
+		}
+	case p.trip != nil:
+		t := *p.trip
+		t.Closed, t.ExitPrice, t.PnL = snap.Time, snap.Price, snap.Realized-p.tripRealized
+		p.trip = nil
+		return &t
+	}
+	return nil
+}
+
+// realizedRestorer resumes realized PnL after a restart; LunoExecutor implements it.
+type realizedRestorer interface {
+	RestoreRealized(realized float64)
+}
+
+// Restore resumes from a snapshot saved before a restart: the high-water
+// mark carries over along with realized PnL. Positions that cannot restore
+// realized PnL, like SimulatedExecutor, start afresh, since the old
+// high-water mark would otherwise read as drawdown.
+func (p *Portfolio) Restore(snap storage.EquitySnapshot) {
+	r, ok := p.Positions.(realizedRestorer)
+	if !ok {
+		return
+	}
+	r.RestoreRealized(snap.Realized)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.last = snap
+}
+
+// Snapshot returns the latest mark.
+func (p *Portfolio) Snapshot() storage.EquitySnapshot {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.last
+}
+
+// Equity returns the latest marked equity, or cfg.InitialEquity before the
+// first mark.
+func (p *Portfolio) Equity(ctx context.Context, md MarketData, cfg Config) (float64, error) {
+	if snap := p.Snapshot(); !snap.Time.IsZero() {
+		return snap.Equity, nil
+	}
+	return cfg.InitialEquity, nil
+}
+
+// CancelAll delegates cancellation.
+func (p *Portfolio) CancelAll(ctx context.Context) error {
+	return p.Inner.CancelAll(ctx)
+}
@@ -0,0 +1,55 @@
+package bot
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/luno/luno-bot/storage"
+)
+
+// equityRecorder keeps saved snapshots in memory.
+type equityRecorder struct {
+	snaps []storage.EquitySnapshot
+}
+
+func (r *equityRecorder) SaveEquity(s storage.EquitySnapshot) error {
+	r.snaps = append(r.snaps, s)
+	return nil
+}
+
+func TestPortfolioMarksToMarket(t *testing.T) {
+	ctx := context.Background()
+	sim := NewSimulatedExecutor()
+	store := &equityRecorder{}
+	p := NewPortfolio("sim", sim, sim, store)
+	p.Interval = time.Minute
+	cfg := Config{Pair: "XBTZAR", StakeSize: 1, PositionLimit: 1, MaxDrawdown: 1e9, InitialEquity: 1000}
+	start := time.Now()
+	tick := func(sig Signal, price float64, after time.Duration) {
+		t.Helper()
+		if err := p.Execute(ctx, sig, MarketData{Bid: price, Ask: price, Timestamp: start.Add(after)}, cfg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tick(SignalBuy, 100, 0)
+	tick(SignalNone, 110, 30*time.Second)
+	if s := p.Snapshot(); s.Unrealized != 10 || s.Equity != 1010 || s.HighWater != 1010 || s.DrawdownPct != 0 {
+		t.Errorf("Marked at 110: %+v", s)
+	}
+	tick(SignalNone, 99, time.Minute)
+	s := p.Snapshot()
+	if s.Equity != 999 || s.HighWater != 1010 || math.Abs(s.DrawdownPct-11.0/1010*100) > 1e-9 {
+		t.Errorf("Marked at 99: %+v", s)
+	}
+	tick(SignalSell, 105, 2*time.Minute)
+	if s := p.Snapshot(); s.Position != 0 || s.Realized != 5 || s.Unrealized != 0 || s.Equity != 1005 {
+		t.Errorf("After sell: %+v", s)
+	}
+	// one snapshot a minute: the first tick, 99 and the sell
+	if len(store.snaps) != 3 {
+		t.Errorf("Expected 3 saved snapshots, got %d", len(store.snaps))
+	}
+}
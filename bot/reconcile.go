@@ -0,0 +1,251 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	luno "github.com/luno/luno-go"
+)
+
+// ErrUnreconciled is returned while exchange state has unacknowledged mismatches.
+var ErrUnreconciled = errors.New("position not reconciled with exchange")
+
+// reconcileTolerance is the base volume difference ignored when comparing positions.
+const reconcileTolerance = 1e-8
+
+// Reconcilable is implemented by executors whose state can be rebuilt from the exchange.
+type Reconcilable interface {
+	CurrentPosition() (position, entryPrice float64)
+	Restore(position, entryPrice float64, open []luno.Order)
+}
+
+// ReconcileReport compares local executor state with balances and open orders on the exchange.
+type ReconcileReport struct {
+	Pair             string       `json:"pair"`
+	BaseAccountId    int64        `json:"base_account_id"`
+	CounterAccountId int64        `json:"counter_account_id"`
+	BaseBalance      float64      `json:"base_balance"`
+	BaseReserved     float64      `json:"base_reserved"`
+	CounterBalance   float64      `json:"counter_balance"`
+	CounterReserved  float64      `json:"counter_reserved"`
+	BaseHoldings     float64      `json:"base_holdings"`
+	LocalPosition    float64      `json:"local_position"`
+	Position         float64      `json:"position"`
+	EntryPrice       float64      `json:"entry_price"`
+	OpenOrders       []luno.Order `json:"open_orders"`
+	Mismatches       []string     `json:"mismatches"`
+	Acknowledged     bool         `json:"acknowledged"`
+	Applied          bool         `json:"applied"`
+	CheckedAt        time.Time    `json:"checked_at"`
+}
+
+// OK reports whether trading may proceed.
+func (r ReconcileReport) OK() bool {
+	return len(r.Mismatches) == 0 || r.Acknowledged
+}
+
+// ReconcilingExecutor wraps an Executor and blocks trading until the wrapped
+// executor's position agrees with the exchange or the mismatch is acknowledged.
+// The position is rebuilt from the base balance less Config.BaseHoldings, the
+// base held outside the bot; a difference from the local position (restored
+// from saved equity marks at startup) is a mismatch, and acknowledging it
+// adopts the exchange's. Open orders are handed to the target to track.
+type ReconcilingExecutor struct {
+	Inner  Executor
+	Client Client
+	Target Reconcilable
+
+	mu     sync.Mutex
+	report *ReconcileReport
+}
+
+// NewReconcilingExecutor constructs a ReconcilingExecutor; target is usually inner itself.
+func NewReconcilingExecutor(inner Executor, client Client, target Reconcilable) *ReconcilingExecutor {
+	return &ReconcilingExecutor{Inner: inner, Client: client, Target: target}
+}
+
+// Reconcile fetches balances and orders for cfg.Pair and compares them with local state.
+// Matching state is applied straight away; mismatches wait for Acknowledge.
+func (r *ReconcilingExecutor) Reconcile(ctx context.Context, cfg Config) (ReconcileReport, error) {
+	rep, err := buildReconcileReport(ctx, r.Client, r.Target, cfg)
+	if err != nil {
+		return ReconcileReport{}, err
+	}
+	if rep.OK() {
+		r.Target.Restore(rep.Position, rep.EntryPrice, rep.OpenOrders)
+		rep.Applied = true
+	}
+	r.mu.Lock()
+	r.report = &rep
+	r.mu.Unlock()
+	return rep, nil
+}
+
+// Acknowledge adopts the exchange state from the last report and unblocks trading.
+func (r *ReconcilingExecutor) Acknowledge() (ReconcileReport, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.report == nil {
+		return ReconcileReport{}, errors.New("no reconciliation report to acknowledge")
+	}
+	if !r.report.Applied {
+		r.Target.Restore(r.report.Position, r.report.EntryPrice, r.report.OpenOrders)
+		r.report.Applied = true
+	}
+	r.report.Acknowledged = true
+	return *r.report, nil
+}
+
+// Report returns the last reconciliation report, if any.
+func (r *ReconcilingExecutor) Report() (ReconcileReport, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.report == nil {
+		return ReconcileReport{}, false
+	}
+	return *r.report, true
+}
+
+// Execute reconciles on first use and refuses to trade while mismatches are unacknowledged.
+func (r *ReconcilingExecutor) Execute(ctx context.Context, sig Signal, md MarketData, cfg Config) error {
+	rep, ok := r.Report()
+	if !ok {
+		var err error
+		if rep, err = r.Reconcile(ctx, cfg); err != nil {
+			return fmt.Errorf("reconcile: %w", err)
+		}
+	}
+	if sig != SignalNone && !rep.OK() {
+		return fmt.Errorf("%w: %s", ErrUnreconciled, strings.Join(rep.Mismatches, "; "))
+	}
+	return r.Inner.Execute(ctx, sig, md, cfg)
+}
+
+// CancelAll delegates cancellation.
+func (r *ReconcilingExecutor) CancelAll(ctx context.Context) error {
+	return r.Inner.CancelAll(ctx)
+}
+
+// buildReconcileReport gathers exchange state for cfg.Pair and lists any mismatches.
+func buildReconcileReport(ctx context.Context, client Client, target Reconcilable, cfg Config) (ReconcileReport, error) {
+	rep := ReconcileReport{
+		Pair:             cfg.Pair,
+		BaseAccountId:    cfg.BaseAccountId,
+		CounterAccountId: cfg.CounterAccountId,
+		CheckedAt:        time.Now(),
+	}
+	var localEntry float64
+	rep.LocalPosition, localEntry = target.CurrentPosition()
+	rep.BaseHoldings = cfg.BaseHoldings
+
+	bal, err := client.GetBalances(ctx, &luno.GetBalancesRequest{})
+	if err != nil {
+		return rep, fmt.Errorf("get balances: %w", err)
+	}
+	baseAsset, counterAsset := splitPair(cfg.Pair)
+	base, ok := findAccount(bal.Balance, cfg.BaseAccountId, baseAsset)
+	if !ok {
+		rep.Mismatches = append(rep.Mismatches, fmt.Sprintf("base account %d (%s) not found", cfg.BaseAccountId, baseAsset))
+	}
+	counter, ok := findAccount(bal.Balance, cfg.CounterAccountId, counterAsset)
+	if !ok {
+		rep.Mismatches = append(rep.Mismatches, fmt.Sprintf("counter account %d (%s) not found", cfg.CounterAccountId, counterAsset))
+	}
+	rep.BaseBalance, rep.BaseReserved = base.Balance.Float64(), base.Reserved.Float64()
+	rep.CounterBalance, rep.CounterReserved = counter.Balance.Float64(), counter.Reserved.Float64()
+	rep.Position = math.Max(rep.BaseBalance-rep.BaseHoldings, 0)
+
+	orders, err := client.ListOrders(ctx, &luno.ListOrdersRequest{Pair: cfg.Pair})
+	if err != nil {
+		return rep, fmt.Errorf("list orders: %w", err)
+	}
+	var completedBuys []luno.Order
+	for _, o := range orders.Orders {
+		switch {
+		case o.State == luno.OrderStatePending:
+			rep.OpenOrders = append(rep.OpenOrders, o)
+		case isBuyOrder(o.Type):
+			completedBuys = append(completedBuys, o)
+		}
+	}
+	// the local entry holds while the positions agree; otherwise the recent
+	// buys are a better estimate when there are any
+	matched := math.Abs(rep.Position-rep.LocalPosition) <= reconcileTolerance
+	if !matched || localEntry == 0 {
+		rep.EntryPrice = estimateEntryPrice(completedBuys, rep.Position)
+	}
+	if rep.EntryPrice == 0 {
+		rep.EntryPrice = localEntry
+	}
+
+	if !matched {
+		rep.Mismatches = append(rep.Mismatches, fmt.Sprintf("exchange position %.8f (balance %.8f less base holdings %.8f) differs from local position %.8f", rep.Position, rep.BaseBalance, rep.BaseHoldings, rep.LocalPosition))
+	}
+	if cfg.PositionLimit > 0 && rep.Position > cfg.PositionLimit {
+		rep.Mismatches = append(rep.Mismatches, fmt.Sprintf("exchange position %.8f exceeds position limit %.8f", rep.Position, cfg.PositionLimit))
+	}
+	if rep.Position > reconcileTolerance && rep.EntryPrice == 0 {
+		rep.Mismatches = append(rep.Mismatches, "entry price unknown: no completed buy orders cover the position")
+	}
+	return rep, nil
+}
+
+// findAccount selects a balance by account ID, or by asset when id is zero.
+func findAccount(balances []luno.AccountBalance, id int64, asset string) (luno.AccountBalance, bool) {
+	for _, b := range balances {
+		if id != 0 && b.AccountId == strconv.FormatInt(id, 10) {
+			return b, true
+		}
+		if id == 0 && b.Asset == asset {
+			return b, true
+		}
+	}
+	return luno.AccountBalance{}, false
+}
+
+// estimateEntryPrice averages the most recent completed buys that make up position.
+func estimateEntryPrice(buys []luno.Order, position float64) float64 {
+	var base, counter float64
+	for _, o := range buys {
+		if base >= position {
+			break
+		}
+		b, c := o.Base.Float64(), o.Counter.Float64()
+		if b <= 0 {
+			continue
+		}
+		if base+b > position {
+			// only part of this order is still held
+			c *= (position - base) / b
+			b = position - base
+		}
+		base += b
+		counter += c
+	}
+	if base <= 0 {
+		return 0
+	}
+	return counter / base
+}
+
+// quoteAssets lists counter currencies longer than three letters, checked before the default split.
+var quoteAssets = []string{"USDC", "USDT"}
+
+// splitPair splits a Luno pair such as "XBTZAR" into base and counter assets.
+func splitPair(pair string) (base, counter string) {
+	for _, q := range quoteAssets {
+		if strings.HasSuffix(pair, q) && len(pair) > len(q) {
+			return pair[:len(pair)-len(q)], q
+		}
+	}
+	if len(pair) <= 3 {
+		return pair, ""
+	}
+	return pair[:len(pair)-3], pair[len(pair)-3:]
+}
@@ -0,0 +1,73 @@
+package bot
+
+import (
+	"context"
+	"testing"
+
+	luno "github.com/luno/luno-go"
+	dec "github.com/luno/luno-go/decimal"
+)
+
+// balanceClient reports fixed XBT and ZAR balances and the given orders.
+type balanceClient struct {
+	Client
+	xbt    float64
+	orders []luno.Order
+}
+
+func (c *balanceClient) GetBalances(ctx context.Context, req *luno.GetBalancesRequest) (*luno.GetBalancesResponse, error) {
+	return &luno.GetBalancesResponse{Balance: []luno.AccountBalance{
+		{AccountId: "1", Asset: "XBT", Balance: dec.NewFromFloat64(c.xbt, 8), Reserved: dec.Zero()},
+		{AccountId: "2", Asset: "ZAR", Balance: dec.NewFromInt64(1000), Reserved: dec.Zero()},
+	}}, nil
+}
+
+func (c *balanceClient) ListOrders(ctx context.Context, req *luno.ListOrdersRequest) (*luno.ListOrdersResponse, error) {
+	return &luno.ListOrdersResponse{Orders: c.orders}, nil
+}
+
+func TestReconcileRebuildsPositionFromBalances(t *testing.T) {
+	ctx := context.Background()
+	cfg := Config{Pair: "XBTZAR", PositionLimit: 2, BaseHoldings: 5}
+
+	// declared holdings account for the balance above the bot's own position,
+	// and a resting order is tracked rather than blocking trading
+	sim := NewSimulatedExecutor()
+	sim.Restore(1, 100, nil)
+	resting := luno.Order{OrderId: "7", Pair: "XBTZAR", Type: luno.OrderTypeAsk, State: luno.OrderStatePending}
+	r := NewReconcilingExecutor(sim, &balanceClient{xbt: 6, orders: []luno.Order{resting}}, sim)
+	rep, err := r.Reconcile(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rep.OK() || !rep.Applied || rep.Position != 1 || rep.EntryPrice != 100 || len(rep.OpenOrders) != 1 {
+		t.Errorf("With holdings: position %v at %v, %d open orders, mismatches %v", rep.Position, rep.EntryPrice, len(rep.OpenOrders), rep.Mismatches)
+	}
+
+	// a fill missed since the last mark shows up in the balance; acknowledging
+	// adopts the exchange's position
+	bought := luno.Order{Pair: "XBTZAR", Type: luno.OrderTypeBid, State: luno.OrderStateComplete, Base: dec.NewFromFloat64(1.5, 8), Counter: dec.NewFromInt64(165)}
+	r = NewReconcilingExecutor(sim, &balanceClient{xbt: 6.5, orders: []luno.Order{bought}}, sim)
+	if rep, err = r.Reconcile(ctx, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if rep.OK() || rep.Position != 1.5 || rep.LocalPosition != 1 || rep.EntryPrice != 110 {
+		t.Errorf("Missed fill: position %v (local %v) at %v, mismatches %v", rep.Position, rep.LocalPosition, rep.EntryPrice, rep.Mismatches)
+	}
+	if _, err := r.Acknowledge(); err != nil {
+		t.Fatal(err)
+	}
+	if pos, entry := sim.CurrentPosition(); pos != 1.5 || entry != 110 {
+		t.Errorf("Acknowledged position %v at %v, want the exchange's 1.5 at 110", pos, entry)
+	}
+
+	// holdings above the balance leave no position rather than a negative one
+	cfg.BaseHoldings = 8
+	r = NewReconcilingExecutor(sim, &balanceClient{xbt: 6.5}, sim)
+	if rep, err = r.Reconcile(ctx, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if rep.Position != 0 || rep.OK() {
+		t.Errorf("Holdings above balance: position %v, mismatches %v", rep.Position, rep.Mismatches)
+	}
+}
@@ -1,12 +1,28 @@
 package bot
 
 import (
+	"context"
+	"fmt"
 	"math"
+	"sync"
+	"time"
+
+	luno "github.com/luno/luno-go"
+
+	"github.com/luno/luno-bot/bot/indicators"
+	"github.com/luno/luno-bot/storage"
 )
 
-// PositionSizer defines how much to stake per trade.
+// defaultKellyMinTrades is used when HistoryKellySizer.MinTrades is unset.
+const defaultKellyMinTrades = 20
+
+// kellyHistoryRefresh spaces reloads of the round-trip history.
+const kellyHistoryRefresh = time.Minute
+
+// PositionSizer defines how much to stake per trade. It sees every tick, so
+// sizers can track volatility, and returns the base volume to stake.
 type PositionSizer interface {
-	Size(equity float64, cfg Config) float64
+	Size(equity float64, md MarketData, cfg Config) float64
 }
 
 // KellySizer uses the Kelly criterion for position sizing.
@@ -16,7 +32,7 @@ type KellySizer struct {
 }
 
 // Size computes optimal fraction of equity per Kelly.
-func (k *KellySizer) Size(equity float64, cfg Config) float64 {
+func (k *KellySizer) Size(equity float64, md MarketData, cfg Config) float64 {
 	f := k.WinProb - (1-k.WinProb)/k.WinLoss
 	// cap by stake size
 	return math.Max(0, math.Min(f*equity, cfg.StakeSize))
@@ -25,6 +41,240 @@ func (k *KellySizer) Size(equity float64, cfg Config) float64 {
 // FixedSizer always uses cfg.StakeSize.
 type FixedSizer struct{}
 
-func (f *FixedSizer) Size(equity float64, cfg Config) float64 {
+func (f *FixedSizer) Size(equity float64, md MarketData, cfg Config) float64 {
 	return cfg.StakeSize
 }
+
+// FixedFractionalSizer stakes the volume that loses RiskPct of equity if
+// price falls to the stop: StopLossPct below the mid, or ATRStopMultiplier
+// ATRs below it when no percentage stop is set. It uses cfg.StakeSize until
+// a stop distance is known.
+type FixedFractionalSizer struct {
+	RiskPct float64 // % of equity risked per trade
+
+	mu  sync.Mutex
+	atr atrSet
+}
+
+// NewFixedFractionalSizer constructs a FixedFractionalSizer risking riskPct of equity.
+func NewFixedFractionalSizer(riskPct float64) *FixedFractionalSizer {
+	return &FixedFractionalSizer{RiskPct: riskPct, atr: make(atrSet)}
+}
+
+// Size divides the equity at risk by the stop distance.
+func (f *FixedFractionalSizer) Size(equity float64, md MarketData, cfg Config) float64 {
+	f.mu.Lock()
+	atr := f.atr.update(md, cfg)
+	f.mu.Unlock()
+	price := (md.Bid + md.Ask) / 2
+	stop := price * cfg.StopLossPct / 100
+	if cfg.StopLossPct <= 0 {
+		stop = cfg.ATRStopMultiplier * atr
+	}
+	if stop <= 0 || equity <= 0 {
+		return cfg.StakeSize
+	}
+	return capStake(equity*f.RiskPct/100/stop, cfg)
+}
+
+// VolTargetSizer stakes the volume whose value moves by TargetPct of equity
+// on a one-ATR move, so positions shrink as volatility rises. It uses
+// cfg.StakeSize while the ATR warms up.
+type VolTargetSizer struct {
+	TargetPct float64 // % of equity moved by one ATR
+
+	mu  sync.Mutex
+	atr atrSet
+}
+
+// NewVolTargetSizer constructs a VolTargetSizer targeting targetPct of equity per ATR.
+func NewVolTargetSizer(targetPct float64) *VolTargetSizer {
+	return &VolTargetSizer{TargetPct: targetPct, atr: make(atrSet)}
+}
+
+// Size divides the equity budget by the ATR.
+func (v *VolTargetSizer) Size(equity float64, md MarketData, cfg Config) float64 {
+	v.mu.Lock()
+	atr := v.atr.update(md, cfg)
+	v.mu.Unlock()
+	if atr <= 0 || equity <= 0 {
+		return cfg.StakeSize
+	}
+	return capStake(equity*v.TargetPct/100/atr, cfg)
+}
+
+// RoundTripHistory lists closed round trips; storage.SQLiteStore implements it.
+type RoundTripHistory interface {
+	ListRoundTrips(executor, pair string) ([]storage.RoundTrip, error)
+}
+
+// HistoryKellySizer applies the Kelly criterion with the win probability
+// and win/loss ratio measured on the pair's closed round trips on one
+// executor chain, as recorded by its Portfolio from realized fills. Until
+// MinTrades round trips have closed, with at least one loss, it uses
+// Fallback's estimates instead. The Kelly fraction of equity is converted
+// to base volume at the mid.
+type HistoryKellySizer struct {
+	Trips     RoundTripHistory
+	Executor  string // chain whose round trips count, e.g. "sim" or "live"
+	MinTrades int
+	Fallback  KellySizer
+
+	mu     sync.Mutex
+	byPair map[string]kellyStats
+}
+
+// kellyStats summarises closed round trips on a pair.
+type kellyStats struct {
+	loaded  time.Time
+	trades  int
+	winProb float64
+	winLoss float64
+}
+
+// NewHistoryKellySizer constructs a HistoryKellySizer over executor's round
+// trips, falling back to the configured estimates.
+func NewHistoryKellySizer(trips RoundTripHistory, executor string, minTrades int, fallback KellySizer) *HistoryKellySizer {
+	if minTrades <= 0 {
+		minTrades = defaultKellyMinTrades
+	}
+	return &HistoryKellySizer{Trips: trips, Executor: executor, MinTrades: minTrades, Fallback: fallback}
+}
+
+// Size stakes the Kelly fraction of equity.
+func (k *HistoryKellySizer) Size(equity float64, md MarketData, cfg Config) float64 {
+	price := (md.Bid + md.Ask) / 2
+	if price <= 0 || equity <= 0 {
+		return cfg.StakeSize
+	}
+	p, r := k.Fallback.WinProb, k.Fallback.WinLoss
+	if st := k.pairStats(cfg.Pair); st.trades >= k.MinTrades && st.winLoss > 0 {
+		p, r = st.winProb, st.winLoss
+	}
+	if r <= 0 {
+		return 0
+	}
+	f := p - (1-p)/r
+	return capStake(f*equity/price, cfg)
+}
+
+// pairStats returns the pair's round-trip statistics, reloading them when
+// they are older than a minute.
+func (k *HistoryKellySizer) pairStats(pair string) kellyStats {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.byPair == nil {
+		k.byPair = make(map[string]kellyStats)
+	}
+	st, ok := k.byPair[pair]
+	if !ok || time.Since(st.loaded) >= kellyHistoryRefresh {
+		trips, err := k.Trips.ListRoundTrips(k.Executor, pair)
+		if err != nil {
+			fmt.Printf("Kelly sizer: list round trips: %v\n", err)
+		} else {
+			st = roundTripStats(trips)
+		}
+		st.loaded = time.Now()
+		k.byPair[pair] = st
+	}
+	return st
+}
+
+// roundTripStats measures wins and losses over closed round trips.
+func roundTripStats(trips []storage.RoundTrip) kellyStats {
+	var wins, losses []float64
+	for _, t := range trips {
+		if t.PnL > 0 {
+			wins = append(wins, t.PnL)
+		} else {
+			losses = append(losses, -t.PnL)
+		}
+	}
+	mean := func(xs []float64) float64 {
+		var sum float64
+		for _, x := range xs {
+			sum += x
+		}
+		return sum / float64(len(xs))
+	}
+	st := kellyStats{trades: len(trips)}
+	if st.trades == 0 {
+		return st
+	}
+	st.winProb = float64(len(wins)) / float64(st.trades)
+	if len(wins) > 0 && len(losses) > 0 && mean(losses) > 0 {
+		st.winLoss = mean(wins) / mean(losses)
+	}
+	return st
+}
+
+// capStake bounds a sized stake to [0, cfg.PositionLimit].
+func capStake(volume float64, cfg Config) float64 {
+	if cfg.PositionLimit > 0 {
+		volume = math.Min(volume, cfg.PositionLimit)
+	}
+	return math.Max(0, volume)
+}
+
+// atrSet keeps an ATR per pair fed from ticks, using the ask as high and the
+// bid as low.
+type atrSet map[string]*indicators.ATR
+
+// update feeds md into the pair's ATR over cfg.ATRPeriod ticks and returns
+// its value once ready.
+func (s atrSet) update(md MarketData, cfg Config) float64 {
+	if md.Bid <= 0 || md.Ask <= 0 {
+		return 0
+	}
+	period := cfg.ATRPeriod
+	if period <= 0 {
+		period = defaultATRPeriod
+	}
+	a := s[cfg.Pair]
+	if a == nil {
+		a = indicators.NewATR(period)
+		s[cfg.Pair] = a
+	}
+	a.Update(md.Ask, md.Bid, (md.Bid+md.Ask)/2)
+	if !a.Ready() {
+		return 0
+	}
+	return a.Value()
+}
+
+// EquitySource reports the account equity, in quote currency, that sizers
+// scale with.
+type EquitySource interface {
+	Equity(ctx context.Context, md MarketData, cfg Config) (float64, error)
+}
+
+// BalanceGetter serves account balances; Client and PaperLedger implement it.
+type BalanceGetter interface {
+	GetBalances(ctx context.Context, req *luno.GetBalancesRequest) (*luno.GetBalancesResponse, error)
+}
+
+// BalanceEquity values the pair's base and counter balances, including
+// reserved funds, at the mid.
+type BalanceEquity struct {
+	Balances BalanceGetter
+}
+
+// Equity sums the counter balance and the base balance at the mid.
+func (b BalanceEquity) Equity(ctx context.Context, md MarketData, cfg Config) (float64, error) {
+	base, counter := splitPair(cfg.Pair)
+	resp, err := b.Balances.GetBalances(ctx, &luno.GetBalancesRequest{Assets: []string{base, counter}})
+	if err != nil {
+		return 0, fmt.Errorf("get balances: %w", err)
+	}
+	var equity float64
+	for _, bal := range resp.Balance {
+		v := decFloat(bal.Balance) + decFloat(bal.Reserved)
+		switch bal.Asset {
+		case base:
+			equity += v * (md.Bid + md.Ask) / 2
+		case counter:
+			equity += v
+		}
+	}
+	return equity, nil
+}
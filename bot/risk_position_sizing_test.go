@@ -0,0 +1,99 @@
+package bot
+
+import (
+	"context"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/luno/luno-bot/storage"
+)
+
+func TestSizersScaleWithEquity(t *testing.T) {
+	cfg := Config{Pair: "XBTZAR", StakeSize: 0.5, PositionLimit: 100, ATRPeriod: 2}
+	md := MarketData{Bid: 99, Ask: 101} // ATR of 2 once warmed up
+
+	ff := NewFixedFractionalSizer(1)
+	if got := ff.Size(10000, md, cfg); got != cfg.StakeSize {
+		t.Errorf("Fixed fractional without a stop staked %v, want the stake size", got)
+	}
+	cfg.StopLossPct = 5 // stop 5 below the mid of 100
+	if got := ff.Size(10000, md, cfg); got != 20 {
+		t.Errorf("Fixed fractional staked %v, want 100/5 = 20", got)
+	}
+
+	vt := NewVolTargetSizer(2)
+	if got := vt.Size(10000, md, cfg); got != cfg.StakeSize {
+		t.Errorf("Vol target during warm-up staked %v, want the stake size", got)
+	}
+	if got := vt.Size(10000, md, cfg); got != 100 {
+		t.Errorf("Vol target staked %v, want 200/2 = 100", got)
+	}
+	if got := vt.Size(20000, md, cfg); got != cfg.PositionLimit {
+		t.Errorf("Vol target staked %v, want it capped at the position limit", got)
+	}
+
+}
+
+func TestHistoryKellySizerUsesClosedRoundTrips(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewSQLiteStore(filepath.Join(t.TempDir(), "trips.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	sim := NewSimulatedExecutor()
+	p := NewPortfolio("sim", sim, sim, nil)
+	p.Trips = store
+	cfg := Config{Pair: "XBTZAR", StakeSize: 1, PositionLimit: 1, MaxDrawdown: 1e9, InitialEquity: 1000}
+	start := time.Now()
+	tick := func(sig Signal, price float64, i int) {
+		t.Helper()
+		md := MarketData{Bid: price, Ask: price, Timestamp: start.Add(time.Duration(i) * time.Minute)}
+		if err := p.Execute(ctx, sig, md, cfg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// three wins of 20 and one loss of 10: p = 0.75, R = 2, f = 0.625
+	for i, exit := range []float64{120, 120, 90, 120} {
+		tick(SignalBuy, 100, 2*i)
+		tick(SignalSell, exit, 2*i+1)
+	}
+	// a live round trip on the same pair is not counted
+	if err := store.SaveRoundTrip(storage.RoundTrip{Executor: "live", Pair: "XBTZAR", Opened: start, Closed: start, PnL: -50}); err != nil {
+		t.Fatal(err)
+	}
+
+	md := MarketData{Bid: 99, Ask: 101}
+	cfg.PositionLimit = 100
+	hk := NewHistoryKellySizer(store, "sim", 4, KellySizer{WinProb: 0.5, WinLoss: 1})
+	if got := hk.Size(1000, md, cfg); math.Abs(got-6.25) > 1e-9 {
+		t.Errorf("History Kelly staked %v, want 0.625*1000/100 = 6.25", got)
+	}
+	hk.MinTrades = 5
+	hk.byPair = nil
+	if got := hk.Size(1000, md, cfg); got != 0 {
+		t.Errorf("History Kelly with too few round trips staked %v, want the zero-edge fallback", got)
+	}
+	hk = NewHistoryKellySizer(store, "sim", 1, KellySizer{WinProb: 0.5, WinLoss: 1})
+	if got := hk.Size(1000, md, Config{Pair: "ETHZAR", StakeSize: 1, PositionLimit: 100}); got != 0 {
+		t.Errorf("History Kelly on a pair without round trips staked %v, want the zero-edge fallback", got)
+	}
+}
+
+func TestSizingExecutorUsesLiveEquity(t *testing.T) {
+	ctx := context.Background()
+	rec := &stakeRecorder{}
+	ledger := NewPaperLedger(map[string]float64{"XBT": 1, "ZAR": 900})
+	s := NewSizingExecutor(rec, NewFixedFractionalSizer(1))
+	s.Equity = BalanceEquity{Balances: ledger}
+	cfg := Config{Pair: "XBTZAR", StakeSize: 0.5, PositionLimit: 100, StopLossPct: 10, InitialEquity: 100}
+	if err := s.Execute(ctx, SignalBuy, MarketData{Bid: 99, Ask: 101}, cfg); err != nil {
+		t.Fatal(err)
+	}
+	// equity 900 + 1*100 = 1000, risking 10 against a stop 10 below
+	if len(rec.stakes) != 1 || math.Abs(rec.stakes[0]-1) > 1e-9 {
+		t.Errorf("Staked %v, want 1", rec.stakes)
+	}
+}
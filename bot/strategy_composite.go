@@ -1,32 +1,276 @@
 package bot
 
-// CompositeStrategy combines multiple strategies and signals only when all agree.
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Composite voting modes.
+const (
+	VoteUnanimous = "unanimous" // every child agrees (the default)
+	VoteMajority  = "majority"  // more than half of the children agree
+	VoteWeighted  = "weighted"  // agreeing children carry more than Threshold of the total weight
+	VoteAtLeast   = "at_least"  // at least MinVotes children agree and outvote the other side
+	VoteVeto      = "veto"      // a majority of the others agree and the Veto child does not oppose
+)
+
+// VoteRule chooses how a CompositeStrategy combines its children's signals.
+// Children that signal nothing abstain, which counts against a signal in
+// every mode. Weights and Veto refer to children by name.
+type VoteRule struct {
+	Mode      string             `json:"vote_mode"`
+	MinVotes  int                `json:"vote_min"`
+	Threshold float64            `json:"vote_threshold"`
+	Weights   map[string]float64 `json:"vote_weights"`
+	Veto      string             `json:"vote_veto"`
+}
+
+// validate checks the rule against the children's names.
+func (r VoteRule) validate(names []string) error {
+	known := make(map[string]bool, len(names))
+	for _, n := range names {
+		known[n] = true
+	}
+	switch r.Mode {
+	case "", VoteUnanimous, VoteMajority:
+	case VoteWeighted:
+		if r.Threshold < 0 || r.Threshold >= 1 {
+			return fmt.Errorf("vote_threshold must be in [0, 1), got %g", r.Threshold)
+		}
+		var total float64
+		for _, n := range names {
+			total += r.weight(n)
+		}
+		for n, w := range r.Weights {
+			if !known[n] {
+				return fmt.Errorf("vote_weights names unknown child %q", n)
+			}
+			if w < 0 {
+				return fmt.Errorf("vote_weights[%s] must not be negative, got %g", n, w)
+			}
+		}
+		if total <= 0 {
+			return fmt.Errorf("vote_weights sum to zero")
+		}
+	case VoteAtLeast:
+		if r.MinVotes <= 0 || r.MinVotes > len(names) {
+			return fmt.Errorf("vote_min must be between 1 and %d, got %d", len(names), r.MinVotes)
+		}
+	case VoteVeto:
+		if !known[r.Veto] {
+			return fmt.Errorf("vote_veto must name a child, got %q", r.Veto)
+		}
+		if len(names) < 2 {
+			return fmt.Errorf("veto needs at least two children")
+		}
+	default:
+		return fmt.Errorf("unknown vote_mode %q", r.Mode)
+	}
+	return nil
+}
+
+// weight returns the child's vote weight, 1 unless set.
+func (r VoteRule) weight(name string) float64 {
+	if w, ok := r.Weights[name]; ok {
+		return w
+	}
+	return 1
+}
+
+// ChildVote is one child's signal on the last tick.
+type ChildVote struct {
+	Name   string  `json:"name"`
+	Signal string  `json:"signal"`
+	Weight float64 `json:"weight,omitempty"`
+}
+
+// Voter reports the child signals behind a strategy's last decision.
+type Voter interface {
+	Votes() []ChildVote
+}
+
+// CompositeStrategy combines multiple strategies and signals when its
+// children's votes pass Rule.
 type CompositeStrategy struct {
+	Rule VoteRule
+
 	strategies []Strategy
+	names      []string
+
+	mu    sync.Mutex
+	votes []ChildVote
 }
 
-// NewCompositeStrategy constructs a CompositeStrategy from given sub-strategies.
+// NewCompositeStrategy constructs a unanimous CompositeStrategy from given
+// sub-strategies, naming each child after its type.
 func NewCompositeStrategy(strats ...Strategy) *CompositeStrategy {
-	return &CompositeStrategy{strategies: strats}
+	names := make([]string, len(strats))
+	for i, s := range strats {
+		names[i] = strategyName(s)
+	}
+	return &CompositeStrategy{strategies: strats, names: names}
+}
+
+// NewVotingStrategy constructs a CompositeStrategy over named children that
+// votes by rule. names and strats must be the same length.
+func NewVotingStrategy(rule VoteRule, names []string, strats ...Strategy) (*CompositeStrategy, error) {
+	if len(names) != len(strats) {
+		return nil, fmt.Errorf("%d names for %d strategies", len(names), len(strats))
+	}
+	if len(strats) == 0 {
+		return nil, fmt.Errorf("no strategies to vote")
+	}
+	if err := rule.validate(names); err != nil {
+		return nil, err
+	}
+	return &CompositeStrategy{Rule: rule, strategies: strats, names: names}, nil
 }
 
-// Next returns SignalBuy if all sub-strategies return buy, SignalSell if all return sell, else SignalNone.
+// Next treats data as a tick event.
 func (c *CompositeStrategy) Next(data MarketData, cfg Config) Signal {
-	buyCount, sellCount := 0, 0
-	n := len(c.strategies)
-	for _, strat := range c.strategies {
-		sig := strat.Next(data, cfg)
-		if sig == SignalBuy {
-			buyCount++
-		} else if sig == SignalSell {
-			sellCount++
+	return c.OnEvent(TickEvent(data), cfg)
+}
+
+// OnEvent polls every child and returns the signal their votes carry, else none.
+func (c *CompositeStrategy) OnEvent(ev MarketEvent, cfg Config) Signal {
+	sigs := make([]Signal, len(c.strategies))
+	votes := make([]ChildVote, len(c.strategies))
+	for i, strat := range c.strategies {
+		sigs[i] = AdaptStrategy(strat).OnEvent(ev, cfg)
+		votes[i] = ChildVote{Name: c.names[i], Signal: sigs[i].String()}
+		if c.Rule.Mode == VoteWeighted {
+			votes[i].Weight = c.Rule.weight(c.names[i])
+		}
+	}
+	c.mu.Lock()
+	c.votes = votes
+	c.mu.Unlock()
+	return c.decide(sigs)
+}
+
+// Votes returns each child's signal on the last tick.
+func (c *CompositeStrategy) Votes() []ChildVote {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]ChildVote(nil), c.votes...)
+}
+
+// Seed warms up the children that run on their own timeframe.
+func (c *CompositeStrategy) Seed(ctx context.Context, client Client, cfg Config) error {
+	return seedAll(ctx, client, cfg, c.strategies...)
+}
+
+// decide applies Rule to the children's signals.
+func (c *CompositeStrategy) decide(sigs []Signal) Signal {
+	count := func(skip int) (buys, sells, n int) {
+		for i, sig := range sigs {
+			if i == skip {
+				continue
+			}
+			n++
+			if sig == SignalBuy {
+				buys++
+			} else if sig == SignalSell {
+				sells++
+			}
+		}
+		return
+	}
+	// side returns the signal whose votes exceed half of total
+	side := func(buys, sells, total float64) Signal {
+		if buys > total/2 {
+			return SignalBuy
 		}
+		if sells > total/2 {
+			return SignalSell
+		}
+		return SignalNone
+	}
+	switch c.Rule.Mode {
+	case VoteMajority:
+		buys, sells, n := count(-1)
+		return side(float64(buys), float64(sells), float64(n))
+	case VoteWeighted:
+		threshold := c.Rule.Threshold
+		if threshold == 0 {
+			threshold = 0.5
+		}
+		var buys, sells, total float64
+		for i, sig := range sigs {
+			w := c.Rule.weight(c.names[i])
+			total += w
+			if sig == SignalBuy {
+				buys += w
+			} else if sig == SignalSell {
+				sells += w
+			}
+		}
+		if total > 0 && buys/total > threshold && buys > sells {
+			return SignalBuy
+		}
+		if total > 0 && sells/total > threshold && sells > buys {
+			return SignalSell
+		}
+		return SignalNone
+	case VoteAtLeast:
+		buys, sells, _ := count(-1)
+		if buys >= c.Rule.MinVotes && buys > sells {
+			return SignalBuy
+		}
+		if sells >= c.Rule.MinVotes && sells > buys {
+			return SignalSell
+		}
+		return SignalNone
+	case VoteVeto:
+		veto := -1
+		for i, n := range c.names {
+			if n == c.Rule.Veto {
+				veto = i
+				break
+			}
+		}
+		if veto < 0 {
+			return SignalNone
+		}
+		buys, sells, n := count(veto)
+		sig := side(float64(buys), float64(sells), float64(n))
+		if (sig == SignalBuy && sigs[veto] == SignalSell) || (sig == SignalSell && sigs[veto] == SignalBuy) {
+			return SignalNone
+		}
+		return sig
 	}
-	if buyCount == n {
+	buys, sells, n := count(-1)
+	if buys == n {
 		return SignalBuy
 	}
-	if sellCount == n {
+	if sells == n {
 		return SignalSell
 	}
 	return SignalNone
 }
+
+// strategyName returns the registry name of a built-in strategy, or its Go type.
+func strategyName(s Strategy) string {
+	switch t := s.(type) {
+	case *SMAStrategy:
+		return "sma"
+	case *RSIStrategy:
+		return "rsi"
+	case *MACDStrategy:
+		return "macd"
+	case *BBandsStrategy:
+		return "bbands"
+	case *ThresholdStrategy:
+		return "threshold"
+	case *VWAPStrategy:
+		return "vwap"
+	case *CompositeStrategy:
+		return "composite"
+	case *MultiTimeframeStrategy:
+		return DefaultStrategy
+	case *TimeframeStrategy:
+		return strategyName(t.Inner)
+	}
+	return fmt.Sprintf("%T", s)
+}
@@ -0,0 +1,69 @@
+package bot
+
+import "testing"
+
+// fixedSignal always returns itself.
+type fixedSignal Signal
+
+func (f fixedSignal) Next(data MarketData, cfg Config) Signal { return Signal(f) }
+
+func TestCompositeVoteModes(t *testing.T) {
+	names := []string{"a", "b", "c", "d", "e"}
+	// a-c buy, d sells, e abstains
+	children := []Strategy{fixedSignal(SignalBuy), fixedSignal(SignalBuy), fixedSignal(SignalBuy), fixedSignal(SignalSell), fixedSignal(SignalNone)}
+	for _, tc := range []struct {
+		rule VoteRule
+		want Signal
+	}{
+		{VoteRule{}, SignalNone},
+		{VoteRule{Mode: VoteMajority}, SignalBuy},
+		{VoteRule{Mode: VoteWeighted, Weights: map[string]float64{"d": 4}}, SignalNone},
+		{VoteRule{Mode: VoteWeighted, Weights: map[string]float64{"d": 4}, Threshold: 0.4}, SignalSell},
+		{VoteRule{Mode: VoteWeighted, Weights: map[string]float64{"a": 3}}, SignalBuy},
+		{VoteRule{Mode: VoteAtLeast, MinVotes: 3}, SignalBuy},
+		{VoteRule{Mode: VoteAtLeast, MinVotes: 4}, SignalNone},
+		{VoteRule{Mode: VoteVeto, Veto: "d"}, SignalNone},
+		{VoteRule{Mode: VoteVeto, Veto: "e"}, SignalBuy},
+	} {
+		c, err := NewVotingStrategy(tc.rule, names, children...)
+		if err != nil {
+			t.Fatalf("%+v: %v", tc.rule, err)
+		}
+		if got := c.Next(MarketData{}, Config{}); got != tc.want {
+			t.Errorf("%+v: got %v, want %v", tc.rule, got, tc.want)
+		}
+	}
+
+	for _, rule := range []VoteRule{
+		{Mode: "plurality"},
+		{Mode: VoteAtLeast, MinVotes: 6},
+		{Mode: VoteWeighted, Weights: map[string]float64{"z": 1}},
+		{Mode: VoteVeto, Veto: "z"},
+	} {
+		if _, err := NewVotingStrategy(rule, names, children...); err == nil {
+			t.Errorf("%+v: expected an error", rule)
+		}
+	}
+}
+
+func TestCompositeVotes(t *testing.T) {
+	c := NewCompositeStrategy(fixedSignal(SignalBuy), fixedSignal(SignalSell))
+	c.Rule = VoteRule{Mode: VoteWeighted}
+	c.Next(MarketData{}, Config{})
+	votes := c.Votes()
+	if len(votes) != 2 {
+		t.Fatalf("got %d votes, want 2", len(votes))
+	}
+	if votes[0].Signal != "buy" || votes[1].Signal != "sell" || votes[0].Weight != 1 {
+		t.Errorf("votes = %+v", votes)
+	}
+
+	mtf, err := BuildStrategy(DefaultStrategy, []byte(`{"vote_mode": "majority"}`))
+	if err != nil {
+		t.Fatalf("BuildStrategy: %v", err)
+	}
+	mtf.Next(MarketData{Bid: 100, Ask: 101}, Config{})
+	if v := mtf.(Voter).Votes(); len(v) != 10 || v[0].Name != "fast/sma" || v[5].Name != "slow/sma" {
+		t.Errorf("multi-timeframe votes = %+v", v)
+	}
+}
@@ -1,6 +1,10 @@
 package bot
 
-import "github.com/luno/luno-bot/config"
+import (
+	"context"
+
+	"github.com/luno/luno-bot/config"
+)
 
 // MultiTimeframeStrategy wraps fast and slow composite strategies.
 type MultiTimeframeStrategy struct {
@@ -8,8 +12,18 @@ type MultiTimeframeStrategy struct {
 	Slow Strategy
 }
 
-// NewMultiTimeframeStrategy builds two composites (fast and slow timeframes) from cfg.
+// NewMultiTimeframeStrategy builds two composites (fast and slow timeframes)
+// from cfg, each voting by cfg's vote settings. The fast composite runs on
+// cfg.FastTimeframe bars, or every tick when unset. The slow one runs the
+// same periods on cfg.SlowTimeframe bars, holding its signal between them;
+// without a slow timeframe it doubles the periods on the fast data instead.
 func NewMultiTimeframeStrategy(cfg *config.Config) *MultiTimeframeStrategy {
+	fastTF, _ := ParseTimeframe(cfg.FastTimeframe)
+	slowTF, _ := ParseTimeframe(cfg.SlowTimeframe)
+	scale := 2
+	if slowTF > 0 {
+		scale = 1
+	}
 	// Fast timeframe strategies
 	fastStrats := []Strategy{
 		NewSMAStrategy(cfg.ShortWindow, cfg.LongWindow),
@@ -18,25 +32,70 @@ func NewMultiTimeframeStrategy(cfg *config.Config) *MultiTimeframeStrategy {
 		NewMACDStrategy(cfg.MACDFastPeriod, cfg.MACDSlowPeriod, cfg.MACDSignalPeriod),
 		NewBBandsStrategy(cfg.BBPeriod, cfg.BBMultiplier),
 	}
-	// Slow timeframe: periods doubled, same thresholds
+	// Slow timeframe: same thresholds, periods scaled unless on slower bars
 	slowStrats := []Strategy{
-		NewSMAStrategy(cfg.ShortWindow*2, cfg.LongWindow*2),
+		NewSMAStrategy(cfg.ShortWindow*scale, cfg.LongWindow*scale),
 		NewThresholdStrategy(),
-		NewRSIStrategy(cfg.RSIPeriod*2, cfg.RSIOverBought, cfg.RSIOverSold),
-		NewMACDStrategy(cfg.MACDFastPeriod*2, cfg.MACDSlowPeriod*2, cfg.MACDSignalPeriod*2),
-		NewBBandsStrategy(cfg.BBPeriod*2, cfg.BBMultiplier),
+		NewRSIStrategy(cfg.RSIPeriod*scale, cfg.RSIOverBought, cfg.RSIOverSold),
+		NewMACDStrategy(cfg.MACDFastPeriod*scale, cfg.MACDSlowPeriod*scale, cfg.MACDSignalPeriod*scale),
+		NewBBandsStrategy(cfg.BBPeriod*scale, cfg.BBMultiplier),
+	}
+	rule := VoteRule{
+		Mode:      cfg.VoteMode,
+		MinVotes:  cfg.VoteMin,
+		Threshold: cfg.VoteThreshold,
+		Weights:   cfg.VoteWeights,
+		Veto:      cfg.VoteVeto,
 	}
 	fast := NewCompositeStrategy(fastStrats...)
+	fast.Rule = rule
 	slow := NewCompositeStrategy(slowStrats...)
-	return &MultiTimeframeStrategy{Fast: fast, Slow: slow}
+	slow.Rule = rule
+	m := &MultiTimeframeStrategy{Fast: fast, Slow: slow}
+	if fastTF > 0 {
+		m.Fast = NewTimeframeStrategy(fastTF, fast, false)
+	}
+	if slowTF > 0 {
+		m.Slow = NewTimeframeStrategy(slowTF, slow, true)
+	}
+	return m
 }
 
-// Next returns a signal only if fast and slow agree, else none.
+// Next treats data as a tick event.
 func (m *MultiTimeframeStrategy) Next(data MarketData, cfg Config) Signal {
-	sigFast := m.Fast.Next(data, cfg)
-	sigSlow := m.Slow.Next(data, cfg)
+	return m.OnEvent(TickEvent(data), cfg)
+}
+
+// OnEvent returns a signal only if fast and slow agree, else none.
+func (m *MultiTimeframeStrategy) OnEvent(ev MarketEvent, cfg Config) Signal {
+	sigFast := AdaptStrategy(m.Fast).OnEvent(ev, cfg)
+	sigSlow := AdaptStrategy(m.Slow).OnEvent(ev, cfg)
 	if sigFast == sigSlow {
 		return sigFast
 	}
 	return SignalNone
 }
+
+// Seed warms up the fast and slow composites on their timeframes.
+func (m *MultiTimeframeStrategy) Seed(ctx context.Context, client Client, cfg Config) error {
+	return seedAll(ctx, client, cfg, m.Fast, m.Slow)
+}
+
+// Votes returns the fast and slow children's signals, prefixed "fast/" and "slow/".
+func (m *MultiTimeframeStrategy) Votes() []ChildVote {
+	var votes []ChildVote
+	for _, tf := range []struct {
+		prefix string
+		strat  Strategy
+	}{{"fast/", m.Fast}, {"slow/", m.Slow}} {
+		v, ok := tf.strat.(Voter)
+		if !ok {
+			continue
+		}
+		for _, vote := range v.Votes() {
+			vote.Name = tf.prefix + vote.Name
+			votes = append(votes, vote)
+		}
+	}
+	return votes
+}
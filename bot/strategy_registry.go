@@ -0,0 +1,505 @@
+package bot
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/luno/luno-bot/config"
+)
+
+// DefaultStrategy is built when a config names no strategy.
+const DefaultStrategy = "multitimeframe"
+
+// ErrUnknownStrategy is returned for a name with no registered factory.
+var ErrUnknownStrategy = errors.New("unknown strategy")
+
+// StrategyParam describes one parameter a strategy factory accepts.
+type StrategyParam struct {
+	Name        string      `json:"name"`
+	Type        string      `json:"type"` // "int", "float", "string", "object" or "array"
+	Default     interface{} `json:"default,omitempty"`
+	Description string      `json:"description"`
+}
+
+// StrategyInfo names a registered strategy and its parameter schema.
+type StrategyInfo struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Params      []StrategyParam `json:"params"`
+}
+
+// StrategyFactory builds a strategy from a JSON parameter block, which may be
+// empty. It validates the parameters and returns an error for bad ones.
+// Unknown keys are ignored, so one block can carry several strategies' params.
+type StrategyFactory func(params json.RawMessage) (Strategy, error)
+
+type registeredStrategy struct {
+	info    StrategyInfo
+	factory StrategyFactory
+}
+
+var (
+	strategiesMu sync.RWMutex
+	strategies   = make(map[string]registeredStrategy)
+)
+
+// timeframeParam is accepted by every strategy; see BuildStrategy.
+var timeframeParam = StrategyParam{Name: "timeframe", Type: "string", Description: `bar interval to run on, e.g. "5m", "1h" or "1d"; "" runs on every tick`}
+
+// RegisterStrategy makes a strategy buildable by name, adding the common
+// timeframe parameter to its schema. Registering a name twice is a
+// programming error and panics.
+func RegisterStrategy(info StrategyInfo, factory StrategyFactory) {
+	strategiesMu.Lock()
+	defer strategiesMu.Unlock()
+	if _, dup := strategies[info.Name]; dup {
+		panic("strategy registered twice: " + info.Name)
+	}
+	info.Params = append(info.Params[:len(info.Params):len(info.Params)], timeframeParam)
+	strategies[info.Name] = registeredStrategy{info: info, factory: factory}
+}
+
+// hasParam reports whether the strategy takes the parameter name.
+func (i StrategyInfo) hasParam(name string) bool {
+	for _, p := range i.Params {
+		if p.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Strategies lists the registered strategies by name.
+func Strategies() []StrategyInfo {
+	strategiesMu.RLock()
+	defer strategiesMu.RUnlock()
+	infos := make([]StrategyInfo, 0, len(strategies))
+	for _, s := range strategies {
+		infos = append(infos, s.info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// BuildStrategy builds the strategy registered as name from params. When
+// params set a timeframe, the strategy runs on bars of that interval
+// resampled from the ticks it is given; strategies with their own fast and
+// slow timeframes reject one.
+func BuildStrategy(name string, params json.RawMessage) (Strategy, error) {
+	strategiesMu.RLock()
+	s, ok := strategies[name]
+	strategiesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownStrategy, name)
+	}
+	var tf struct {
+		Timeframe string `json:"timeframe"`
+		timeframeParams
+	}
+	if err := decodeParams(params, &tf); err != nil {
+		return nil, fmt.Errorf("strategy %s: %w", name, err)
+	}
+	// a strategy with its own timeframes would otherwise resample twice
+	if tf.Timeframe != "" && (tf.Fast != "" || tf.Slow != "") && s.info.hasParam("fast_timeframe") {
+		return nil, fmt.Errorf("strategy %s: timeframe cannot be combined with fast_timeframe or slow_timeframe", name)
+	}
+	interval, err := ParseTimeframe(tf.Timeframe)
+	if err != nil {
+		return nil, fmt.Errorf("strategy %s: %w", name, err)
+	}
+	strat, err := s.factory(params)
+	if err != nil {
+		return nil, fmt.Errorf("strategy %s: %w", name, err)
+	}
+	if interval > 0 {
+		strat = NewTimeframeStrategy(interval, strat, false)
+	}
+	return strat, nil
+}
+
+// NewStrategy builds cfg.Strategy, or DefaultStrategy when unset. Its
+// parameters are the config's indicator fields (short_window, rsi_period,
+// bb_multiplier, ...) overridden by cfg.StrategyParams.
+func NewStrategy(cfg Config) (Strategy, error) {
+	name := cfg.Strategy
+	if name == "" {
+		name = DefaultStrategy
+	}
+	params, err := mergeParams(configParams(cfg), cfg.StrategyParams)
+	if err != nil {
+		return nil, fmt.Errorf("strategy %s: %w", name, err)
+	}
+	return BuildStrategy(name, params)
+}
+
+// configParams returns cfg's non-zero indicator, vote and timeframe fields
+// under their config keys.
+func configParams(cfg Config) json.RawMessage {
+	m := make(map[string]interface{})
+	for key, v := range map[string]float64{
+		"short_window":       float64(cfg.ShortWindow),
+		"long_window":        float64(cfg.LongWindow),
+		"rsi_period":         float64(cfg.RSIPeriod),
+		"rsi_overbought":     cfg.RSIOverBought,
+		"rsi_oversold":       cfg.RSIOverSold,
+		"macd_fast_period":   float64(cfg.MACDFastPeriod),
+		"macd_slow_period":   float64(cfg.MACDSlowPeriod),
+		"macd_signal_period": float64(cfg.MACDSignalPeriod),
+		"bb_period":          float64(cfg.BBPeriod),
+		"bb_multiplier":      cfg.BBMultiplier,
+		"vote_min":           float64(cfg.VoteMin),
+		"vote_threshold":     cfg.VoteThreshold,
+	} {
+		if v != 0 {
+			m[key] = v
+		}
+	}
+	for key, v := range map[string]string{
+		"vote_mode":      cfg.VoteMode,
+		"vote_veto":      cfg.VoteVeto,
+		"timeframe":      cfg.Timeframe,
+		"fast_timeframe": cfg.FastTimeframe,
+		"slow_timeframe": cfg.SlowTimeframe,
+	} {
+		if v != "" {
+			m[key] = v
+		}
+	}
+	if len(cfg.VoteWeights) > 0 {
+		m["vote_weights"] = cfg.VoteWeights
+	}
+	data, _ := json.Marshal(m)
+	return data
+}
+
+// mergeParams overlays the keys of override onto base; both must be JSON objects.
+func mergeParams(base, override json.RawMessage) (json.RawMessage, error) {
+	if len(bytes.TrimSpace(override)) == 0 {
+		return base, nil
+	}
+	m := make(map[string]json.RawMessage)
+	for _, raw := range []json.RawMessage{base, override} {
+		if err := json.Unmarshal(raw, &m); err != nil {
+			return nil, fmt.Errorf("params must be a JSON object: %w", err)
+		}
+	}
+	return json.Marshal(m)
+}
+
+// decodeParams unmarshals params into dst, which holds the defaults.
+func decodeParams(params json.RawMessage, dst interface{}) error {
+	if len(bytes.TrimSpace(params)) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(params, dst); err != nil {
+		return fmt.Errorf("invalid params: %w", err)
+	}
+	return nil
+}
+
+// smaParams configures SMAStrategy.
+type smaParams struct {
+	Short int `json:"short_window"`
+	Long  int `json:"long_window"`
+}
+
+func (p smaParams) validate() error {
+	if p.Short <= 0 || p.Long <= 0 || p.Short >= p.Long {
+		return fmt.Errorf("need 0 < short_window < long_window, got %d and %d", p.Short, p.Long)
+	}
+	return nil
+}
+
+// rsiParams configures RSIStrategy.
+type rsiParams struct {
+	Period     int     `json:"rsi_period"`
+	Overbought float64 `json:"rsi_overbought"`
+	Oversold   float64 `json:"rsi_oversold"`
+}
+
+func (p rsiParams) validate() error {
+	if p.Period <= 0 {
+		return fmt.Errorf("rsi_period must be positive, got %d", p.Period)
+	}
+	if p.Oversold <= 0 || p.Oversold >= p.Overbought || p.Overbought >= 100 {
+		return fmt.Errorf("need 0 < rsi_oversold < rsi_overbought < 100, got %g and %g", p.Oversold, p.Overbought)
+	}
+	return nil
+}
+
+// macdParams configures MACDStrategy.
+type macdParams struct {
+	Fast   int `json:"macd_fast_period"`
+	Slow   int `json:"macd_slow_period"`
+	Signal int `json:"macd_signal_period"`
+}
+
+func (p macdParams) validate() error {
+	if p.Fast <= 0 || p.Slow <= 0 || p.Signal <= 0 {
+		return fmt.Errorf("macd periods must be positive, got %d, %d and %d", p.Fast, p.Slow, p.Signal)
+	}
+	if p.Fast >= p.Slow {
+		return fmt.Errorf("need macd_fast_period < macd_slow_period, got %d and %d", p.Fast, p.Slow)
+	}
+	return nil
+}
+
+// bbandsParams configures BBandsStrategy.
+type bbandsParams struct {
+	Period     int     `json:"bb_period"`
+	Multiplier float64 `json:"bb_multiplier"`
+}
+
+func (p bbandsParams) validate() error {
+	if p.Period <= 0 || p.Multiplier <= 0 {
+		return fmt.Errorf("need bb_period > 0 and bb_multiplier > 0, got %d and %g", p.Period, p.Multiplier)
+	}
+	return nil
+}
+
+// vwapParams configures VWAPStrategy.
+type vwapParams struct {
+	Period  int     `json:"vwap_period"`
+	BandPct float64 `json:"vwap_band_pct"`
+}
+
+func (p vwapParams) validate() error {
+	if p.Period <= 0 || p.BandPct < 0 {
+		return fmt.Errorf("need vwap_period > 0 and vwap_band_pct >= 0, got %d and %g", p.Period, p.BandPct)
+	}
+	return nil
+}
+
+// Defaults for parameters a config or params block leaves out.
+var (
+	defaultSMAParams    = smaParams{Short: 5, Long: 10}
+	defaultRSIParams    = rsiParams{Period: 14, Overbought: 70, Oversold: 30}
+	defaultMACDParams   = macdParams{Fast: 12, Slow: 26, Signal: 9}
+	defaultBBandsParams = bbandsParams{Period: 20, Multiplier: 2}
+	defaultVWAPParams   = vwapParams{Period: 20, BandPct: 1}
+)
+
+// timeframeParams configures the MultiTimeframeStrategy bar intervals.
+type timeframeParams struct {
+	Fast string `json:"fast_timeframe"`
+	Slow string `json:"slow_timeframe"`
+}
+
+func (p timeframeParams) validate() error {
+	fast, err := ParseTimeframe(p.Fast)
+	if err != nil {
+		return err
+	}
+	slow, err := ParseTimeframe(p.Slow)
+	if err != nil {
+		return err
+	}
+	if slow > 0 && slow <= fast {
+		return fmt.Errorf("slow_timeframe %s must be longer than fast_timeframe %s", p.Slow, p.Fast)
+	}
+	return nil
+}
+
+// voteParams describes the VoteRule keys composite strategies accept.
+var voteParams = []StrategyParam{
+	{Name: "vote_mode", Type: "string", Default: VoteUnanimous, Description: "unanimous, majority, weighted, at_least or veto"},
+	{Name: "vote_min", Type: "int", Description: "at_least: votes a signal needs"},
+	{Name: "vote_threshold", Type: "float", Default: 0.5, Description: "weighted: share of total weight a signal needs"},
+	{Name: "vote_weights", Type: "object", Description: "weighted: weight per child name, default 1"},
+	{Name: "vote_veto", Type: "string", Description: "veto: child whose opposing signal blocks the others' majority"},
+}
+
+// compositeParams configures CompositeStrategy.
+type compositeParams struct {
+	VoteRule
+	Strategies []struct {
+		Name   string          `json:"name"`
+		Params json.RawMessage `json:"params"`
+	} `json:"strategies"`
+}
+
+func init() {
+	RegisterStrategy(StrategyInfo{
+		Name:        "sma",
+		Description: "Buys when the short SMA of the mid crosses above the long SMA by entry_threshold, sells below by exit_threshold",
+		Params: []StrategyParam{
+			{Name: "short_window", Type: "int", Default: defaultSMAParams.Short, Description: "short SMA window"},
+			{Name: "long_window", Type: "int", Default: defaultSMAParams.Long, Description: "long SMA window, greater than short_window"},
+		},
+	}, func(params json.RawMessage) (Strategy, error) {
+		p := defaultSMAParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		if err := p.validate(); err != nil {
+			return nil, err
+		}
+		return NewSMAStrategy(p.Short, p.Long), nil
+	})
+
+	RegisterStrategy(StrategyInfo{
+		Name:        "rsi",
+		Description: "Buys when Wilder's RSI of the mid is oversold and sells when it is overbought",
+		Params: []StrategyParam{
+			{Name: "rsi_period", Type: "int", Default: defaultRSIParams.Period, Description: "RSI period"},
+			{Name: "rsi_overbought", Type: "float", Default: defaultRSIParams.Overbought, Description: "RSI level at or above which to sell"},
+			{Name: "rsi_oversold", Type: "float", Default: defaultRSIParams.Oversold, Description: "RSI level at or below which to buy"},
+		},
+	}, func(params json.RawMessage) (Strategy, error) {
+		p := defaultRSIParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		if err := p.validate(); err != nil {
+			return nil, err
+		}
+		return NewRSIStrategy(p.Period, p.Overbought, p.Oversold), nil
+	})
+
+	RegisterStrategy(StrategyInfo{
+		Name:        "macd",
+		Description: "Buys when MACD is above its signal line and sells when it is below",
+		Params: []StrategyParam{
+			{Name: "macd_fast_period", Type: "int", Default: defaultMACDParams.Fast, Description: "fast EMA period"},
+			{Name: "macd_slow_period", Type: "int", Default: defaultMACDParams.Slow, Description: "slow EMA period, greater than the fast one"},
+			{Name: "macd_signal_period", Type: "int", Default: defaultMACDParams.Signal, Description: "signal line EMA period"},
+		},
+	}, func(params json.RawMessage) (Strategy, error) {
+		p := defaultMACDParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		if err := p.validate(); err != nil {
+			return nil, err
+		}
+		return NewMACDStrategy(p.Fast, p.Slow, p.Signal), nil
+	})
+
+	RegisterStrategy(StrategyInfo{
+		Name:        "bbands",
+		Description: "Buys below the lower Bollinger Band and sells above the upper band",
+		Params: []StrategyParam{
+			{Name: "bb_period", Type: "int", Default: defaultBBandsParams.Period, Description: "band window in ticks"},
+			{Name: "bb_multiplier", Type: "float", Default: defaultBBandsParams.Multiplier, Description: "standard deviations from the mean to each band"},
+		},
+	}, func(params json.RawMessage) (Strategy, error) {
+		p := defaultBBandsParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		if err := p.validate(); err != nil {
+			return nil, err
+		}
+		return NewBBandsStrategy(p.Period, p.Multiplier), nil
+	})
+
+	RegisterStrategy(StrategyInfo{
+		Name:        "vwap",
+		Description: "Buys when the close is vwap_band_pct below the rolling VWAP and sells when it is above; needs bars with volume",
+		Params: []StrategyParam{
+			{Name: "vwap_period", Type: "int", Default: defaultVWAPParams.Period, Description: "VWAP window in bars"},
+			{Name: "vwap_band_pct", Type: "float", Default: defaultVWAPParams.BandPct, Description: "% distance from the VWAP that triggers a signal"},
+		},
+	}, func(params json.RawMessage) (Strategy, error) {
+		p := defaultVWAPParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return NewVWAPStrategy(p.Period, p.BandPct)
+	})
+
+	RegisterStrategy(StrategyInfo{
+		Name:        "threshold",
+		Description: "Buys when the spread exceeds entry_threshold and covers round-trip fees, sells when it exceeds exit_threshold",
+		Params:      []StrategyParam{},
+	}, func(params json.RawMessage) (Strategy, error) {
+		if err := decodeParams(params, &struct{}{}); err != nil {
+			return nil, err
+		}
+		return NewThresholdStrategy(), nil
+	})
+
+	RegisterStrategy(StrategyInfo{
+		Name:        "composite",
+		Description: "Combines child strategies by vote; unanimous unless vote_mode says otherwise",
+		Params: append([]StrategyParam{
+			{Name: "strategies", Type: "array", Description: `child strategies, each {"name": ..., "params": {...}}`},
+		}, voteParams...),
+	}, func(params json.RawMessage) (Strategy, error) {
+		var p compositeParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		if len(p.Strategies) == 0 {
+			return nil, errors.New("strategies must list at least one child")
+		}
+		names := make([]string, 0, len(p.Strategies))
+		children := make([]Strategy, 0, len(p.Strategies))
+		for i, child := range p.Strategies {
+			s, err := BuildStrategy(child.Name, child.Params)
+			if err != nil {
+				return nil, fmt.Errorf("child %d: %w", i, err)
+			}
+			names = append(names, child.Name)
+			children = append(children, s)
+		}
+		return NewVotingStrategy(p.VoteRule, names, children...)
+	})
+
+	RegisterStrategy(StrategyInfo{
+		Name:        DefaultStrategy,
+		Description: "Combines sma, threshold, rsi, macd and bbands by vote on a fast timeframe and a slow one; signals when both agree",
+		Params: append([]StrategyParam{
+			{Name: "short_window", Type: "int", Default: defaultSMAParams.Short, Description: "short SMA window"},
+			{Name: "long_window", Type: "int", Default: defaultSMAParams.Long, Description: "long SMA window"},
+			{Name: "rsi_period", Type: "int", Default: defaultRSIParams.Period, Description: "RSI period"},
+			{Name: "rsi_overbought", Type: "float", Default: defaultRSIParams.Overbought, Description: "RSI sell level"},
+			{Name: "rsi_oversold", Type: "float", Default: defaultRSIParams.Oversold, Description: "RSI buy level"},
+			{Name: "macd_fast_period", Type: "int", Default: defaultMACDParams.Fast, Description: "fast EMA period"},
+			{Name: "macd_slow_period", Type: "int", Default: defaultMACDParams.Slow, Description: "slow EMA period"},
+			{Name: "macd_signal_period", Type: "int", Default: defaultMACDParams.Signal, Description: "signal line EMA period"},
+			{Name: "bb_period", Type: "int", Default: defaultBBandsParams.Period, Description: "Bollinger Band window"},
+			{Name: "bb_multiplier", Type: "float", Default: defaultBBandsParams.Multiplier, Description: "Bollinger Band width in standard deviations"},
+			{Name: "fast_timeframe", Type: "string", Description: `bar interval of the fast composite; "" is every tick`},
+			{Name: "slow_timeframe", Type: "string", Description: `bar interval of the slow composite, which then keeps the fast periods; "" doubles the periods on the fast data`},
+		}, voteParams...),
+	}, func(params json.RawMessage) (Strategy, error) {
+		sma, rsi, macd, bb := defaultSMAParams, defaultRSIParams, defaultMACDParams, defaultBBandsParams
+		var vote VoteRule
+		var tf timeframeParams
+		for _, p := range []interface{}{&sma, &rsi, &macd, &bb, &vote, &tf} {
+			if err := decodeParams(params, p); err != nil {
+				return nil, err
+			}
+		}
+		children := []string{"sma", "threshold", "rsi", "macd", "bbands"}
+		for _, err := range []error{sma.validate(), rsi.validate(), macd.validate(), bb.validate(), vote.validate(children), tf.validate()} {
+			if err != nil {
+				return nil, err
+			}
+		}
+		return NewMultiTimeframeStrategy(&config.Config{
+			ShortWindow:      sma.Short,
+			LongWindow:       sma.Long,
+			RSIPeriod:        rsi.Period,
+			RSIOverBought:    rsi.Overbought,
+			RSIOverSold:      rsi.Oversold,
+			MACDFastPeriod:   macd.Fast,
+			MACDSlowPeriod:   macd.Slow,
+			MACDSignalPeriod: macd.Signal,
+			BBPeriod:         bb.Period,
+			BBMultiplier:     bb.Multiplier,
+			VoteMode:         vote.Mode,
+			VoteMin:          vote.MinVotes,
+			VoteThreshold:    vote.Threshold,
+			VoteWeights:      vote.Weights,
+			VoteVeto:         vote.Veto,
+			FastTimeframe:    tf.Fast,
+			SlowTimeframe:    tf.Slow,
+		}), nil
+	})
+}
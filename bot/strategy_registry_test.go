@@ -0,0 +1,88 @@
+package bot
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBuildStrategyValidatesParams(t *testing.T) {
+	for _, tc := range []struct {
+		name, params string
+	}{
+		{"sma", `{"short_window": 10, "long_window": 5}`},
+		{"sma", `{"short_window": 0}`},
+		{"rsi", `{"rsi_oversold": 80}`},
+		{"macd", `{"macd_fast_period": 30}`},
+		{"bbands", `{"bb_multiplier": -1}`},
+		{"vwap", `{"vwap_period": -1}`},
+		{"multitimeframe", `{"rsi_period": -2}`},
+		{"composite", `{}`},
+		{"composite", `{"strategies": [{"name": "sma", "params": {"long_window": 1}}]}`},
+		{"sma", `[1, 2]`},
+		{"sma", `{"timeframe": "soon"}`},
+		{"multitimeframe", `{"fast_timeframe": "1h", "slow_timeframe": "5m"}`},
+		{"multitimeframe", `{"timeframe": "5m", "slow_timeframe": "1h"}`},
+	} {
+		if _, err := BuildStrategy(tc.name, json.RawMessage(tc.params)); err == nil {
+			t.Errorf("%s %s: expected an error", tc.name, tc.params)
+		}
+	}
+	if _, err := BuildStrategy("nope", nil); !errors.Is(err, ErrUnknownStrategy) {
+		t.Errorf("unknown name: got %v, want ErrUnknownStrategy", err)
+	}
+	if _, err := NewVWAPStrategy(0, 1); err == nil {
+		t.Error("Expected an error for a zero vwap period")
+	}
+}
+
+func TestBuildCompositeStrategy(t *testing.T) {
+	s, err := BuildStrategy("composite", json.RawMessage(`{"strategies": [
+		{"name": "sma", "params": {"short_window": 2, "long_window": 3}},
+		{"name": "threshold"}
+	]}`))
+	if err != nil {
+		t.Fatalf("BuildStrategy: %v", err)
+	}
+	comp, ok := s.(*CompositeStrategy)
+	if !ok || len(comp.strategies) != 2 {
+		t.Fatalf("got %#v, want a composite of two", s)
+	}
+	if sma, ok := comp.strategies[0].(*SMAStrategy); !ok || sma.ShortWindow != 2 || sma.LongWindow != 3 {
+		t.Errorf("first child = %#v, want sma 2/3", comp.strategies[0])
+	}
+
+	s, err = BuildStrategy("rsi", json.RawMessage(`{"timeframe": "4h"}`))
+	if tf, ok := s.(*TimeframeStrategy); err != nil || !ok || tf.Interval != 4*time.Hour {
+		t.Errorf("rsi on 4h bars: got %#v, %v", s, err)
+	}
+}
+
+func TestNewStrategyOverridesConfigParams(t *testing.T) {
+	s, err := NewStrategy(Config{
+		Strategy:       "sma",
+		ShortWindow:    3,
+		LongWindow:     8,
+		StrategyParams: json.RawMessage(`{"long_window": 12}`),
+	})
+	if err != nil {
+		t.Fatalf("NewStrategy: %v", err)
+	}
+	if sma := s.(*SMAStrategy); sma.ShortWindow != 3 || sma.LongWindow != 12 {
+		t.Errorf("got sma %d/%d, want 3/12", sma.ShortWindow, sma.LongWindow)
+	}
+	if _, err := NewStrategy(Config{}); err != nil {
+		t.Errorf("default strategy from an empty config: %v", err)
+	}
+
+	names := make(map[string]bool)
+	for _, info := range Strategies() {
+		names[info.Name] = true
+	}
+	for _, want := range []string{"sma", "rsi", "macd", "bbands", "threshold", "composite", DefaultStrategy} {
+		if !names[want] {
+			t.Errorf("Strategies() is missing %s", want)
+		}
+	}
+}
@@ -8,12 +8,13 @@ func NewThresholdStrategy() *ThresholdStrategy {
 	return &ThresholdStrategy{}
 }
 
-// Next returns buy/sell/none based on entry/exit thresholds in cfg.
+// Next returns buy/sell/none based on entry/exit thresholds in cfg. Entries
+// are skipped when the spread is no wider than the round-trip fees.
 func (s *ThresholdStrategy) Next(data MarketData, cfg Config) Signal {
 	bid := data.Bid
 	ask := data.Ask
-	// Entry
-	if cfg.EntryThreshold > 0 && ask > bid*(1+cfg.EntryThreshold) {
+	// Entry, only when the spread edge covers round-trip fees
+	if cfg.EntryThreshold > 0 && ask > bid*(1+cfg.EntryThreshold) && SpreadEdge(bid, ask) > cfg.RoundTripCost() {
 		return SignalBuy
 	}
 	// Exit
@@ -0,0 +1,147 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	luno "github.com/luno/luno-go"
+)
+
+// seedBars is how many bars of history a TimeframeStrategy is seeded with.
+const seedBars = 200
+
+// candleDurations are the candle lengths Luno serves, in seconds, longest first.
+var candleDurations = []int64{604800, 259200, 86400, 28800, 14400, 10800, 3600, 1800, 900, 300, 60}
+
+// Seeder is a strategy that can warm up on history before its first event.
+type Seeder interface {
+	Seed(ctx context.Context, client Client, cfg Config) error
+}
+
+// TimeframeStrategy subscribes Inner to bars of Interval resampled from the
+// events it is given, ticks or finer bars, so its indicators run on a real
+// higher timeframe. Inner sees each bar once, when the first event of the
+// next bar closes it, as a bar event quoted at the close with the current
+// spread. The signal is returned on that event only, or repeated until the
+// next bar closes when Hold is set, so a slow trend can be combined with
+// faster strategies.
+type TimeframeStrategy struct {
+	Inner    Strategy
+	Interval time.Duration
+	Hold     bool
+
+	mu   sync.Mutex
+	bars *Resampler
+	last Signal
+}
+
+// NewTimeframeStrategy constructs a TimeframeStrategy running inner on bars of interval.
+func NewTimeframeStrategy(interval time.Duration, inner Strategy, hold bool) *TimeframeStrategy {
+	return &TimeframeStrategy{Inner: inner, Interval: interval, Hold: hold, bars: NewResampler(interval)}
+}
+
+// Next treats data as a tick event.
+func (s *TimeframeStrategy) Next(data MarketData, cfg Config) Signal {
+	return s.OnEvent(TickEvent(data), cfg)
+}
+
+// OnEvent merges the event's bar into the open bar and runs Inner when a bar
+// closes.
+func (s *TimeframeStrategy) OnEvent(ev MarketEvent, cfg Config) Signal {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	in := ev.Bar
+	if in.Time.IsZero() {
+		in.Time = time.Now()
+	}
+	bar, closed := s.bars.AddBar(in)
+	if !closed {
+		if s.Hold {
+			return s.last
+		}
+		return SignalNone
+	}
+	half := 0.0
+	if ev.Bid > 0 && ev.Ask > ev.Bid {
+		half = (ev.Ask - ev.Bid) / 2
+	}
+	s.last = AdaptStrategy(s.Inner).OnEvent(BarEvent(bar, s.Interval, bar.Close-half, bar.Close+half), cfg)
+	return s.last
+}
+
+// Seed runs Inner over the last seedBars bars of cfg.Pair, resampled from
+// the longest candles that divide Interval or, when there are none, from
+// public trades, so its indicators are warm on the first live bar. The
+// newest bar stays open for live events to extend.
+func (s *TimeframeStrategy) Seed(ctx context.Context, client Client, cfg Config) error {
+	since := time.Now().Add(-seedBars * s.Interval)
+	var bars []Bar
+	if d := candleDuration(s.Interval); d > 0 {
+		resp, err := client.GetCandles(ctx, &luno.GetCandlesRequest{Pair: cfg.Pair, Duration: d, Since: luno.Time(since)})
+		if err == nil {
+			bars = ResampleBars(CandleBars(resp.Candles), s.Interval)
+		}
+	}
+	if len(bars) == 0 {
+		if oldest := time.Now().Add(-tradeHistoryWindow); since.Before(oldest) {
+			since = oldest
+		}
+		trades, err := tradesSince(ctx, client, cfg.Pair, since)
+		if err != nil {
+			return fmt.Errorf("seed %s: %w", cfg.Pair, err)
+		}
+		bars = ResampleTrades(trades, s.Interval)
+	}
+	s.seed(bars, cfg)
+	return nil
+}
+
+// seed passes every bar but the newest to Inner and opens the newest.
+func (s *TimeframeStrategy) seed(bars []Bar, cfg Config) {
+	if len(bars) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	inner := AdaptStrategy(s.Inner)
+	for _, b := range bars[:len(bars)-1] {
+		s.last = inner.OnEvent(BarEvent(b, s.Interval, b.Close, b.Close), cfg)
+	}
+	s.bars = NewResampler(s.Interval)
+	s.bars.AddBar(bars[len(bars)-1])
+}
+
+// seedAll seeds each of strats that is a Seeder, returning the first error.
+func seedAll(ctx context.Context, client Client, cfg Config, strats ...Strategy) error {
+	var firstErr error
+	for _, strat := range strats {
+		if sd, ok := strat.(Seeder); ok {
+			if err := sd.Seed(ctx, client, cfg); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// candleDuration returns the longest candle length, in seconds, that
+// divides interval, or 0 when interval is shorter than a minute or not a
+// whole number of them.
+func candleDuration(interval time.Duration) int64 {
+	for _, d := range candleDurations {
+		if interval%(time.Duration(d)*time.Second) == 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// Votes delegates to Inner when it is a Voter.
+func (s *TimeframeStrategy) Votes() []ChildVote {
+	if v, ok := s.Inner.(Voter); ok {
+		return v.Votes()
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package bot
+
+import "github.com/luno/luno-bot/bot/indicators"
+
+// VWAPStrategy trades reversion to the rolling VWAP of the bars it is given:
+// it buys when the close is BandPct below the VWAP and sells when it is
+// BandPct above. It needs bars with volume: candle backtests, a timeframe
+// fed with trades, or engine ticks from a VolumeSource such as
+// StreamingMarketData.
+type VWAPStrategy struct {
+	Period  int
+	BandPct float64
+	vwap    *indicators.VWAP
+}
+
+// NewVWAPStrategy constructs a VWAPStrategy over period bars.
+func NewVWAPStrategy(period int, bandPct float64) (*VWAPStrategy, error) {
+	if err := (vwapParams{Period: period, BandPct: bandPct}).validate(); err != nil {
+		return nil, err
+	}
+	return &VWAPStrategy{Period: period, BandPct: bandPct, vwap: indicators.NewVWAP(period)}, nil
+}
+
+// Next treats data as a tick event.
+func (v *VWAPStrategy) Next(data MarketData, cfg Config) Signal {
+	return v.OnEvent(TickEvent(data), cfg)
+}
+
+// OnEvent adds the event's bar to the VWAP and compares the close to the bands.
+func (v *VWAPStrategy) OnEvent(ev MarketEvent, cfg Config) Signal {
+	vwap := v.vwap.Update(ev.High, ev.Low, ev.Close, ev.Volume)
+	if !v.vwap.Ready() {
+		return SignalNone
+	}
+	if ev.Close < vwap*(1-v.BandPct/100) {
+		return SignalBuy
+	}
+	if ev.Close > vwap*(1+v.BandPct/100) {
+		return SignalSell
+	}
+	return SignalNone
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/luno/luno-bot/bot"
+	"github.com/luno/luno-bot/storage"
+)
+
+func main() {
+	apiKeyID := flag.String("api_key_id", os.Getenv("API_KEY_ID"), "Luno API key ID")
+	apiKeySecret := flag.String("api_key_secret", os.Getenv("API_KEY_SECRET"), "Luno API key secret")
+	dbPath := flag.String("db", "luno-bot.db", "SQLite database holding the cache")
+	pairs := flag.String("pairs", "", "Comma-separated market pairs, e.g. XBTZAR,ETHZAR")
+	duration := flag.Int64("duration", 60, "Candle duration in seconds")
+	fromFlag := flag.String("from", "", "Start of range (RFC3339 or YYYY-MM-DD); defaults to --days ago")
+	toFlag := flag.String("to", "", "End of range (RFC3339 or YYYY-MM-DD); defaults to now")
+	days := flag.Int("days", 7, "Days to backfill when --from is not set")
+	trades := flag.Bool("trades", true, "Also cache public trades (Luno serves the last 24h)")
+	watch := flag.Duration("watch", 0, "Keep the cache current, syncing at this interval (e.g. 1m)")
+	flag.Parse()
+
+	if *apiKeyID == "" || *apiKeySecret == "" || *pairs == "" {
+		fmt.Println("Usage: backfill --api_key_id <id> --api_key_secret <secret> --pairs <pair,...> [--db <path>] [--duration <sec>] [--from <time>] [--to <time>] [--days <n>] [--trades] [--watch <interval>]")
+		return
+	}
+	to := time.Now()
+	if *toFlag != "" {
+		t, err := parseTime(*toFlag)
+		if err != nil {
+			fmt.Println("Invalid --to:", err)
+			return
+		}
+		to = t
+	}
+	from := to.AddDate(0, 0, -*days)
+	if *fromFlag != "" {
+		t, err := parseTime(*fromFlag)
+		if err != nil {
+			fmt.Println("Invalid --from:", err)
+			return
+		}
+		from = t
+	}
+
+	lc := bot.NewLunoClient()
+	if err := lc.SetAuth(*apiKeyID, *apiKeySecret); err != nil {
+		fmt.Println("Error setting auth:", err)
+		return
+	}
+	store, err := storage.NewSQLiteStore(*dbPath)
+	if err != nil {
+		fmt.Println("Error opening SQLite DB:", err)
+		return
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	bf := bot.NewBackfiller(lc, store)
+	list := strings.Split(*pairs, ",")
+	for _, pair := range list {
+		gaps, err := store.CandleGaps(pair, *duration, from, to)
+		if err != nil {
+			fmt.Printf("%s: error reading coverage: %v\n", pair, err)
+			continue
+		}
+		n, err := bf.BackfillCandles(ctx, pair, *duration, from, to)
+		if err != nil {
+			fmt.Printf("%s: backfill stopped after %d candles: %v\n", pair, n, err)
+			continue
+		}
+		fmt.Printf("%s: filled %d gaps with %d candles (%ds) from %s to %s\n", pair, len(gaps), n, *duration, from.Format(time.RFC3339), to.Format(time.RFC3339))
+		if *trades {
+			n, err := bf.BackfillTrades(ctx, pair)
+			if err != nil {
+				fmt.Printf("%s: trade backfill stopped after %d trades: %v\n", pair, n, err)
+				continue
+			}
+			fmt.Printf("%s: fetched %d trades\n", pair, n)
+		}
+	}
+
+	if *watch > 0 {
+		fmt.Printf("Keeping %s current every %s\n", *pairs, *watch)
+		bf.Run(ctx, list, *duration, time.Since(from), *watch)
+	}
+}
+
+// parseTime accepts RFC3339 timestamps or plain dates.
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", s)
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/luno/luno-bot/bot"
+	"github.com/luno/luno-bot/storage"
+)
+
+// EquityLister lists saved equity snapshots; storage.SQLiteStore implements it.
+type EquityLister interface {
+	ListEquity(executor string, since time.Time) ([]storage.EquitySnapshot, error)
+}
+
+// RegisterEquityRoutes serves the equity curve saved by portfolios and their latest marks.
+func RegisterEquityRoutes(r *gin.Engine, store EquityLister, portfolios ...*bot.Portfolio) {
+	// Equity series, optionally for one executor (?executor=live) and pair (?pair=XBTZAR) over the last ?minutes
+	r.GET("/equity", func(c *gin.Context) {
+		if store == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "equity store not configured"})
+			return
+		}
+		var since time.Time
+		if m := c.Query("minutes"); m != "" {
+			minutes, err := strconv.Atoi(m)
+			if err != nil || minutes <= 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "minutes must be a positive integer"})
+				return
+			}
+			since = time.Now().Add(-time.Duration(minutes) * time.Minute)
+		}
+		snaps, err := store.ListEquity(c.Query("executor"), since)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		out := []storage.EquitySnapshot{}
+		for _, snap := range snaps {
+			if pair := c.Query("pair"); pair == "" || snap.Pair == pair {
+				out = append(out, snap)
+			}
+		}
+		c.JSON(http.StatusOK, out)
+	})
+
+	// Latest mark of each portfolio, including ticks between saved snapshots
+	r.GET("/equity/current", func(c *gin.Context) {
+		current := []storage.EquitySnapshot{}
+		for _, p := range portfolios {
+			if snap := p.Snapshot(); !snap.Time.IsZero() {
+				current = append(current, snap)
+			}
+		}
+		c.JSON(http.StatusOK, current)
+	})
+
+	// Account totals per executor chain, summing the latest mark of every market
+	r.GET("/equity/total", func(c *gin.Context) {
+		type total struct {
+			Equity     float64 `json:"equity"`
+			Realized   float64 `json:"realized"`
+			Unrealized float64 `json:"unrealized"`
+			Markets    int     `json:"markets"`
+		}
+		totals := map[string]*total{}
+		for _, p := range portfolios {
+			snap := p.Snapshot()
+			if snap.Time.IsZero() {
+				continue
+			}
+			t := totals[snap.Executor]
+			if t == nil {
+				t = &total{}
+				totals[snap.Executor] = t
+			}
+			t.Equity += snap.Equity
+			t.Realized += snap.Realized
+			t.Unrealized += snap.Unrealized
+			t.Markets++
+		}
+		c.JSON(http.StatusOK, totals)
+	})
+}
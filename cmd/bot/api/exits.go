@@ -0,0 +1,33 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/luno/luno-bot/storage"
+)
+
+// ExitLister lists recorded exits; storage.SQLiteStore implements it.
+type ExitLister interface {
+	ListExits() ([]storage.ExitRecord, error)
+}
+
+// RegisterExitRoutes exposes positions closed by the exit manager.
+func RegisterExitRoutes(r *gin.Engine, store ExitLister) {
+	// Exits with their reasons, oldest first
+	r.GET("/exits", func(c *gin.Context) {
+		if store == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "exit store not configured"})
+			return
+		}
+		exits, err := store.ListExits()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if exits == nil {
+			exits = []storage.ExitRecord{}
+		}
+		c.JSON(http.StatusOK, exits)
+	})
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/luno/luno-bot/bot"
+	"github.com/luno/luno-bot/storage"
+)
+
+// RegisterJobRoutes exposes background TWAP/VWAP execution jobs from runners.
+func RegisterJobRoutes(r *gin.Engine, runners ...*bot.JobRunner) {
+	// All jobs with slice progress, newest first
+	r.GET("/jobs", func(c *gin.Context) {
+		jobs := []storage.JobRecord{}
+		for _, jr := range runners {
+			jobs = append(jobs, jr.Jobs()...)
+		}
+		sort.Slice(jobs, func(a, b int) bool { return jobs[a].ID > jobs[b].ID })
+		c.JSON(http.StatusOK, jobs)
+	})
+
+	// One job with planned and filled volume per slice
+	r.GET("/jobs/:id", func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid job id"})
+			return
+		}
+		for _, jr := range runners {
+			if job, ok := jr.Job(id); ok {
+				c.JSON(http.StatusOK, job)
+				return
+			}
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": bot.ErrJobNotFound.Error()})
+	})
+
+	// Pause, resume or cancel a job between slices
+	actions := map[string]func(*bot.JobRunner, int64) (storage.JobRecord, error){
+		"pause":  (*bot.JobRunner).Pause,
+		"resume": (*bot.JobRunner).Resume,
+		"cancel": (*bot.JobRunner).Cancel,
+	}
+	for name, action := range actions {
+		action := action
+		r.POST("/jobs/:id/"+name, func(c *gin.Context) {
+			id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid job id"})
+				return
+			}
+			for _, jr := range runners {
+				if _, ok := jr.Job(id); !ok {
+					continue
+				}
+				job, err := action(jr, id)
+				if errors.Is(err, bot.ErrJobState) {
+					c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "job": job})
+					return
+				}
+				if err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+					return
+				}
+				c.JSON(http.StatusOK, job)
+				return
+			}
+			c.JSON(http.StatusNotFound, gin.H{"error": bot.ErrJobNotFound.Error()})
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/luno/luno-bot/bot"
+)
+
+// RegisterKillSwitchRoutes exposes the global trading halt.
+func RegisterKillSwitchRoutes(r *gin.Engine, ks *bot.KillSwitch) {
+	// Current halt state
+	r.GET("/killswitch", func(c *gin.Context) {
+		if ks == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "kill switch not configured"})
+			return
+		}
+		c.JSON(http.StatusOK, ks.State())
+	})
+
+	// Halt all trading and cancel open orders
+	r.POST("/killswitch", func(c *gin.Context) {
+		if ks == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "kill switch not configured"})
+			return
+		}
+		var req struct {
+			Reason string `json:"reason"`
+		}
+		// the body is optional, but one that is sent must parse
+		if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if req.Reason == "" {
+			req.Reason = "manual"
+		}
+		if err := ks.Trip(context.Background(), req.Reason); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "state": ks.State()})
+			return
+		}
+		c.JSON(http.StatusOK, ks.State())
+	})
+
+	// Explicitly resume trading after a halt
+	r.POST("/killswitch/reset", func(c *gin.Context) {
+		if ks == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "kill switch not configured"})
+			return
+		}
+		if err := ks.Reset(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, ks.State())
+	})
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/luno/luno-bot/bot"
+	luno "github.com/luno/luno-go"
+)
+
+// RegisterPaperRoutes exposes the paper trading account in the same format
+// as the live balances, and both accounts together.
+func RegisterPaperRoutes(r *gin.Engine, client bot.Client, ledger *bot.PaperLedger) {
+	// Paper account balances, shaped like GET /balances
+	r.GET("/paper/balances", func(c *gin.Context) {
+		if ledger == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "paper account not configured"})
+			return
+		}
+		resp, _ := ledger.GetBalances(context.Background(), &luno.GetBalancesRequest{})
+		c.JSON(http.StatusOK, resp.Balance)
+	})
+
+	// Live and paper balances side by side
+	r.GET("/accounts", func(c *gin.Context) {
+		out := gin.H{"live": nil, "paper": nil}
+		if ledger != nil {
+			resp, _ := ledger.GetBalances(context.Background(), &luno.GetBalancesRequest{})
+			out["paper"] = resp.Balance
+		}
+		resp, err := client.GetBalances(context.Background(), &luno.GetBalancesRequest{})
+		if err != nil {
+			out["live_error"] = err.Error()
+		} else {
+			out["live"] = resp.Balance
+		}
+		c.JSON(http.StatusOK, out)
+	})
+}
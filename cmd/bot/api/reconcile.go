@@ -0,0 +1,72 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/luno/luno-bot/bot"
+	"github.com/luno/luno-bot/config"
+)
+
+// RegisterReconcileRoutes exposes each pair's live reconciliation, keyed by
+// pair. Routes take ?pair= and default to the configured pair.
+func RegisterReconcileRoutes(r *gin.Engine, store config.StateStore, recons map[string]*bot.ReconcilingExecutor) {
+	// pick returns the requested pair's reconciler and its config, or writes an error
+	pick := func(c *gin.Context) (*bot.ReconcilingExecutor, *config.Config, bool) {
+		cfgRaw, err := store.LoadConfig()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return nil, nil, false
+		}
+		pair := c.DefaultQuery("pair", cfgRaw.Pair)
+		recon := recons[pair]
+		if recon == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "reconciliation not configured for " + pair})
+			return nil, nil, false
+		}
+		return recon, cfgRaw.ForPair(pair), true
+	}
+
+	// Last reconciliation report
+	r.GET("/reconcile", func(c *gin.Context) {
+		recon, _, ok := pick(c)
+		if !ok {
+			return
+		}
+		rep, ok := recon.Report()
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not reconciled yet"})
+			return
+		}
+		c.JSON(http.StatusOK, rep)
+	})
+
+	// Re-run reconciliation against the exchange
+	r.POST("/reconcile", func(c *gin.Context) {
+		recon, cfg, ok := pick(c)
+		if !ok {
+			return
+		}
+		rep, err := recon.Reconcile(context.Background(), bot.ConfigFromStore(cfg))
+		if err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, rep)
+	})
+
+	// Accept the exchange state and allow trading despite mismatches
+	r.POST("/reconcile/ack", func(c *gin.Context) {
+		recon, _, ok := pick(c)
+		if !ok {
+			return
+		}
+		rep, err := recon.Acknowledge()
+		if err != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, rep)
+	})
+}
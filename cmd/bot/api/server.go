@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -15,15 +16,17 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/luno/luno-bot/bot"
+	"github.com/luno/luno-bot/bot/backtest"
+	"github.com/luno/luno-bot/bot/indicators"
 	"github.com/luno/luno-bot/config"
 	luno "github.com/luno/luno-go"
 )
 
-// Metrics
+// Metrics, labelled by pair
 var (
-	simulateCounter    = prometheus.NewCounter(prometheus.CounterOpts{Name: "simulation_requests_total", Help: "Total simulation requests"})
-	simulationPnLGauge = prometheus.NewGauge(prometheus.GaugeOpts{Name: "simulation_total_pnl", Help: "Latest simulation total PnL"})
-	liveExecCounter    = prometheus.NewCounter(prometheus.CounterOpts{Name: "live_execute_requests_total", Help: "Total live execution requests"})
+	simulateCounter    = prometheus.NewCounterVec(prometheus.CounterOpts{Name: "simulation_requests_total", Help: "Total simulation requests"}, []string{"pair"})
+	simulationPnLGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "simulation_total_pnl", Help: "Latest simulation total PnL"}, []string{"pair"})
+	liveExecCounter    = prometheus.NewCounterVec(prometheus.CounterOpts{Name: "live_execute_requests_total", Help: "Total live execution requests"}, []string{"pair"})
 )
 
 // SweepRequest defines parameters for market scanning.
@@ -98,83 +101,127 @@ var logsBuffer []string
 var scanCountMu sync.Mutex
 var scanConsecCount = make(map[string]int)
 
-// computeRSI calculates the relative strength index over a period
+// computeRSI returns Wilder's RSI of prices, or 50 when there are too few prices
 func computeRSI(prices []float64, period int) float64 {
-  if len(prices) < period+1 {
-    return 50
+  rsi := indicators.NewRSI(period)
+  for _, p := range prices {
+    rsi.Update(p)
   }
-  gains, losses := 0.0, 0.0
-  for i := 1; i < len(prices); i++ {
-    delta := prices[i] - prices[i-1]
-    if delta > 0 {
-      gains += delta
-    } else {
-      losses -= delta
-    }
-  }
-  if losses == 0 {
-    return 100
-  }
-  rs := gains / losses
-  return 100 - (100 / (1 + rs))
+  return rsi.Value()
 }
 
 // computeStdDev calculates the standard deviation of price series
 func computeStdDev(vals []float64) float64 {
-  n := float64(len(vals))
-  if n == 0 {
+  if len(vals) == 0 {
     return 0
   }
-  sum := 0.0
+  dev := indicators.NewStdDev(len(vals))
   for _, v := range vals {
-    sum += v
+    dev.Update(v)
   }
-  mean := sum / n
-  var sdSum float64
-  for _, v := range vals {
-    diff := v - mean
-    sdSum += diff * diff
-  }
-  return math.Sqrt(sdSum / n)
+  return dev.Value()
 }
 
-// computeEMA calculates exponential moving average series
-func computeEMA(prices []float64, period int) []float64 {
-  k := 2.0 / float64(period+1)
-  ema := make([]float64, len(prices))
-  ema[0] = prices[0]
-  for i := 1; i < len(prices); i++ {
-    ema[i] = prices[i]*k + ema[i-1]*(1-k)
-  }
-  return ema
+// simulatedFor returns the simulated account behind pair's chain in exec:
+// exec itself, a Portfolio over it, or either registered in a PairExecutor.
+func simulatedFor(exec bot.Executor, pair string) *bot.SimulatedExecutor {
+	if pe, ok := exec.(*bot.PairExecutor); ok {
+		exec, _ = pe.Get(pair)
+	}
+	if p, ok := exec.(*bot.Portfolio); ok {
+		sim, _ := p.Positions.(*bot.SimulatedExecutor)
+		return sim
+	}
+	sim, _ := exec.(*bot.SimulatedExecutor)
+	return sim
 }
 
-// computeMACD returns MACD line, signal line, and histogram
-func computeMACD(prices []float64, fastPeriod, slowPeriod, signalPeriod int) (macd, signal, hist []float64) {
-  emaFast := computeEMA(prices, fastPeriod)
-  emaSlow := computeEMA(prices, slowPeriod)
-  macd = make([]float64, len(prices))
-  for i := range prices {
-    macd[i] = emaFast[i] - emaSlow[i]
+// scanCosts looks up round-trip taker fees for one scan. Each pair is looked
+// up once, and once a lookup fails the rest of the scan uses fallback rather
+// than calling GetFeeInfo for every ticker.
+type scanCosts struct {
+	fees     *bot.Fees
+	fallback bot.FeeRates
+	failed   bool
+	costs    map[string]float64
+}
+
+// newScanCosts starts the fee lookups for a scan.
+func newScanCosts(fees *bot.Fees, fallback bot.FeeRates) *scanCosts {
+	return &scanCosts{fees: fees, fallback: fallback, costs: make(map[string]float64)}
+}
+
+// roundTrip returns the round-trip cost for pair, using the fallback when the
+// account's fees cannot be fetched.
+func (s *scanCosts) roundTrip(pair string) float64 {
+	if cost, ok := s.costs[pair]; ok {
+		return cost
+	}
+	cost := s.fallback.RoundTrip()
+	if !s.failed {
+		if f, err := s.fees.Rates(context.Background(), pair); err == nil {
+			cost = f.RoundTrip()
+		} else {
+			s.failed = true
+		}
+	}
+	s.costs[pair] = cost
+	return cost
+}
+
+// computeSMA returns the average of the last period prices
+func computeSMA(prices []float64, period int) float64 {
+  sma := indicators.NewSMA(period)
+  for _, p := range prices {
+    sma.Update(p)
   }
-  signal = computeEMA(macd, signalPeriod)
-  hist = make([]float64, len(prices))
-  for i := range prices {
-    hist[i] = macd[i] - signal[i]
+  return sma.Value()
+}
+
+// computeMACD returns the latest MACD histogram; ok is false when prices are too few
+func computeMACD(prices []float64, fastPeriod, slowPeriod, signalPeriod int) (hist float64, ok bool) {
+  macd := indicators.NewMACD(fastPeriod, slowPeriod, signalPeriod)
+  for _, p := range prices {
+    macd.Update(p)
   }
-  return
+  return macd.Histogram(), macd.Ready()
+}
+
+// autoScanPairs returns the pairs for one auto-scan pass. Tickers are only
+// fetched when no pairs are given or the volume filter needs them.
+func autoScanPairs(client bot.Client, req AutoScanRequest) ([]string, error) {
+	if len(req.Pairs) > 0 && req.MinVolume <= 0 {
+		return req.Pairs, nil
+	}
+	resp, err := client.GetTickers(context.Background(), &luno.GetTickersRequest{Pair: req.Pairs})
+	if err != nil {
+		return nil, err
+	}
+	var pairs []string
+	for _, t := range resp.Tickers {
+		if req.MinVolume > 0 && t.Rolling24HourVolume.Float64() < req.MinVolume {
+			continue
+		}
+		pairs = append(pairs, t.Pair)
+	}
+	return pairs, nil
 }
 
 // SetupRouter initializes REST endpoints for bot management.
-func SetupRouter(store config.StateStore, client bot.Client, strat bot.Strategy, simExec, liveExec bot.Executor) *gin.Engine {
+func SetupRouter(store config.StateStore, client bot.Client, strat bot.Strategy, simExec, liveExec bot.Executor, engine *bot.Engine, market bot.MarketDataSource) *gin.Engine {
+	if market == nil {
+		market = bot.NewRESTMarketData(client)
+	}
 	// Register metrics safely (ignore already registered)
-	for _, c := range []prometheus.Collector{simulateCounter, simulationPnLGauge, liveExecCounter} {
+	for _, c := range []prometheus.Collector{simulateCounter, simulationPnLGauge, liveExecCounter, bot.RiskRejections, bot.PositionExits, bot.EngineTicks} {
 		if err := prometheus.Register(c); err != nil {
 			if _, ok := err.(prometheus.AlreadyRegisteredError); !ok {
 				panic(err)
 			}
 		}
 	}
+	// Account fees per pair; scans skip spreads that do not cover them
+	fees := bot.NewFees(client)
 	r := gin.Default()
 
 	// Log capture middleware
@@ -233,11 +280,45 @@ func SetupRouter(store config.StateStore, client bot.Client, strat bot.Strategy,
 		c.JSON(http.StatusOK, newCfg)
 	})
 
-	// Bot status
+	// Bot status reported by the trading engine
 	r.GET("/status", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "running"})
+		if engine == nil {
+			c.JSON(http.StatusOK, gin.H{"status": bot.EngineStopped})
+			return
+		}
+		st := engine.Status()
+		c.JSON(http.StatusOK, gin.H{
+			"status":      st.State,
+			"pairs":       st.Pairs,
+			"interval":    st.Interval,
+			"ticks":       st.Ticks,
+			"last_tick":   st.LastTick,
+			"last_pair":   st.LastPair,
+			"last_signal": st.LastSignal,
+			"last_error":  st.LastError,
+			"markets":     st.Markets,
+		})
 	})
 
+	// Trading engine lifecycle
+	engineAction := func(action func() error) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			if engine == nil {
+				c.JSON(http.StatusServiceUnavailable, gin.H{"error": "engine not configured"})
+				return
+			}
+			if err := action(); err != nil {
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, engine.Status())
+		}
+	}
+	r.POST("/engine/start", engineAction(func() error { return engine.Start() }))
+	r.POST("/engine/pause", engineAction(func() error { return engine.Pause() }))
+	r.POST("/engine/resume", engineAction(func() error { return engine.Resume() }))
+	r.POST("/engine/stop", engineAction(func() error { return engine.Stop() }))
+
 	// Recent API logs
 	r.GET("/logs", func(c *gin.Context) {
 		logsMu.Lock()
@@ -259,6 +340,11 @@ func SetupRouter(store config.StateStore, client bot.Client, strat bot.Strategy,
 		c.JSON(http.StatusOK, pairs)
 	})
 
+	// Registered strategies and their parameters
+	r.GET("/strategies", func(c *gin.Context) {
+		c.JSON(http.StatusOK, bot.Strategies())
+	})
+
 	// Get account balances
 	r.GET("/balances", func(c *gin.Context) {
 		resp, err := client.GetBalances(context.Background(), &luno.GetBalancesRequest{})
@@ -317,6 +403,7 @@ func SetupRouter(store config.StateStore, client bot.Client, strat bot.Strategy,
 			return
 		}
 		var results []SweepResult
+		costs := newScanCosts(fees, bot.FeeRates{Maker: cfg.MakerFee, Taker: cfg.TakerFee})
 		scanCountMu.Lock()
 		defer scanCountMu.Unlock()
 		for _, t := range resp.Tickers {
@@ -358,7 +445,8 @@ func SetupRouter(store config.StateStore, client bot.Client, strat bot.Strategy,
 			bid := t.Bid.Float64()
 			ask := t.Ask.Float64()
 			// Update consecutive entry threshold hits
-			hit := req.EntryThreshold > 0 && ask > bid*(1+req.EntryThreshold)
+			cost := costs.roundTrip(t.Pair)
+			hit := req.EntryThreshold > 0 && ask > bid*(1+req.EntryThreshold) && bot.SpreadEdge(bid, ask) > cost
 			if hit {
 				scanConsecCount[t.Pair]++
 			} else {
@@ -403,18 +491,8 @@ func SetupRouter(store config.StateStore, client bot.Client, strat bot.Strategy,
 						for i, cnd := range maRes.Candles {
 							closesMA[i] = cnd.Close.Float64()
 						}
-						totalShort, totalLong := 0.0, 0.0
-						startShort := len(closesMA) - cfg.ShortWindow
-						if startShort < 0 { startShort = 0 }
-						startLong := len(closesMA) - cfg.LongWindow
-						for i := startShort; i < len(closesMA); i++ {
-							totalShort += closesMA[i]
-						}
-						for i := startLong; i < len(closesMA); i++ {
-							totalLong += closesMA[i]
-						}
-						smaShort := totalShort / float64(cfg.ShortWindow)
-						smaLong := totalLong / float64(cfg.LongWindow)
+						smaShort := computeSMA(closesMA, cfg.ShortWindow)
+						smaLong := computeSMA(closesMA, cfg.LongWindow)
 						if smaShort <= smaLong {
 							sig = "hold"
 						}
@@ -423,8 +501,8 @@ func SetupRouter(store config.StateStore, client bot.Client, strat bot.Strategy,
 				// MACD momentum filter
 				if sig == "buy" && cfg.MACDFastPeriod > 0 && cfg.MACDSlowPeriod > 0 && cfg.MACDSignalPeriod > 0 {
 					// fetch sufficient candles
-					levels := cfg.MACDSlowPeriod
-					if cfg.MACDSignalPeriod > levels { levels = cfg.MACDSignalPeriod }
+					// slow EMA seed plus signal EMA seed
+					levels := cfg.MACDSlowPeriod + cfg.MACDSignalPeriod
 					sinceMACD := time.Now().Add(-time.Duration(levels+1) * time.Minute)
 					macdRes, errM := client.GetCandles(context.Background(), &luno.GetCandlesRequest{Pair: t.Pair, Duration: 60, Since: luno.Time(sinceMACD)})
 					if errM == nil && len(macdRes.Candles) >= levels+1 {
@@ -434,8 +512,7 @@ func SetupRouter(store config.StateStore, client bot.Client, strat bot.Strategy,
 						for i := start; i < len(macdRes.Candles); i++ {
 							closesM[i-start] = macdRes.Candles[i].Close.Float64()
 						}
-						_, _, hist := computeMACD(closesM, cfg.MACDFastPeriod, cfg.MACDSlowPeriod, cfg.MACDSignalPeriod)
-						if hist[len(hist)-1] <= 0 {
+						if hist, ok := computeMACD(closesM, cfg.MACDFastPeriod, cfg.MACDSlowPeriod, cfg.MACDSignalPeriod); ok && hist <= 0 {
 							sig = "hold"
 						}
 					}
@@ -551,14 +628,14 @@ func SetupRouter(store config.StateStore, client bot.Client, strat bot.Strategy,
 				bid := t.Bid.Float64()
 				ask := t.Ask.Float64()
 				potential := (ask - bid) / bid * 100
-				ob, errOb := client.GetOrderBook(context.Background(), &luno.GetOrderBookRequest{Pair: t.Pair})
+				bids, asks, errOb := market.OrderBook(context.Background(), t.Pair)
 				topBidVol, topAskVol := 0.0, 0.0
 				if errOb == nil {
-					if len(ob.Bids) > 0 {
-						topBidVol = ob.Bids[0].Volume.Float64()
+					if len(bids) > 0 {
+						topBidVol = bids[0].Volume.Float64()
 					}
-					if len(ob.Asks) > 0 {
-						topAskVol = ob.Asks[0].Volume.Float64()
+					if len(asks) > 0 {
+						topAskVol = asks[0].Volume.Float64()
 					}
 				}
 				liquidity := topBidVol + topAskVol
@@ -603,18 +680,19 @@ func SetupRouter(store config.StateStore, client bot.Client, strat bot.Strategy,
 				case <-ctx2.Done():
 					return
 				case <-ticker.C:
-					resp, err := client.GetTickers(context.Background(), &luno.GetTickersRequest{Pair: req.Pairs})
+					pairs, err := autoScanPairs(client, req)
 					if err != nil {
 						continue
 					}
-					for _, t := range resp.Tickers {
-						vol := t.Rolling24HourVolume.Float64()
-						if req.MinVolume > 0 && vol < req.MinVolume {
+					costs := newScanCosts(fees, bot.FeeRates{})
+					for _, pair := range pairs {
+						md, err := market.MarketData(context.Background(), pair)
+						if err != nil {
 							continue
 						}
-						bid, ask := t.Bid.Float64(), t.Ask.Float64()
+						bid, ask := md.Bid, md.Ask
 						signal := "hold"
-						if req.EntryThreshold > 0 && ask > bid*(1+req.EntryThreshold) {
+						if req.EntryThreshold > 0 && ask > bid*(1+req.EntryThreshold) && bot.SpreadEdge(bid, ask) > costs.roundTrip(pair) {
 							signal = "buy"
 						}
 						if signal == "hold" && req.ExitThreshold > 0 && bid < ask*(1-req.ExitThreshold) {
@@ -626,7 +704,7 @@ func SetupRouter(store config.StateStore, client bot.Client, strat bot.Strategy,
 							if err == nil {
 								// build bot.Config from store Config
 								botCfg := bot.Config{
-									Pair:             t.Pair,
+									Pair:             pair,
 									EntryThreshold:   cfgRaw.EntryThreshold,
 									ExitThreshold:    cfgRaw.ExitThreshold,
 									StakeSize:        cfgRaw.StakeSize,
@@ -648,8 +726,8 @@ func SetupRouter(store config.StateStore, client bot.Client, strat bot.Strategy,
 								default:
 									sigConst = bot.SignalNone
 								}
-								_ = liveExec.Execute(context.Background(), sigConst, bot.MarketData{Bid: bid, Ask: ask, Timestamp: time.Now()}, botCfg)
-								liveExecCounter.Inc()
+								_ = liveExec.Execute(context.Background(), sigConst, md, botCfg)
+								liveExecCounter.WithLabelValues(pair).Inc()
 							}
 						}
 					}
@@ -698,153 +776,154 @@ func SetupRouter(store config.StateStore, client bot.Client, strat bot.Strategy,
 			Short        int     `json:"short"`
 			Long         int     `json:"long"`
 			FeeRate      float64 `json:"fee_rate"`
+			// optional registered strategy and its params; defaults to sma over short/long
+			Strategy string          `json:"strategy"`
+			Params   json.RawMessage `json:"params"`
+			// optional fixed range served from the candle cache, for repeatable runs
+			From     time.Time `json:"from"`
+			To       time.Time `json:"to"`
+			Duration int64     `json:"duration"`
+			// optional fill model; defaults to mid fills at fee_rate
+			FillModel     string  `json:"fill_model"`
+			SpreadBps     float64 `json:"spread_bps"`
+			MakerFee      float64 `json:"maker_fee"`
+			TakerFee      float64 `json:"taker_fee"`
+			LatencyMs     int     `json:"latency_ms"`
+			Participation float64 `json:"participation"`
+			LevelVolume   float64 `json:"level_volume"`
+			LevelBps      float64 `json:"level_bps"`
+			// optional stake, limit, equity and sizer; default to the pair's config
+			StakeSize     *float64 `json:"stake_size"`
+			PositionLimit *float64 `json:"position_limit"`
+			InitialEquity *float64 `json:"initial_equity"`
+			Sizer         *string  `json:"position_sizer_type"`
 		}
 		if err := c.ShouldBindJSON(&req); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-		since := time.Now().Add(-time.Duration(req.SinceMinutes) * time.Minute)
-		candlesRes, err := client.GetCandles(context.Background(), &luno.GetCandlesRequest{
-			Pair:     req.Pair,
-			Duration: 60,
-			Since:    luno.Time(since),
+		if req.Duration == 0 {
+			req.Duration = 60
+		}
+		if req.Strategy == "" {
+			req.Strategy = "sma"
+		}
+		strategy, err := bot.NewStrategy(bot.Config{
+			Strategy:       req.Strategy,
+			StrategyParams: req.Params,
+			ShortWindow:    req.Short,
+			LongWindow:     req.Long,
 		})
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-		n := len(candlesRes.Candles)
-		closes := make([]float64, n)
-		times := make([]time.Time, n)
-		for i, cnd := range candlesRes.Candles {
-			closes[i] = cnd.Close.Float64()
-			times[i] = time.Time(cnd.Timestamp)
-		}
-		strat := bot.NewSMAStrategy(req.Short, req.Long)
-		var cfg bot.Config
-		cfg.EntryThreshold = 0
-		cfg.ExitThreshold = 0
-		cfg.StakeSize = 1
-		inPos := false
-		entry := 0.0
-		trades, wins, losses := 0, 0, 0
-		pnlTotal := 0.0
-		pnlHistory := make([]gin.H, 0, n)
-		drawdownHistory := make([]gin.H, 0, n)
-		var profits []float64
-		var peak, maxDD float64
-		for i := 0; i < n; i++ {
-			price := closes[i]
-			md := bot.MarketData{Bid: price, Ask: price, Timestamp: times[i]}
-			sig := strat.Next(md, cfg)
-			if sig == bot.SignalBuy && !inPos {
-				entry = price
-				inPos = true
-			} else if sig == bot.SignalSell && inPos {
-				profitGross := (price - entry) * cfg.StakeSize
-				feeCost := (entry + price) * cfg.StakeSize * req.FeeRate
-				profit := profitGross - feeCost
-				pnlTotal += profit
-				profits = append(profits, profit)
-				trades++
-				if profit > 0 {
-					wins++
-				} else {
-					losses++
-				}
-				inPos = false
-			}
-			// track drawdown
-			if pnlTotal > peak {
-				peak = pnlTotal
+		var candles []luno.Candle
+		if !req.From.IsZero() {
+			history, ok := client.(bot.CandleHistory)
+			if !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "from/to require the candle cache"})
+				return
 			}
-			dd := peak - pnlTotal
-			if dd > maxDD {
-				maxDD = dd
+			if req.To.IsZero() {
+				req.To = time.Now()
 			}
-			drawdownHistory = append(drawdownHistory, gin.H{"time": times[i], "drawdown": dd})
-			pnlHistory = append(pnlHistory, gin.H{"time": times[i], "pnl": pnlTotal})
-		}
-		winRate := 0.0
-		if trades > 0 {
-			winRate = float64(wins) / float64(trades) * 100
-		}
-		avgPnl := 0.0
-		if trades > 0 {
-			avgPnl = pnlTotal / float64(trades)
-		}
-		// compute Sharpe ratio on trade profits
-		var sharpe float64
-		if len(profits) > 1 {
-			mean := 0.0
-			for _, p := range profits {
-				mean += p
+			res, err := history.CandlesBetween(context.Background(), req.Pair, req.Duration, req.From, req.To)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
 			}
-			mean /= float64(len(profits))
-			sumsq := 0.0
-			for _, p := range profits {
-				sumsq += (p - mean) * (p - mean)
+			candles = res
+		} else {
+			since := time.Now().Add(-time.Duration(req.SinceMinutes) * time.Minute)
+			candlesRes, err := client.GetCandles(context.Background(), &luno.GetCandlesRequest{
+				Pair:     req.Pair,
+				Duration: req.Duration,
+				Since:    luno.Time(since),
+			})
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
 			}
-			std := math.Sqrt(sumsq / float64(len(profits)-1))
-			if std > 0 {
-				sharpe = mean / std * math.Sqrt(float64(len(profits)))
+			candles = candlesRes.Candles
+		}
+		// size like the pair's live config, unless the request overrides it
+		var stored *config.Config
+		if store != nil {
+			loaded, err := store.LoadConfig()
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
 			}
+			stored = loaded
 		}
-		c.JSON(http.StatusOK, gin.H{
-			"trades":      trades,
-			"wins":        wins,
-			"losses":      losses,
-			"win_rate":    winRate,
-			"total_pnl":   pnlTotal,
-			"avg_pnl":     avgPnl,
-			"sharpe":      sharpe,
-			"max_drawdown": maxDD,
-			"pnl_history":  pnlHistory,
-			"drawdown_history": drawdownHistory,
+		cfg, sizer, err := backtest.ResolveConfig(stored, req.Pair, backtest.Overrides{
+			StakeSize:     req.StakeSize,
+			PositionLimit: req.PositionLimit,
+			InitialEquity: req.InitialEquity,
+			Sizer:         req.Sizer,
 		})
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		cfg.FillModel = req.FillModel
+		cfg.FillSpreadBps = req.SpreadBps
+		cfg.MakerFee, cfg.TakerFee = req.MakerFee, req.TakerFee
+		cfg.FillLatencyMs = req.LatencyMs
+		cfg.FillParticipation = req.Participation
+		cfg.FillLevelVolume, cfg.FillLevelBps = req.LevelVolume, req.LevelBps
+		// without explicit fees, charge the account's fees for the pair
+		if req.FeeRate == 0 && req.MakerFee == 0 && req.TakerFee == 0 {
+			if f, err := fees.Rates(context.Background(), req.Pair); err == nil {
+				cfg.MakerFee, cfg.TakerFee = f.Maker, f.Taker
+			}
+		}
+		opts := backtest.Options{FeeRate: req.FeeRate, Sizer: sizer, BarInterval: time.Duration(req.Duration) * time.Second}
+		if req.FillModel != "" {
+			fills, err := bot.NewFillModel(cfg)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			opts.Fills = fills
+		}
+		report, err := backtest.Run(context.Background(), strategy, cfg, backtest.BarsFromCandles(candles), opts)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, report)
 	})
 
-	// Simulate one step
+	// Simulate one step for ?pair, defaulting to the configured pair
 	r.POST("/simulate", func(c *gin.Context) {
-		simulateCounter.Inc()
 		cfgRaw, err := store.LoadConfig()
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		// convert config to bot.Config
-		cfg := bot.Config{
-			Pair:           cfgRaw.Pair,
-			EntryThreshold: cfgRaw.EntryThreshold,
-			ExitThreshold:  cfgRaw.ExitThreshold,
-			StakeSize:      cfgRaw.StakeSize,
-			Cooldown:       cfgRaw.Cooldown,
-			PositionLimit:  cfgRaw.PositionLimit,
-			MaxDrawdown:    cfgRaw.MaxDrawdown,
-			ShortWindow:    cfgRaw.ShortWindow,
-			LongWindow:     cfgRaw.LongWindow,
-		}
+		// convert the pair's market config to bot.Config
+		cfg := bot.ConfigFromStore(cfgRaw.ForPair(c.DefaultQuery("pair", cfgRaw.Pair)))
+		simulateCounter.WithLabelValues(cfg.Pair).Inc()
 		// fetch market data
-		ob, err := client.GetOrderBook(context.Background(), &luno.GetOrderBookRequest{Pair: cfg.Pair})
+		md, err := market.MarketData(context.Background(), cfg.Pair)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		// compute mid-price
-		bid := ob.Bids[0].Price.Float64()
-		ask := ob.Asks[0].Price.Float64()
-		md := bot.MarketData{Bid: bid, Ask: ask, Timestamp: time.Now()}
 		// strategy signal and execution
 		sig := strat.Next(md, cfg)
 		execErr := simExec.Execute(context.Background(), sig, md, cfg)
-		simulationPnLGauge.Set(simExec.(*bot.SimulatedExecutor).TotalPnL)
-		// build response
-		resp := gin.H{
-			"signal":                sig,
-			"position":              simExec.(*bot.SimulatedExecutor).Position,
-			"total_pnl":             simExec.(*bot.SimulatedExecutor).TotalPnL,
-			"max_drawdown_exceeded": simExec.(*bot.SimulatedExecutor).MaxDrawdownExceeded,
-			"error":                 nil,
+		// build response from the pair's simulated account
+		resp := gin.H{"pair": cfg.Pair, "signal": sig, "error": nil}
+		if sim := simulatedFor(simExec, cfg.Pair); sim != nil {
+			st := sim.Stats()
+			simulationPnLGauge.WithLabelValues(cfg.Pair).Set(st.TotalPnL)
+			resp["position"] = st.Position
+			resp["total_pnl"] = st.TotalPnL
+			resp["total_fees"] = st.TotalFees
+			resp["max_drawdown_exceeded"] = st.MaxDrawdownExceeded
 		}
 		if execErr != nil {
 			resp["error"] = execErr.Error()
@@ -852,38 +931,23 @@ func SetupRouter(store config.StateStore, client bot.Client, strat bot.Strategy,
 		c.JSON(http.StatusOK, resp)
 	})
 
-	// Execute one step live
+	// Execute one step live for ?pair, defaulting to the configured pair
 	r.POST("/execute", func(c *gin.Context) {
-		liveExecCounter.Inc()
 		cfgRaw, err := store.LoadConfig()
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		cfg := bot.Config{
-			Pair:             cfgRaw.Pair,
-			EntryThreshold:   cfgRaw.EntryThreshold,
-			ExitThreshold:    cfgRaw.ExitThreshold,
-			StakeSize:        cfgRaw.StakeSize,
-			Cooldown:         cfgRaw.Cooldown,
-			PositionLimit:    cfgRaw.PositionLimit,
-			MaxDrawdown:      cfgRaw.MaxDrawdown,
-			ShortWindow:      cfgRaw.ShortWindow,
-			LongWindow:       cfgRaw.LongWindow,
-			BaseAccountId:    cfgRaw.BaseAccountId,
-			CounterAccountId: cfgRaw.CounterAccountId,
-		}
-		ob, err := client.GetOrderBook(context.Background(), &luno.GetOrderBookRequest{Pair: cfg.Pair})
+		cfg := bot.ConfigFromStore(cfgRaw.ForPair(c.DefaultQuery("pair", cfgRaw.Pair)))
+		liveExecCounter.WithLabelValues(cfg.Pair).Inc()
+		md, err := market.MarketData(context.Background(), cfg.Pair)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		bid := ob.Bids[0].Price.Float64()
-		ask := ob.Asks[0].Price.Float64()
-		md := bot.MarketData{Bid: bid, Ask: ask, Timestamp: time.Now()}
 		sig := strat.Next(md, cfg)
 		execErr := liveExec.Execute(context.Background(), sig, md, cfg)
-		resp := gin.H{"signal": sig, "error": nil}
+		resp := gin.H{"pair": cfg.Pair, "signal": sig, "error": nil}
 		if execErr != nil {
 			resp["error"] = execErr.Error()
 		}
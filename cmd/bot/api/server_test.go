@@ -4,16 +4,23 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"math"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/luno/luno-bot/bot"
+	"github.com/luno/luno-bot/config"
+	"github.com/luno/luno-bot/storage"
 	"github.com/luno/luno-go"
 	"github.com/luno/luno-go/decimal"
 )
 
 func TestHealthzEndpoint(t *testing.T) {
-	r := SetupRouter(nil, nil, nil, nil, nil)
+	r := SetupRouter(nil, nil, nil, nil, nil, nil, nil)
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", "/healthz", nil)
 	r.ServeHTTP(w, req)
@@ -23,7 +30,7 @@ func TestHealthzEndpoint(t *testing.T) {
 }
 
 func TestMetricsEndpoint(t *testing.T) {
-	r := SetupRouter(nil, nil, nil, nil, nil)
+	r := SetupRouter(nil, nil, nil, nil, nil, nil, nil)
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", "/metrics", nil)
 	r.ServeHTTP(w, req)
@@ -46,6 +53,9 @@ func (f *fakeClient) GetOrderBook(ctx context.Context, req *luno.GetOrderBookReq
 func (f *fakeClient) PostLimitOrder(ctx context.Context, req *luno.PostLimitOrderRequest) (*luno.PostLimitOrderResponse, error) {
 	return &luno.PostLimitOrderResponse{}, nil
 }
+func (f *fakeClient) PostMarketOrder(ctx context.Context, req *luno.PostMarketOrderRequest) (*luno.PostMarketOrderResponse, error) {
+	return &luno.PostMarketOrderResponse{}, nil
+}
 func (f *fakeClient) ListTrades(ctx context.Context, req *luno.ListTradesRequest) (*luno.ListTradesResponse, error) {
 	return &luno.ListTradesResponse{}, nil
 }
@@ -55,10 +65,25 @@ func (f *fakeClient) GetCandles(ctx context.Context, req *luno.GetCandlesRequest
 func (f *fakeClient) GetBalances(ctx context.Context, req *luno.GetBalancesRequest) (*luno.GetBalancesResponse, error) {
 	return &luno.GetBalancesResponse{}, nil
 }
+func (f *fakeClient) GetOrderV2(ctx context.Context, req *luno.GetOrderV2Request) (*luno.GetOrderV2Response, error) {
+	return &luno.GetOrderV2Response{OrderId: req.Id, Status: luno.StatusComplete}, nil
+}
+func (f *fakeClient) ListOrders(ctx context.Context, req *luno.ListOrdersRequest) (*luno.ListOrdersResponse, error) {
+	return &luno.ListOrdersResponse{}, nil
+}
+func (f *fakeClient) StopOrder(ctx context.Context, req *luno.StopOrderRequest) (*luno.StopOrderResponse, error) {
+	return &luno.StopOrderResponse{Success: true}, nil
+}
+func (f *fakeClient) Markets(ctx context.Context, req *luno.MarketsRequest) (*luno.MarketsResponse, error) {
+	return &luno.MarketsResponse{}, nil
+}
+func (f *fakeClient) GetFeeInfo(ctx context.Context, req *luno.GetFeeInfoRequest) (*luno.GetFeeInfoResponse, error) {
+	return &luno.GetFeeInfoResponse{}, nil
+}
 
 func TestPairsEndpoint(t *testing.T) {
 	fc := &fakeClient{}
-	r := SetupRouter(nil, fc, nil, nil, nil)
+	r := SetupRouter(nil, fc, nil, nil, nil, nil, nil)
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", "/pairs", nil)
 	r.ServeHTTP(w, req)
@@ -76,7 +101,7 @@ func TestPairsEndpoint(t *testing.T) {
 
 func TestScanEndpoint(t *testing.T) {
 	fc := &fakeClient{}
-	r := SetupRouter(nil, fc, nil, nil, nil)
+	r := SetupRouter(nil, fc, nil, nil, nil, nil, nil)
 	body := map[string]interface{}{"pairs": []string{"XBTZAR"}, "min_volume": 0, "entry_threshold": 0.05, "exit_threshold": 0.01}
 	b, _ := json.Marshal(body)
 	w := httptest.NewRecorder()
@@ -106,7 +131,7 @@ func TestScanEndpoint(t *testing.T) {
 // Test continuous auto-scan endpoints
 func TestAutoScanEndpoints(t *testing.T) {
 	fc := &fakeClient{}
-	r := SetupRouter(nil, fc, nil, nil, nil)
+	r := SetupRouter(nil, fc, nil, nil, nil, nil, nil)
 
 	// Start auto-scan
 	body := map[string]interface{}{"pairs": []string{"XBTZAR"}, "min_volume": 0, "entry_threshold": 0, "exit_threshold": 0, "interval_seconds": 1, "auto_execute": false}
@@ -154,3 +179,242 @@ func TestAutoScanEndpoints(t *testing.T) {
 		t.Errorf("Expected BadRequest on double stop, got %d", w.Code)
 	}
 }
+
+// fakeStore implements config.StateStore for tests.
+type fakeStore struct{ cfg config.Config }
+
+func (f *fakeStore) LoadConfig() (*config.Config, error) { c := f.cfg; return &c, nil }
+func (f *fakeStore) SaveConfig(c *config.Config) error   { f.cfg = *c; return nil }
+
+// Test trading engine lifecycle endpoints and status reporting
+func TestEngineEndpoints(t *testing.T) {
+	fc := &fakeClient{}
+	store := &fakeStore{cfg: config.Config{Pair: "XBTZAR"}}
+	engine := bot.NewEngine(store, fc, bot.NewThresholdStrategy(), bot.NewSimulatedExecutor(), time.Hour)
+	r := SetupRouter(store, fc, nil, nil, nil, engine, nil)
+
+	post := func(path string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", path, nil)
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	status := func() map[string]interface{} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/status", nil)
+		r.ServeHTTP(w, req)
+		var resp map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Invalid JSON: %v", err)
+		}
+		return resp
+	}
+
+	if code := post("/engine/start"); code != http.StatusOK {
+		t.Fatalf("Start returned %d, expected %d", code, http.StatusOK)
+	}
+	if code := post("/engine/start"); code != http.StatusConflict {
+		t.Errorf("Expected Conflict on double start, got %d", code)
+	}
+	if code := post("/engine/pause"); code != http.StatusOK {
+		t.Fatalf("Pause returned %d, expected %d", code, http.StatusOK)
+	}
+	if st := status(); st["status"] != "paused" {
+		t.Errorf("Expected status paused, got %v", st["status"])
+	}
+	if code := post("/engine/resume"); code != http.StatusOK {
+		t.Fatalf("Resume returned %d, expected %d", code, http.StatusOK)
+	}
+	if code := post("/engine/stop"); code != http.StatusOK {
+		t.Fatalf("Stop returned %d, expected %d", code, http.StatusOK)
+	}
+	if st := status(); st["status"] != "stopped" {
+		t.Errorf("Expected status stopped, got %v", st["status"])
+	}
+}
+
+func TestReconcileEndpoints(t *testing.T) {
+	fc := &fakeClient{}
+	store := &fakeStore{cfg: config.Config{Pair: "XBTZAR", StakeSize: 1, PositionLimit: 1}}
+	sim := bot.NewSimulatedExecutor()
+	recon := bot.NewReconcilingExecutor(sim, fc, sim)
+	r := SetupRouter(store, fc, nil, nil, nil, nil, nil)
+	RegisterReconcileRoutes(r, store, map[string]*bot.ReconcilingExecutor{"XBTZAR": recon})
+
+	call := func(method, path string) (int, bot.ReconcileReport) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, nil)
+		r.ServeHTTP(w, req)
+		var rep bot.ReconcileReport
+		json.Unmarshal(w.Body.Bytes(), &rep)
+		return w.Code, rep
+	}
+
+	if code, _ := call("GET", "/reconcile"); code != http.StatusNotFound {
+		t.Errorf("Expected NotFound before reconciling, got %d", code)
+	}
+	// fake client has no balances, so accounts are missing
+	code, rep := call("POST", "/reconcile")
+	if code != http.StatusOK || len(rep.Mismatches) == 0 {
+		t.Fatalf("Reconcile returned %d with mismatches %v", code, rep.Mismatches)
+	}
+	cfg := bot.ConfigFromStore(&store.cfg)
+	md := bot.MarketData{Bid: 100, Ask: 110}
+	if err := recon.Execute(context.Background(), bot.SignalBuy, md, cfg); !errors.Is(err, bot.ErrUnreconciled) {
+		t.Errorf("Expected ErrUnreconciled, got %v", err)
+	}
+	if code, rep := call("POST", "/reconcile/ack"); code != http.StatusOK || !rep.Acknowledged {
+		t.Fatalf("Ack returned %d, acknowledged=%v", code, rep.Acknowledged)
+	}
+	if err := recon.Execute(context.Background(), bot.SignalBuy, md, cfg); err != nil {
+		t.Errorf("Execute after ack failed: %v", err)
+	}
+}
+
+func TestKillSwitchEndpoints(t *testing.T) {
+	store, err := storage.NewSQLiteStore(t.TempDir() + "/bot.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	ks, err := bot.NewKillSwitch(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exec := bot.NewBreakerExecutor(bot.NewSimulatedExecutor(), ks)
+	r := SetupRouter(nil, &fakeClient{}, nil, nil, nil, nil, nil)
+	RegisterKillSwitchRoutes(r, ks)
+
+	call := func(path, body string) storage.KillSwitchState {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s returned %d: %s", path, w.Code, w.Body.String())
+		}
+		var st storage.KillSwitchState
+		json.Unmarshal(w.Body.Bytes(), &st)
+		return st
+	}
+
+	if st := call("/killswitch", `{"reason":"exchange outage"}`); !st.Halted || st.Reason != "exchange outage" {
+		t.Fatalf("Expected halted state, got %+v", st)
+	}
+	cfg := bot.Config{Pair: "XBTZAR", StakeSize: 1, PositionLimit: 1}
+	md := bot.MarketData{Bid: 100, Ask: 110}
+	if err := exec.Execute(context.Background(), bot.SignalBuy, md, cfg); !errors.Is(err, bot.ErrHalted) {
+		t.Errorf("Expected ErrHalted, got %v", err)
+	}
+	// a restart loads the persisted halt
+	reloaded, err := bot.NewKillSwitch(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reloaded.Halted() {
+		t.Error("Expected halt to survive a reload")
+	}
+	if st := call("/killswitch/reset", ""); st.Halted {
+		t.Fatalf("Expected reset state, got %+v", st)
+	}
+	if err := exec.Execute(context.Background(), bot.SignalBuy, md, cfg); err != nil {
+		t.Errorf("Execute after reset failed: %v", err)
+	}
+}
+
+// fakeMarket serves a fixed top of book.
+type fakeMarket struct{ bid, ask float64 }
+
+func (m fakeMarket) MarketData(ctx context.Context, pair string) (bot.MarketData, error) {
+	return bot.MarketData{Bid: m.bid, Ask: m.ask, Timestamp: time.Now()}, nil
+}
+func (m fakeMarket) OrderBook(ctx context.Context, pair string) ([]luno.OrderBookEntry, []luno.OrderBookEntry, error) {
+	return nil, nil, nil
+}
+
+func TestSimulateUsesMarketDataSource(t *testing.T) {
+	// fakeClient's order book is empty, so a 200 means the market source was used
+	store := &fakeStore{cfg: config.Config{Pair: "XBTZAR", StakeSize: 1, PositionLimit: 1}}
+	sim := bot.NewSimulatedExecutor()
+	r := SetupRouter(store, &fakeClient{}, bot.NewThresholdStrategy(), sim, nil, nil, fakeMarket{bid: 100, ask: 110})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/simulate", nil)
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Simulate returned %d, expected %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+}
+
+// candleClient serves a minute candle series from base, up to 1000 per call,
+// and fails every call while down.
+type candleClient struct {
+	fakeClient
+	base  time.Time
+	n     int
+	calls int
+	down  bool
+}
+
+func (f *candleClient) GetCandles(ctx context.Context, req *luno.GetCandlesRequest) (*luno.GetCandlesResponse, error) {
+	f.calls++
+	if f.down {
+		return nil, errors.New("exchange unreachable")
+	}
+	var candles []luno.Candle
+	for i := 0; i < f.n && len(candles) < 1000; i++ {
+		ts := f.base.Add(time.Duration(i) * time.Minute)
+		if ts.Before(time.Time(req.Since)) {
+			continue
+		}
+		p := decimal.NewFromFloat64(100+10*math.Sin(float64(i)/10), 8)
+		candles = append(candles, luno.Candle{Timestamp: luno.Time(ts), Open: p, High: p, Low: p, Close: p, Volume: decimal.NewFromInt64(1)})
+	}
+	return &luno.GetCandlesResponse{Pair: req.Pair, Duration: req.Duration, Candles: candles}, nil
+}
+
+func TestBacktestFromCandleCache(t *testing.T) {
+	store, err := storage.NewSQLiteStore(t.TempDir() + "/cache.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cc := &candleClient{base: base, n: 1500}
+	r := SetupRouter(nil, bot.NewCachedClient(cc, store), nil, nil, nil, nil, nil)
+
+	run := func() map[string]interface{} {
+		body, _ := json.Marshal(map[string]interface{}{
+			"pair": "XBTZAR", "short": 3, "long": 8, "stake_size": 1, "position_limit": 1,
+			"from": base, "to": base.Add(1500 * time.Minute),
+		})
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/backtest", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Backtest returned %d: %s", w.Code, w.Body.String())
+		}
+		var resp map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Invalid JSON: %v", err)
+		}
+		return resp
+	}
+
+	first := run()
+	if cc.calls != 2 {
+		t.Errorf("Expected 2 paged candle calls, got %d", cc.calls)
+	}
+	if first["trades"].(float64) == 0 {
+		t.Fatalf("Expected trades from cached candles, got %v", first)
+	}
+	// covered range is served from the cache without calling the exchange
+	cc.down = true
+	second := run()
+	if cc.calls != 2 {
+		t.Errorf("Expected no further candle calls, got %d", cc.calls)
+	}
+	if first["total_pnl"] != second["total_pnl"] || first["trades"] != second["trades"] {
+		t.Errorf("Cached rerun differs: %v vs %v", first["total_pnl"], second["total_pnl"])
+	}
+}
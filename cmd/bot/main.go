@@ -27,6 +27,7 @@ func main() {
 	apiKeyID := flag.String("api_key_id", "", "Luno API key ID")
 	apiKeySecret := flag.String("api_key_secret", "", "Luno API key secret")
 	configPath := flag.String("config", "../../config/config.json", "Path to config file")
+	liveEngine := flag.Bool("live", false, "Drive the trading engine with the live executor instead of simulation")
 	flag.Parse()
 
 	// Fallback to environment variables if flags not provided
@@ -75,19 +76,15 @@ func main() {
 	}
 	fmt.Printf("Order Book (%s): Bids: %+v\nAsks: %+v\n", cfg.Pair, ob.Bids, ob.Asks)
 
-	// Initialize strategy and simulated executor
-	strat := bot.NewMultiTimeframeStrategy(cfg)
-	// Setup position sizing and TWAP executor chain
-	var sizer bot.PositionSizer
-	switch cfg.PositionSizerType {
-	case "kelly":
-		sizer = &bot.KellySizer{WinProb: cfg.KellyWinProb, WinLoss: cfg.KellyWinLossRatio}
-	default:
-		sizer = &bot.FixedSizer{}
+	// Each market gets its own strategy instance, built from its settings on first tick
+	strat := bot.NewPairStrategy(nil)
+	pairs := cfg.Pairs()
+	for _, pair := range pairs {
+		if _, err := bot.NewStrategy(bot.ConfigFromStore(cfg.ForPair(pair))); err != nil {
+			fmt.Printf("Invalid strategy for %s: %v\n", pair, err)
+			return
+		}
 	}
-	simInner := bot.NewSimulatedExecutor()
-	simSizing := bot.NewSizingExecutor(simInner, sizer)
-	// Setup VWAP executor for simulation
 	// Initialize SQLite store
 	sqlStore, err := storage.NewSQLiteStore(cfg.DBPath)
 	if err != nil {
@@ -95,12 +92,93 @@ func main() {
 		return
 	}
 	defer sqlStore.Close()
-	simVWAP := bot.NewVWAPExecutor(simSizing, lc, cfg.TWAPSlices, time.Duration(cfg.TWAPIntervalSeconds)*time.Second, sqlStore)
-	// Initialize live VWAP executor
-	liveInner := bot.NewLunoExecutor(lc)
-	liveSizing := bot.NewSizingExecutor(liveInner, sizer)
-	var liveExec bot.Executor = bot.NewVWAPExecutor(liveSizing, lc, cfg.TWAPSlices, time.Duration(cfg.TWAPIntervalSeconds)*time.Second, sqlStore)
-	// Wrap live executor with logging
+	// Serve candles and trades through the local cache, keeping it current in the background
+	history := bot.NewCachedClient(lc, sqlStore)
+	// Timeframe strategies warm up on cached candles when they are built
+	strat.History = history
+	go bot.NewBackfiller(lc, sqlStore).Run(ctx, pairs, 60, 24*time.Hour, time.Minute)
+	// Stream order books for active pairs, falling back to REST when a stream is stale
+	market := bot.NewStreamingMarketData(lc, *apiKeyID, *apiKeySecret)
+	for _, pair := range pairs {
+		if err := market.Subscribe(pair); err != nil {
+			fmt.Printf("Streaming unavailable for %s, using REST market data: %v\n", pair, err)
+		}
+	}
+	defer market.Close()
+	go market.Run(ctx)
+	// Load each pair's precision, limits and trading status, refreshing hourly
+	markets := bot.NewMarkets(lc)
+	if err := markets.Refresh(ctx); err != nil {
+		fmt.Println("Error loading markets, orders use default precision:", err)
+	}
+	go markets.Run(ctx, time.Hour)
+	// Paper account for simulated fills, seeded from a snapshot of the real balances or from config
+	var ledger *bot.PaperLedger
+	if cfg.PaperSnapshotBalances {
+		if ledger, err = bot.SnapshotLedger(ctx, lc); err != nil {
+			fmt.Println("Error snapshotting balances for paper trading:", err)
+		}
+	}
+	if ledger == nil && len(cfg.PaperBalances) > 0 {
+		ledger = bot.NewPaperLedger(cfg.PaperBalances)
+	}
+	// Slice orders by VWAP weights, as a randomized TWAP, or as a share of market volume,
+	// as each pair's execution_algo asks; multi-slice schedules and POV orders run as
+	// background jobs that survive a restart
+	newSlicer := func(c *config.Config, inner bot.Executor, jobs *bot.JobRunner) bot.Executor {
+		interval := time.Duration(c.TWAPIntervalSeconds) * time.Second
+		switch c.ExecutionAlgo {
+		case "twap":
+			t := bot.NewRandomTWAPExecutor(inner, c.TWAPSlices, interval, c.TWAPJitter, time.Duration(c.TWAPDeadlineSeconds)*time.Second)
+			t.Jobs = jobs
+			return t
+		case "pov":
+			p := bot.NewPOVExecutor(inner, history, c.POVRate, interval, time.Duration(c.POVMaxSeconds)*time.Second)
+			p.Jobs = jobs
+			return p
+		}
+		v := bot.NewVWAPExecutor(inner, history, c.TWAPSlices, interval, sqlStore)
+		v.Market = market
+		v.Jobs = jobs
+		return v
+	}
+	for _, pair := range pairs {
+		c := cfg.ForPair(pair)
+		switch c.ExecutionAlgo {
+		case "", "vwap", "twap":
+		case "pov":
+			if c.POVMaxSeconds <= 0 {
+				fmt.Printf("execution_algo pov on %s needs pov_max_seconds\n", pair)
+				return
+			}
+		default:
+			fmt.Printf("Unknown execution_algo %q on %s\n", c.ExecutionAlgo, pair)
+			return
+		}
+		switch c.OrderMode {
+		case "", "limit", "maker", "market":
+		default:
+			fmt.Printf("Unknown order_mode %q on %s\n", c.OrderMode, pair)
+			return
+		}
+	}
+	// Size each signal above the slicer so slices split the sized stake; each
+	// chain gets its own sizer since volatility sizers track ticks
+	if _, err := bot.SizerFromStore(cfg, nil, ""); err != nil {
+		fmt.Println(err)
+		return
+	}
+	newSizer := func(chain string) bot.PositionSizer {
+		sizer, _ := bot.SizerFromStore(cfg, sqlStore, chain)
+		return sizer
+	}
+	switch cfg.SizingEquity {
+	case "", "portfolio", "balances", "initial":
+	default:
+		fmt.Printf("Unknown sizing_equity %q\n", cfg.SizingEquity)
+		return
+	}
+	// Wrap live executors with logging
 	actFile, err := os.OpenFile("live_activity.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
 	if err != nil {
 		fmt.Println("Error opening live activity log:", err)
@@ -115,14 +193,144 @@ func main() {
 	defer errFile.Close()
 	actLogger := log.New(actFile, "", log.LstdFlags)
 	errLogger := log.New(errFile, "", log.LstdFlags|log.Lshortfile)
-	liveExec = bot.NewLoggingExecutor(liveExec, actLogger, errLogger)
-	
+	// Halt all chains together; the halt is persisted so a restart stays halted
+	killSwitch, err := bot.NewKillSwitch(sqlStore)
+	if err != nil {
+		fmt.Println("Error loading kill switch:", err)
+		return
+	}
+	if st := killSwitch.State(); st.Halted {
+		fmt.Printf("Trading halted since %s: %s (POST /killswitch/reset to resume)\n", st.TrippedAt.Format(time.RFC3339), st.Reason)
+	}
+	equityInterval := time.Duration(cfg.EquitySnapshotSeconds) * time.Second
+
+	// Build an isolated simulated and live chain per market, so positions,
+	// orders, exits, jobs and equity never mix between pairs
+	simExecs, liveExecs, aiExecs := bot.NewPairExecutor(), bot.NewPairExecutor(), bot.NewPairExecutor()
+	recons := make(map[string]*bot.ReconcilingExecutor)
+	var jobRunners []*bot.JobRunner
+	var portfolios []*bot.Portfolio
+	for _, pair := range pairs {
+		stored := cfg.ForPair(pair)
+		pairCfg := bot.ConfigFromStore(stored)
+		simInner := bot.NewSimulatedExecutor()
+		// Fill simulated orders with the configured model, walking the live book for depth
+		fills, err := bot.NewFillModel(pairCfg)
+		if err != nil {
+			fmt.Println("Error configuring fill model:", err)
+			return
+		}
+		simInner.Fills = fills
+		if pairCfg.FillModel == "market" {
+			simInner.Market = market
+		}
+		simInner.Markets = markets
+		simInner.Ledger = ledger
+		// Pre-trade risk checks sit below sizing so they see the final stake
+		simRisk := bot.NewRiskExecutor(simInner, simInner, nil)
+		simJobs := bot.NewJobRunner("sim", simRisk, simInner, sqlStore)
+		simSizing := bot.NewSizingExecutor(newSlicer(stored, simRisk, simJobs), newSizer("sim"))
+		// Initialize live executor
+		liveInner := bot.NewLunoExecutor(lc)
+		liveInner.Markets = markets
+		// Place orders as plain limits at the mid, as repriced post-only maker
+		// orders, or as market orders guarded by expected slippage, as each
+		// order's order_mode asks
+		livePlacer := bot.NewOrderModeExecutor(liveInner, bot.NewMakerExecutor(liveInner, market), bot.NewMarketExecutor(liveInner, market))
+		// Resume the bot's own position from its last saved mark, then check it
+		// and open orders against the exchange before trading live
+		lastMark, saved, err := sqlStore.LatestEquity("live", pair)
+		if err != nil {
+			fmt.Printf("Error loading %s equity: %v\n", pair, err)
+		}
+		if saved {
+			liveInner.Restore(lastMark.Position, lastMark.EntryPrice, nil)
+		}
+		liveRecon := bot.NewReconcilingExecutor(livePlacer, lc, liveInner)
+		if rep, err := liveRecon.Reconcile(ctx, pairCfg); err != nil {
+			fmt.Printf("Error reconciling %s with exchange: %v\n", pair, err)
+		} else if !rep.OK() {
+			fmt.Printf("Reconciliation mismatches on %s, live trading blocked until POST /reconcile/ack?pair=%s: %v\n", pair, pair, rep.Mismatches)
+		} else {
+			fmt.Printf("Reconciled %s: position %.8f at %.2f, %d open orders\n", rep.Pair, rep.Position, rep.EntryPrice, len(rep.OpenOrders))
+		}
+		recons[pair] = liveRecon
+		liveRisk := bot.NewRiskExecutor(liveRecon, liveInner, liveInner.Orders())
+		liveRisk.Market = market
+		liveJobs := bot.NewJobRunner("live", liveRisk, liveInner, sqlStore)
+		liveSizing := bot.NewSizingExecutor(newSlicer(stored, liveRisk, liveJobs), newSizer("live"))
+		// Pick up execution jobs interrupted by the last shutdown
+		for _, jobs := range []*bot.JobRunner{simJobs, liveJobs} {
+			jobs.Market = market
+			jobs.Halted = killSwitch.Halted
+			jobs.Pair = pair
+			jobs.Trades = history
+			if err := jobs.Restore(ctx); err != nil {
+				fmt.Println("Error restoring execution jobs:", err)
+			}
+		}
+		jobRunners = append(jobRunners, simJobs, liveJobs)
+		// Stops, targets and holding limits see every tick before the execution chain;
+		// both chains are marked to market on every tick to keep an equity curve
+		simExit := bot.NewExitManager(simSizing, simInner, sqlStore)
+		liveExit := bot.NewExitManager(liveSizing, liveInner, sqlStore)
+		simBreaker, liveBreaker := bot.NewBreakerExecutor(simExit, killSwitch), bot.NewBreakerExecutor(liveExit, killSwitch)
+		simPortfolio := bot.NewPortfolio("sim", simBreaker, simInner, sqlStore)
+		livePortfolio := bot.NewPortfolio("live", liveBreaker, liveInner, sqlStore)
+		// Trip on the drawdown of each chain's marked equity
+		simBreaker.Equity, liveBreaker.Equity = simPortfolio, livePortfolio
+		simPortfolio.Trips, livePortfolio.Trips = sqlStore, sqlStore
+		// Resume live realized PnL and the high-water mark from the last saved mark
+		if saved {
+			livePortfolio.Restore(lastMark)
+		}
+		if equityInterval > 0 {
+			simPortfolio.Interval, livePortfolio.Interval = equityInterval, equityInterval
+		}
+		portfolios = append(portfolios, simPortfolio, livePortfolio)
+		// Size from the marked equity, the account balances, or the configured starting equity
+		switch cfg.SizingEquity {
+		case "", "portfolio":
+			simSizing.Equity, liveSizing.Equity = simPortfolio, livePortfolio
+		case "balances":
+			simSizing.Equity, liveSizing.Equity = simPortfolio, bot.BalanceEquity{Balances: lc}
+			if ledger != nil {
+				simSizing.Equity = bot.BalanceEquity{Balances: ledger}
+			}
+		}
+		simExecs.Add(pair, simPortfolio)
+		liveExecs.Add(pair, bot.NewLoggingExecutor(livePortfolio, actLogger, errLogger))
+		// AI trades without a price go out as market orders through the pair's own
+		// breaker and risk checks, skipping exits, sizing and slicing
+		aiExecs.Add(pair, bot.NewLoggingExecutor(liveBreaker.Through(liveRisk), actLogger, errLogger))
+	}
+	var simExec bot.Executor = simExecs
+	var liveExec bot.Executor = liveExecs
+
 	// Initialize AI controller
 	aiController := ai.NewAIController(lc, sqlStore, cfg, strat, liveExec)
+	aiController.MarketExecutor = aiExecs
 	aiController.Start()
 	
+	// Trading engine ticks each market's strategy on a schedule; started via POST /engine/start
+	var engineExec bot.Executor = simExec
+	if *liveEngine {
+		engineExec = liveExec
+	}
+	engine := bot.NewEngine(store, lc, strat, engineExec, time.Duration(cfg.EngineIntervalSeconds)*time.Second)
+	engine.SetMarketData(market)
+	engine.SetFees(bot.NewFees(lc))
+	engine.SetUpdates(market.Updates())
+	defer engine.Stop()
+
 	// Launch REST API server with simulation and live execution
-	r := api.SetupRouter(store, lc, strat, simVWAP, liveExec)
+	r := api.SetupRouter(store, history, strat, simExec, liveExec, engine, market)
+	api.RegisterReconcileRoutes(r, store, recons)
+	api.RegisterKillSwitchRoutes(r, killSwitch)
+	api.RegisterExitRoutes(r, sqlStore)
+	api.RegisterJobRoutes(r, jobRunners...)
+	api.RegisterPaperRoutes(r, lc, ledger)
+	api.RegisterEquityRoutes(r, sqlStore, portfolios...)
 	
 	// Register AI routes
 	aiGroup := r.Group("/api/ai")
@@ -0,0 +1,121 @@
+// Package backtestcli holds the flags and setup shared by the backtester
+// commands, which differ only in where their bars come from.
+package backtestcli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/luno/luno-bot/bot"
+	"github.com/luno/luno-bot/bot/backtest"
+	"github.com/luno/luno-bot/config"
+	luno "github.com/luno/luno-go"
+)
+
+// Flags are the command-line flags every backtester takes.
+type Flags struct {
+	APIKeyID      string
+	APIKeySecret  string
+	Pair          string
+	Strategy      string
+	Params        string
+	FeeRate       float64
+	Execution     string
+	Slices        int
+	ConfigPath    string
+	Stake         float64
+	PositionLimit float64
+}
+
+// RegisterFlags defines the shared flags on fs.
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+	f := &Flags{}
+	fs.StringVar(&f.APIKeyID, "api_key_id", "", "Luno API key ID")
+	fs.StringVar(&f.APIKeySecret, "api_key_secret", "", "Luno API key secret")
+	fs.StringVar(&f.Pair, "pair", "", "Market pair, e.g. XBTZAR")
+	fs.StringVar(&f.Strategy, "strategy", "sma", "Registered strategy to replay, e.g. sma, rsi, macd or composite")
+	fs.StringVar(&f.Params, "params", "", `Strategy parameters as JSON, e.g. {"short_window": 5, "long_window": 10}`)
+	fs.Float64Var(&f.FeeRate, "fee_rate", 0, "Trading fee rate per trade side (e.g. 0.001 = 0.1% of trade volume); 0 uses the account's taker fee from GetFeeInfo")
+	fs.StringVar(&f.Execution, "execution", backtest.ExecDirect, "Executor chain: direct, twap or vwap")
+	fs.IntVar(&f.Slices, "slices", 1, "Slices for twap/vwap execution")
+	fs.StringVar(&f.ConfigPath, "config", "", "Bot config whose stake_size, position_limit, initial_equity and position sizer for the pair are used")
+	fs.Float64Var(&f.Stake, "stake", 0, "Stake per trade; 0 uses the config's stake_size")
+	fs.Float64Var(&f.PositionLimit, "position_limit", 0, "Position limit; 0 uses the config's position_limit")
+	return f
+}
+
+// Client returns a Luno client authenticated with the key flags.
+func (f *Flags) Client() (*bot.LunoClient, error) {
+	if f.APIKeyID == "" || f.APIKeySecret == "" || f.Pair == "" {
+		return nil, errors.New("--api_key_id, --api_key_secret and --pair are required")
+	}
+	lc := bot.NewLunoClient()
+	if err := lc.SetAuth(f.APIKeyID, f.APIKeySecret); err != nil {
+		return nil, fmt.Errorf("setting auth: %w", err)
+	}
+	return lc, nil
+}
+
+// Run backtests bars of length interval (zero for trade ticks) with the
+// strategy, config, stake and execution the flags select. Without
+// --fee_rate it charges the account's taker fee, fetched through client.
+func (f *Flags) Run(ctx context.Context, client bot.Client, bars []backtest.Bar, interval time.Duration) (*backtest.Report, error) {
+	strategy, err := bot.BuildStrategy(f.Strategy, json.RawMessage(f.Params))
+	if err != nil {
+		return nil, err
+	}
+	var stored *config.Config
+	if f.ConfigPath != "" {
+		if stored, err = config.NewStateStore(f.ConfigPath).LoadConfig(); err != nil {
+			return nil, fmt.Errorf("loading config: %w", err)
+		}
+	}
+	var o backtest.Overrides
+	if f.Stake > 0 {
+		o.StakeSize = &f.Stake
+	}
+	if f.PositionLimit > 0 {
+		o.PositionLimit = &f.PositionLimit
+	}
+	cfg, sizer, err := backtest.ResolveConfig(stored, f.Pair, o)
+	if err != nil {
+		return nil, err
+	}
+	fee := f.FeeRate
+	if fee == 0 {
+		rates, err := bot.NewFees(client).Rates(ctx, f.Pair)
+		if err != nil {
+			fmt.Println("Error fetching fees, using 0.1%:", err)
+			rates = bot.FeeRates{Maker: 0.001, Taker: 0.001}
+		}
+		fee = rates.Taker
+	}
+	opts := backtest.Options{FeeRate: fee, Sizer: sizer, Execution: f.Execution, Slices: f.Slices, BarInterval: interval}
+	return backtest.Run(ctx, strategy, cfg, bars, opts)
+}
+
+// Candles fetches the 1m candles of the last since through client.
+func Candles(ctx context.Context, client bot.Client, pair string, since time.Duration) ([]backtest.Bar, error) {
+	res, err := client.GetCandles(ctx, &luno.GetCandlesRequest{
+		Pair:     pair,
+		Duration: 60,
+		Since:    luno.Time(time.Now().Add(-since)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fetching candles: %w", err)
+	}
+	return backtest.BarsFromCandles(res.Candles), nil
+}
+
+// Print writes rep's summary under title.
+func Print(title string, rep *backtest.Report) {
+	fmt.Printf("%s: Trades=%d, Wins=%d, Losses=%d, Win rate=%.2f%%, Total PnL=%.2f\n",
+		title, rep.Trades, rep.Wins, rep.Losses, rep.WinRate, rep.TotalPnL)
+	if rep.Trades > 0 {
+		fmt.Printf("Avg PnL per trade: %.2f, Sharpe: %.2f, Max drawdown: %.2f, Fees: %.2f\n", rep.AvgPnL, rep.Sharpe, rep.MaxDrawdown, rep.Fees)
+	}
+}
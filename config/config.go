@@ -42,6 +42,244 @@ type Config struct {
 	VWAPOrderbookDepthLevels int     `json:"vwap_orderbook_depth_levels"`
 	VWAPHybridWeight         float64 `json:"vwap_hybrid_weight"`
 	DBPath                   string  `json:"db_path"`
+	EngineIntervalSeconds    int     `json:"engine_interval_seconds"`
+	// Simulated fill parameters
+	FillModel         string  `json:"fill_model"`
+	FillSpreadBps     float64 `json:"fill_spread_bps"`
+	MakerFee          float64 `json:"maker_fee"`
+	TakerFee          float64 `json:"taker_fee"`
+	FillLatencyMs     int     `json:"fill_latency_ms"`
+	FillParticipation float64 `json:"fill_participation"`
+	FillLevelVolume   float64 `json:"fill_level_volume"`
+	FillLevelBps      float64 `json:"fill_level_bps"`
+	// Pre-trade risk limits; zero disables a check
+	MaxOrderNotional float64 `json:"max_order_notional"`
+	MaxDailyVolume   float64 `json:"max_daily_volume"`
+	PriceBandBps     float64 `json:"price_band_bps"`
+	MaxOpenOrders    int     `json:"max_open_orders"`
+	MaxPairExposure  float64 `json:"max_pair_exposure"`
+	// Circuit breaker; zero disables a trigger
+	MaxConsecutiveErrors int `json:"max_consecutive_errors"`
+	StaleDataSeconds     int `json:"stale_data_seconds"`
+	// Exit manager; zero disables a rule
+	StopLossPct       float64 `json:"stop_loss_pct"`
+	TakeProfitPct     float64 `json:"take_profit_pct"`
+	TrailingStopPct   float64 `json:"trailing_stop_pct"`
+	ATRStopMultiplier float64 `json:"atr_stop_multiplier"`
+	ATRPeriod         int     `json:"atr_period"`
+	MaxHoldSeconds    int     `json:"max_hold_seconds"`
+	// Market rules
+	BumpMinVolume bool `json:"bump_min_volume"`
+	// Live order placement
+	OrderMode           string  `json:"order_mode"`
+	MakerRepriceTicks   int     `json:"maker_reprice_ticks"`
+	MakerTimeoutSeconds int     `json:"maker_timeout_seconds"`
+	MakerCrossOnTimeout bool    `json:"maker_cross_on_timeout"`
+	MakerTickSize       float64 `json:"maker_tick_size"`
+	MaxSlippageBps      float64 `json:"max_slippage_bps"`
+	// Execution algorithms
+	ExecutionAlgo       string  `json:"execution_algo"`
+	TWAPJitter          float64 `json:"twap_jitter"`
+	TWAPDeadlineSeconds int     `json:"twap_deadline_seconds"`
+	POVRate             float64 `json:"pov_rate"`
+	POVMaxSeconds       int     `json:"pov_max_seconds"`
+
+	// Paper trading account: virtual balances by asset, or a snapshot of the real account
+	PaperBalances         map[string]float64 `json:"paper_balances"`
+	PaperSnapshotBalances bool               `json:"paper_snapshot_balances"`
+	// Portfolio tracking
+	EquitySnapshotSeconds int `json:"equity_snapshot_seconds"`
+	// Position sizing inputs
+	SizingEquity    string  `json:"sizing_equity"`
+	RiskPerTradePct float64 `json:"risk_per_trade_pct"`
+	VolTargetPct    float64 `json:"vol_target_pct"`
+	KellyMinTrades  int     `json:"kelly_min_trades"`
+	// Strategy selection
+	Strategy       string          `json:"strategy"`
+	StrategyParams json.RawMessage `json:"strategy_params,omitempty"`
+	// Composite voting
+	VoteMode      string             `json:"vote_mode"`
+	VoteMin       int                `json:"vote_min"`
+	VoteThreshold float64            `json:"vote_threshold"`
+	VoteWeights   map[string]float64 `json:"vote_weights"`
+	VoteVeto      string             `json:"vote_veto"`
+	// Timeframes
+	Timeframe     string `json:"timeframe"`
+	FastTimeframe string `json:"fast_timeframe"`
+	SlowTimeframe string `json:"slow_timeframe"`
+	// Strategy events
+	StrategyDepth bool `json:"strategy_depth"`
+
+	// Reconciliation: base balance held outside the bot, expected on top of its position
+	BaseHoldings float64 `json:"base_holdings"`
+
+	// Markets traded, each overriding the settings above; empty trades Pair alone
+	Markets []MarketConfig `json:"markets"`
+}
+
+// MarketConfig is one traded pair with its own strategy, parameters, stake,
+// limits, accounts, execution and share of the starting equity. Unset (null) fields
+// inherit the top-level Config value; a set field overrides it, even with 0.
+type MarketConfig struct {
+	Pair             string   `json:"pair"`
+	Strategy         string   `json:"strategy"`
+	EntryThreshold   *float64 `json:"entry_threshold,omitempty"`
+	ExitThreshold    *float64 `json:"exit_threshold,omitempty"`
+	StakeSize        *float64 `json:"stake_size,omitempty"`
+	PositionLimit    *float64 `json:"position_limit,omitempty"`
+	MaxDrawdown      *float64 `json:"max_drawdown,omitempty"`
+	ShortWindow      *int     `json:"short_window,omitempty"`
+	LongWindow       *int     `json:"long_window,omitempty"`
+	RSIPeriod        *int     `json:"rsi_period,omitempty"`
+	RSIOverBought    *float64 `json:"rsi_overbought,omitempty"`
+	RSIOverSold      *float64 `json:"rsi_oversold,omitempty"`
+	MACDFastPeriod   *int     `json:"macd_fast_period,omitempty"`
+	MACDSlowPeriod   *int     `json:"macd_slow_period,omitempty"`
+	MACDSignalPeriod *int     `json:"macd_signal_period,omitempty"`
+	BBPeriod         *int     `json:"bb_period,omitempty"`
+	BBMultiplier     *float64 `json:"bb_multiplier,omitempty"`
+	MaxOrderNotional *float64 `json:"max_order_notional,omitempty"`
+	MaxDailyVolume   *float64 `json:"max_daily_volume,omitempty"`
+	MaxOpenOrders    *int     `json:"max_open_orders,omitempty"`
+	MaxPairExposure  *float64 `json:"max_pair_exposure,omitempty"`
+	BaseAccountId    *int64   `json:"base_account_id,omitempty"`
+	CounterAccountId *int64   `json:"counter_account_id,omitempty"`
+	// InitialEquity is this market's starting capital; markets without one
+	// share what the others leave of the top-level initial_equity equally
+	InitialEquity *float64 `json:"initial_equity,omitempty"`
+	// BaseHoldings is base balance outside the bot on this market's account
+	BaseHoldings *float64 `json:"base_holdings,omitempty"`
+	// Simulated fills, live order placement and exits
+	FillModel         string   `json:"fill_model,omitempty"`
+	OrderMode         string   `json:"order_mode,omitempty"`
+	StopLossPct       *float64 `json:"stop_loss_pct,omitempty"`
+	TakeProfitPct     *float64 `json:"take_profit_pct,omitempty"`
+	TrailingStopPct   *float64 `json:"trailing_stop_pct,omitempty"`
+	ATRStopMultiplier *float64 `json:"atr_stop_multiplier,omitempty"`
+	ATRPeriod         *int     `json:"atr_period,omitempty"`
+	MaxHoldSeconds    *int     `json:"max_hold_seconds,omitempty"`
+	// Execution algorithm and its schedule
+	ExecutionAlgo       string   `json:"execution_algo,omitempty"`
+	TWAPSlices          *int     `json:"twap_slices,omitempty"`
+	TWAPIntervalSeconds *int     `json:"twap_interval_seconds,omitempty"`
+	TWAPJitter          *float64 `json:"twap_jitter,omitempty"`
+	TWAPDeadlineSeconds *int     `json:"twap_deadline_seconds,omitempty"`
+	POVRate             *float64 `json:"pov_rate,omitempty"`
+	POVMaxSeconds       *int     `json:"pov_max_seconds,omitempty"`
+
+	StrategyParams json.RawMessage `json:"strategy_params,omitempty"`
+}
+
+// Pairs returns the traded pairs: each market's, or Pair when none are listed.
+func (c *Config) Pairs() []string {
+	if len(c.Markets) == 0 {
+		return []string{c.Pair}
+	}
+	pairs := make([]string, 0, len(c.Markets))
+	for _, m := range c.Markets {
+		pairs = append(pairs, m.Pair)
+	}
+	return pairs
+}
+
+// ForPair returns a copy of c for pair with that market's overrides applied
+// and its share of InitialEquity, so the markets' equity adds up to the
+// total. A pair without a market entry gets the top-level settings.
+func (c *Config) ForPair(pair string) *Config {
+	out := *c
+	out.Pair = pair
+	out.Markets = nil
+	for _, m := range c.Markets {
+		if m.Pair != pair {
+			continue
+		}
+		setString(&out.Strategy, m.Strategy)
+		if len(m.StrategyParams) > 0 {
+			out.StrategyParams = m.StrategyParams
+		}
+		setFloat(&out.EntryThreshold, m.EntryThreshold)
+		setFloat(&out.ExitThreshold, m.ExitThreshold)
+		setFloat(&out.StakeSize, m.StakeSize)
+		setFloat(&out.PositionLimit, m.PositionLimit)
+		setFloat(&out.MaxDrawdown, m.MaxDrawdown)
+		setInt(&out.ShortWindow, m.ShortWindow)
+		setInt(&out.LongWindow, m.LongWindow)
+		setInt(&out.RSIPeriod, m.RSIPeriod)
+		setFloat(&out.RSIOverBought, m.RSIOverBought)
+		setFloat(&out.RSIOverSold, m.RSIOverSold)
+		setInt(&out.MACDFastPeriod, m.MACDFastPeriod)
+		setInt(&out.MACDSlowPeriod, m.MACDSlowPeriod)
+		setInt(&out.MACDSignalPeriod, m.MACDSignalPeriod)
+		setInt(&out.BBPeriod, m.BBPeriod)
+		setFloat(&out.BBMultiplier, m.BBMultiplier)
+		setFloat(&out.MaxOrderNotional, m.MaxOrderNotional)
+		setFloat(&out.MaxDailyVolume, m.MaxDailyVolume)
+		setInt(&out.MaxOpenOrders, m.MaxOpenOrders)
+		setFloat(&out.MaxPairExposure, m.MaxPairExposure)
+		if m.BaseAccountId != nil {
+			out.BaseAccountId = *m.BaseAccountId
+		}
+		if m.CounterAccountId != nil {
+			out.CounterAccountId = *m.CounterAccountId
+		}
+		setFloat(&out.BaseHoldings, m.BaseHoldings)
+		setString(&out.FillModel, m.FillModel)
+		setString(&out.OrderMode, m.OrderMode)
+		setFloat(&out.StopLossPct, m.StopLossPct)
+		setFloat(&out.TakeProfitPct, m.TakeProfitPct)
+		setFloat(&out.TrailingStopPct, m.TrailingStopPct)
+		setFloat(&out.ATRStopMultiplier, m.ATRStopMultiplier)
+		setInt(&out.ATRPeriod, m.ATRPeriod)
+		setInt(&out.MaxHoldSeconds, m.MaxHoldSeconds)
+		setString(&out.ExecutionAlgo, m.ExecutionAlgo)
+		setInt(&out.TWAPSlices, m.TWAPSlices)
+		setInt(&out.TWAPIntervalSeconds, m.TWAPIntervalSeconds)
+		setFloat(&out.TWAPJitter, m.TWAPJitter)
+		setInt(&out.TWAPDeadlineSeconds, m.TWAPDeadlineSeconds)
+		setFloat(&out.POVRate, m.POVRate)
+		setInt(&out.POVMaxSeconds, m.POVMaxSeconds)
+		out.InitialEquity = c.equityFor(m)
+		break
+	}
+	return &out
+}
+
+// equityFor returns m's starting equity: its own, or an equal share of what
+// the markets with their own leave of InitialEquity.
+func (c *Config) equityFor(m MarketConfig) float64 {
+	if m.InitialEquity != nil {
+		return *m.InitialEquity
+	}
+	rest, shared := c.InitialEquity, 0
+	for _, other := range c.Markets {
+		if other.InitialEquity != nil {
+			rest -= *other.InitialEquity
+		} else {
+			shared++
+		}
+	}
+	if rest <= 0 {
+		return 0
+	}
+	return rest / float64(shared)
+}
+
+func setString(dst *string, v string) {
+	if v != "" {
+		*dst = v
+	}
+}
+
+func setFloat(dst *float64, v *float64) {
+	if v != nil {
+		*dst = *v
+	}
+}
+
+func setInt(dst *int, v *int) {
+	if v != nil {
+		*dst = *v
+	}
 }
 
 // StateStore persists and retrieves bot configuration.
@@ -98,6 +336,61 @@ func (s *JSONStateStore) LoadConfig() (*Config, error) {
 		VWAPOrderbookDepthLevels int     `json:"vwap_orderbook_depth_levels"`
 		VWAPHybridWeight         float64 `json:"vwap_hybrid_weight"`
 		DBPath                   string  `json:"db_path"`
+		EngineIntervalSeconds    int     `json:"engine_interval_seconds"`
+		FillModel                string  `json:"fill_model"`
+		FillSpreadBps            float64 `json:"fill_spread_bps"`
+		MakerFee                 float64 `json:"maker_fee"`
+		TakerFee                 float64 `json:"taker_fee"`
+		FillLatencyMs            int     `json:"fill_latency_ms"`
+		FillParticipation        float64 `json:"fill_participation"`
+		FillLevelVolume          float64 `json:"fill_level_volume"`
+		FillLevelBps             float64 `json:"fill_level_bps"`
+		MaxOrderNotional         float64 `json:"max_order_notional"`
+		MaxDailyVolume           float64 `json:"max_daily_volume"`
+		PriceBandBps             float64 `json:"price_band_bps"`
+		MaxOpenOrders            int     `json:"max_open_orders"`
+		MaxPairExposure          float64 `json:"max_pair_exposure"`
+		MaxConsecutiveErrors     int     `json:"max_consecutive_errors"`
+		StaleDataSeconds         int     `json:"stale_data_seconds"`
+		StopLossPct              float64 `json:"stop_loss_pct"`
+		TakeProfitPct            float64 `json:"take_profit_pct"`
+		TrailingStopPct          float64 `json:"trailing_stop_pct"`
+		ATRStopMultiplier        float64 `json:"atr_stop_multiplier"`
+		ATRPeriod                int     `json:"atr_period"`
+		MaxHoldSeconds           int     `json:"max_hold_seconds"`
+		BumpMinVolume            bool    `json:"bump_min_volume"`
+		OrderMode                string  `json:"order_mode"`
+		MakerRepriceTicks        int     `json:"maker_reprice_ticks"`
+		MakerTimeoutSeconds      int     `json:"maker_timeout_seconds"`
+		MakerCrossOnTimeout      bool    `json:"maker_cross_on_timeout"`
+		MakerTickSize            float64 `json:"maker_tick_size"`
+		MaxSlippageBps           float64 `json:"max_slippage_bps"`
+		ExecutionAlgo            string  `json:"execution_algo"`
+		TWAPJitter               float64 `json:"twap_jitter"`
+		TWAPDeadlineSeconds      int     `json:"twap_deadline_seconds"`
+		POVRate                  float64 `json:"pov_rate"`
+		POVMaxSeconds            int     `json:"pov_max_seconds"`
+
+		PaperBalances         map[string]float64 `json:"paper_balances"`
+		PaperSnapshotBalances bool               `json:"paper_snapshot_balances"`
+		EquitySnapshotSeconds int                `json:"equity_snapshot_seconds"`
+		SizingEquity          string             `json:"sizing_equity"`
+		RiskPerTradePct       float64            `json:"risk_per_trade_pct"`
+		VolTargetPct          float64            `json:"vol_target_pct"`
+		KellyMinTrades        int                `json:"kelly_min_trades"`
+		Strategy              string             `json:"strategy"`
+		Markets               []MarketConfig     `json:"markets"`
+		StrategyParams        json.RawMessage    `json:"strategy_params,omitempty"`
+		VoteMode              string             `json:"vote_mode"`
+		VoteMin               int                `json:"vote_min"`
+		VoteThreshold         float64            `json:"vote_threshold"`
+		VoteWeights           map[string]float64 `json:"vote_weights"`
+		VoteVeto              string             `json:"vote_veto"`
+		Timeframe             string             `json:"timeframe"`
+		FastTimeframe         string             `json:"fast_timeframe"`
+		SlowTimeframe         string             `json:"slow_timeframe"`
+		StrategyDepth         bool               `json:"strategy_depth"`
+		BaseHoldings          float64            `json:"base_holdings"`
 	}
 	var r raw
 	if err := json.Unmarshal(data, &r); err != nil {
@@ -138,6 +431,60 @@ func (s *JSONStateStore) LoadConfig() (*Config, error) {
 		VWAPOrderbookDepthLevels: r.VWAPOrderbookDepthLevels,
 		VWAPHybridWeight:         r.VWAPHybridWeight,
 		DBPath:                   r.DBPath,
+		EngineIntervalSeconds:    r.EngineIntervalSeconds,
+		FillModel:                r.FillModel,
+		FillSpreadBps:            r.FillSpreadBps,
+		MakerFee:                 r.MakerFee,
+		TakerFee:                 r.TakerFee,
+		FillLatencyMs:            r.FillLatencyMs,
+		FillParticipation:        r.FillParticipation,
+		FillLevelVolume:          r.FillLevelVolume,
+		FillLevelBps:             r.FillLevelBps,
+		MaxOrderNotional:         r.MaxOrderNotional,
+		MaxDailyVolume:           r.MaxDailyVolume,
+		PriceBandBps:             r.PriceBandBps,
+		MaxOpenOrders:            r.MaxOpenOrders,
+		MaxPairExposure:          r.MaxPairExposure,
+		MaxConsecutiveErrors:     r.MaxConsecutiveErrors,
+		StaleDataSeconds:         r.StaleDataSeconds,
+		StopLossPct:              r.StopLossPct,
+		TakeProfitPct:            r.TakeProfitPct,
+		TrailingStopPct:          r.TrailingStopPct,
+		ATRStopMultiplier:        r.ATRStopMultiplier,
+		ATRPeriod:                r.ATRPeriod,
+		MaxHoldSeconds:           r.MaxHoldSeconds,
+		BumpMinVolume:            r.BumpMinVolume,
+		OrderMode:                r.OrderMode,
+		MakerRepriceTicks:        r.MakerRepriceTicks,
+		MakerTimeoutSeconds:      r.MakerTimeoutSeconds,
+		MakerCrossOnTimeout:      r.MakerCrossOnTimeout,
+		MakerTickSize:            r.MakerTickSize,
+		MaxSlippageBps:           r.MaxSlippageBps,
+		ExecutionAlgo:            r.ExecutionAlgo,
+		TWAPJitter:               r.TWAPJitter,
+		TWAPDeadlineSeconds:      r.TWAPDeadlineSeconds,
+		POVRate:                  r.POVRate,
+		POVMaxSeconds:            r.POVMaxSeconds,
+		PaperBalances:            r.PaperBalances,
+		PaperSnapshotBalances:    r.PaperSnapshotBalances,
+		EquitySnapshotSeconds:    r.EquitySnapshotSeconds,
+		SizingEquity:             r.SizingEquity,
+		RiskPerTradePct:          r.RiskPerTradePct,
+		VolTargetPct:             r.VolTargetPct,
+		KellyMinTrades:           r.KellyMinTrades,
+		Strategy:                 r.Strategy,
+		Markets:                  r.Markets,
+		StrategyParams:           r.StrategyParams,
+		VoteMode:                 r.VoteMode,
+		VoteMin:                  r.VoteMin,
+		VoteThreshold:            r.VoteThreshold,
+		VoteWeights:              r.VoteWeights,
+		VoteVeto:                 r.VoteVeto,
+		Timeframe:                r.Timeframe,
+		FastTimeframe:            r.FastTimeframe,
+		SlowTimeframe:            r.SlowTimeframe,
+		StrategyDepth:            r.StrategyDepth,
+		BaseHoldings:             r.BaseHoldings,
 	}
 	return cfg, nil
 }
@@ -175,6 +522,61 @@ func (s *JSONStateStore) SaveConfig(cfg *Config) error {
 		VWAPOrderbookDepthLevels int     `json:"vwap_orderbook_depth_levels"`
 		VWAPHybridWeight         float64 `json:"vwap_hybrid_weight"`
 		DBPath                   string  `json:"db_path"`
+		EngineIntervalSeconds    int     `json:"engine_interval_seconds"`
+		FillModel                string  `json:"fill_model"`
+		FillSpreadBps            float64 `json:"fill_spread_bps"`
+		MakerFee                 float64 `json:"maker_fee"`
+		TakerFee                 float64 `json:"taker_fee"`
+		FillLatencyMs            int     `json:"fill_latency_ms"`
+		FillParticipation        float64 `json:"fill_participation"`
+		FillLevelVolume          float64 `json:"fill_level_volume"`
+		FillLevelBps             float64 `json:"fill_level_bps"`
+		MaxOrderNotional         float64 `json:"max_order_notional"`
+		MaxDailyVolume           float64 `json:"max_daily_volume"`
+		PriceBandBps             float64 `json:"price_band_bps"`
+		MaxOpenOrders            int     `json:"max_open_orders"`
+		MaxPairExposure          float64 `json:"max_pair_exposure"`
+		MaxConsecutiveErrors     int     `json:"max_consecutive_errors"`
+		StaleDataSeconds         int     `json:"stale_data_seconds"`
+		StopLossPct              float64 `json:"stop_loss_pct"`
+		TakeProfitPct            float64 `json:"take_profit_pct"`
+		TrailingStopPct          float64 `json:"trailing_stop_pct"`
+		ATRStopMultiplier        float64 `json:"atr_stop_multiplier"`
+		ATRPeriod                int     `json:"atr_period"`
+		MaxHoldSeconds           int     `json:"max_hold_seconds"`
+		BumpMinVolume            bool    `json:"bump_min_volume"`
+		OrderMode                string  `json:"order_mode"`
+		MakerRepriceTicks        int     `json:"maker_reprice_ticks"`
+		MakerTimeoutSeconds      int     `json:"maker_timeout_seconds"`
+		MakerCrossOnTimeout      bool    `json:"maker_cross_on_timeout"`
+		MakerTickSize            float64 `json:"maker_tick_size"`
+		MaxSlippageBps           float64 `json:"max_slippage_bps"`
+		ExecutionAlgo            string  `json:"execution_algo"`
+		TWAPJitter               float64 `json:"twap_jitter"`
+		TWAPDeadlineSeconds      int     `json:"twap_deadline_seconds"`
+		POVRate                  float64 `json:"pov_rate"`
+		POVMaxSeconds            int     `json:"pov_max_seconds"`
+
+		PaperBalances         map[string]float64 `json:"paper_balances"`
+		PaperSnapshotBalances bool               `json:"paper_snapshot_balances"`
+		EquitySnapshotSeconds int                `json:"equity_snapshot_seconds"`
+		SizingEquity          string             `json:"sizing_equity"`
+		RiskPerTradePct       float64            `json:"risk_per_trade_pct"`
+		VolTargetPct          float64            `json:"vol_target_pct"`
+		KellyMinTrades        int                `json:"kelly_min_trades"`
+		Strategy              string             `json:"strategy"`
+		Markets               []MarketConfig     `json:"markets"`
+		StrategyParams        json.RawMessage    `json:"strategy_params,omitempty"`
+		VoteMode              string             `json:"vote_mode"`
+		VoteMin               int                `json:"vote_min"`
+		VoteThreshold         float64            `json:"vote_threshold"`
+		VoteWeights           map[string]float64 `json:"vote_weights"`
+		VoteVeto              string             `json:"vote_veto"`
+		Timeframe             string             `json:"timeframe"`
+		FastTimeframe         string             `json:"fast_timeframe"`
+		SlowTimeframe         string             `json:"slow_timeframe"`
+		StrategyDepth         bool               `json:"strategy_depth"`
+		BaseHoldings          float64            `json:"base_holdings"`
 	}
 	r := raw{
 		Pair:                     cfg.Pair,
@@ -207,6 +609,60 @@ func (s *JSONStateStore) SaveConfig(cfg *Config) error {
 		VWAPOrderbookDepthLevels: cfg.VWAPOrderbookDepthLevels,
 		VWAPHybridWeight:         cfg.VWAPHybridWeight,
 		DBPath:                   cfg.DBPath,
+		EngineIntervalSeconds:    cfg.EngineIntervalSeconds,
+		FillModel:                cfg.FillModel,
+		FillSpreadBps:            cfg.FillSpreadBps,
+		MakerFee:                 cfg.MakerFee,
+		TakerFee:                 cfg.TakerFee,
+		FillLatencyMs:            cfg.FillLatencyMs,
+		FillParticipation:        cfg.FillParticipation,
+		FillLevelVolume:          cfg.FillLevelVolume,
+		FillLevelBps:             cfg.FillLevelBps,
+		MaxOrderNotional:         cfg.MaxOrderNotional,
+		MaxDailyVolume:           cfg.MaxDailyVolume,
+		PriceBandBps:             cfg.PriceBandBps,
+		MaxOpenOrders:            cfg.MaxOpenOrders,
+		MaxPairExposure:          cfg.MaxPairExposure,
+		MaxConsecutiveErrors:     cfg.MaxConsecutiveErrors,
+		StaleDataSeconds:         cfg.StaleDataSeconds,
+		StopLossPct:              cfg.StopLossPct,
+		TakeProfitPct:            cfg.TakeProfitPct,
+		TrailingStopPct:          cfg.TrailingStopPct,
+		ATRStopMultiplier:        cfg.ATRStopMultiplier,
+		ATRPeriod:                cfg.ATRPeriod,
+		MaxHoldSeconds:           cfg.MaxHoldSeconds,
+		BumpMinVolume:            cfg.BumpMinVolume,
+		OrderMode:                cfg.OrderMode,
+		MakerRepriceTicks:        cfg.MakerRepriceTicks,
+		MakerTimeoutSeconds:      cfg.MakerTimeoutSeconds,
+		MakerCrossOnTimeout:      cfg.MakerCrossOnTimeout,
+		MakerTickSize:            cfg.MakerTickSize,
+		MaxSlippageBps:           cfg.MaxSlippageBps,
+		ExecutionAlgo:            cfg.ExecutionAlgo,
+		TWAPJitter:               cfg.TWAPJitter,
+		TWAPDeadlineSeconds:      cfg.TWAPDeadlineSeconds,
+		POVRate:                  cfg.POVRate,
+		POVMaxSeconds:            cfg.POVMaxSeconds,
+		PaperBalances:            cfg.PaperBalances,
+		PaperSnapshotBalances:    cfg.PaperSnapshotBalances,
+		EquitySnapshotSeconds:    cfg.EquitySnapshotSeconds,
+		SizingEquity:             cfg.SizingEquity,
+		RiskPerTradePct:          cfg.RiskPerTradePct,
+		VolTargetPct:             cfg.VolTargetPct,
+		KellyMinTrades:           cfg.KellyMinTrades,
+		Strategy:                 cfg.Strategy,
+		Markets:                  cfg.Markets,
+		StrategyParams:           cfg.StrategyParams,
+		VoteMode:                 cfg.VoteMode,
+		VoteMin:                  cfg.VoteMin,
+		VoteThreshold:            cfg.VoteThreshold,
+		VoteWeights:              cfg.VoteWeights,
+		VoteVeto:                 cfg.VoteVeto,
+		Timeframe:                cfg.Timeframe,
+		FastTimeframe:            cfg.FastTimeframe,
+		SlowTimeframe:            cfg.SlowTimeframe,
+		StrategyDepth:            cfg.StrategyDepth,
+		BaseHoldings:             cfg.BaseHoldings,
 	}
 	data, err := json.MarshalIndent(r, "", "  ")
 	if err != nil {
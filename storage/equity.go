@@ -0,0 +1,69 @@
+package storage
+
+import (
+    "database/sql"
+    "errors"
+    "time"
+)
+
+// EquitySnapshot is a portfolio marked to market, in quote currency.
+type EquitySnapshot struct {
+    Time        time.Time `json:"time"`
+    Executor    string    `json:"executor"` // chain that was marked, e.g. "sim" or "live"
+    Pair        string    `json:"pair"`
+    Price       float64   `json:"price"`
+    Position    float64   `json:"position"`
+    EntryPrice  float64   `json:"entry_price"`
+    Realized    float64   `json:"realized_pnl"`
+    Unrealized  float64   `json:"unrealized_pnl"`
+    Equity      float64   `json:"equity"`
+    HighWater   float64   `json:"high_water"`
+    DrawdownPct float64   `json:"drawdown_pct"`
+}
+
+// SaveEquity inserts an equity snapshot.
+func (s *SQLiteStore) SaveEquity(e EquitySnapshot) error {
+    _, err := s.db.Exec(`INSERT INTO equity(timestamp, executor, pair, price, position, entry_price, realized, unrealized, equity, high_water, drawdown_pct) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+        e.Time.UnixMilli(), e.Executor, e.Pair, e.Price, e.Position, e.EntryPrice, e.Realized, e.Unrealized, e.Equity, e.HighWater, e.DrawdownPct)
+    return err
+}
+
+// ListEquity returns snapshots for executor, or for all executors when it
+// is empty, taken at or after since, ordered by time.
+func (s *SQLiteStore) ListEquity(executor string, since time.Time) ([]EquitySnapshot, error) {
+    rows, err := s.db.Query(`SELECT timestamp, executor, pair, price, position, entry_price, realized, unrealized, equity, high_water, drawdown_pct FROM equity
+        WHERE (? = '' OR executor = ?) AND timestamp >= ? ORDER BY timestamp`, executor, executor, since.UnixMilli())
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var snaps []EquitySnapshot
+    for rows.Next() {
+        var e EquitySnapshot
+        var ts int64
+        if err := rows.Scan(&ts, &e.Executor, &e.Pair, &e.Price, &e.Position, &e.EntryPrice, &e.Realized, &e.Unrealized, &e.Equity, &e.HighWater, &e.DrawdownPct); err != nil {
+            return nil, err
+        }
+        e.Time = time.UnixMilli(ts)
+        snaps = append(snaps, e)
+    }
+    return snaps, rows.Err()
+}
+
+// LatestEquity returns the most recent snapshot of executor on pair, with ok
+// false when none has been saved.
+func (s *SQLiteStore) LatestEquity(executor, pair string) (e EquitySnapshot, ok bool, err error) {
+    var ts int64
+    err = s.db.QueryRow(`SELECT timestamp, executor, pair, price, position, entry_price, realized, unrealized, equity, high_water, drawdown_pct FROM equity
+        WHERE executor = ? AND pair = ? ORDER BY timestamp DESC, rowid DESC LIMIT 1`, executor, pair).
+        Scan(&ts, &e.Executor, &e.Pair, &e.Price, &e.Position, &e.EntryPrice, &e.Realized, &e.Unrealized, &e.Equity, &e.HighWater, &e.DrawdownPct)
+    if errors.Is(err, sql.ErrNoRows) {
+        return EquitySnapshot{}, false, nil
+    }
+    if err != nil {
+        return EquitySnapshot{}, false, err
+    }
+    e.Time = time.UnixMilli(ts)
+    return e, true, nil
+}
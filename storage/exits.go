@@ -0,0 +1,44 @@
+package storage
+
+import (
+    "time"
+)
+
+// ExitRecord is a position closed by a stop, target or holding limit.
+type ExitRecord struct {
+    ID         int64     `json:"id"`
+    Time       time.Time `json:"time"`
+    Pair       string    `json:"pair"`
+    Reason     string    `json:"reason"`
+    EntryPrice float64   `json:"entry_price"`
+    Price      float64   `json:"price"`
+    Volume     float64   `json:"volume"`
+}
+
+// SaveExit inserts an exit record.
+func (s *SQLiteStore) SaveExit(e ExitRecord) error {
+    _, err := s.db.Exec(`INSERT INTO exits(timestamp, pair, reason, entry_price, price, volume) VALUES (?, ?, ?, ?, ?, ?)`,
+        e.Time.UnixMilli(), e.Pair, e.Reason, e.EntryPrice, e.Price, e.Volume)
+    return err
+}
+
+// ListExits returns all exit records ordered by time.
+func (s *SQLiteStore) ListExits() ([]ExitRecord, error) {
+    rows, err := s.db.Query(`SELECT id, timestamp, pair, reason, entry_price, price, volume FROM exits ORDER BY timestamp`)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var exits []ExitRecord
+    for rows.Next() {
+        var e ExitRecord
+        var ts int64
+        if err := rows.Scan(&e.ID, &ts, &e.Pair, &e.Reason, &e.EntryPrice, &e.Price, &e.Volume); err != nil {
+            return nil, err
+        }
+        e.Time = time.UnixMilli(ts)
+        exits = append(exits, e)
+    }
+    return exits, rows.Err()
+}
@@ -0,0 +1,123 @@
+package storage
+
+import (
+    "database/sql"
+    "fmt"
+    "time"
+)
+
+// JobRecord is a sliced execution schedule. Its ID is the trade it executes;
+// progress is kept per slice in the slices table.
+type JobRecord struct {
+    ID       int64         `json:"id"`
+    Executor string        `json:"executor"` // chain that runs the job, e.g. "sim" or "live"
+    Kind     string        `json:"kind"`     // "twap", "vwap" or "pov"
+    Pair     string        `json:"pair"`
+    Side     string        `json:"side"`
+    Price    float64       `json:"price"`
+    Volume   float64       `json:"volume"`
+    Status   string        `json:"status"`
+    Error    string        `json:"error,omitempty"`
+    Interval time.Duration `json:"interval"`
+    Params   string        `json:"-"` // executor-specific JSON needed to resume
+    Created  time.Time     `json:"created"`
+    Updated  time.Time     `json:"updated"`
+    Slices   []SliceRecord `json:"slices"`
+}
+
+// CreateJob saves the job's trade, header and pending slices sized by
+// weights, and returns the new job ID.
+func (s *SQLiteStore) CreateJob(j JobRecord, weights []float64) (int64, error) {
+    tx, err := s.db.Begin()
+    if err != nil {
+        return 0, err
+    }
+    defer tx.Rollback()
+    rs, err := tx.Exec(`INSERT INTO trades(timestamp, pair, side, price, volume) VALUES (?, ?, ?, ?, ?)`,
+        j.Created.Format(time.RFC3339Nano), j.Pair, j.Side, j.Price, j.Volume)
+    if err != nil {
+        return 0, err
+    }
+    id, err := rs.LastInsertId()
+    if err != nil {
+        return 0, err
+    }
+    _, err = tx.Exec(`INSERT INTO execution_jobs(trade_id, executor, kind, status, interval_ms, params, error, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+        id, j.Executor, j.Kind, j.Status, j.Interval.Milliseconds(), j.Params, j.Error, j.Created.UnixMilli(), j.Created.UnixMilli())
+    if err != nil {
+        return 0, err
+    }
+    for i, w := range weights {
+        _, err := tx.Exec(`INSERT INTO slices(trade_id, slice_index, size, weight, status) VALUES (?, ?, ?, ?, 'pending')`,
+            id, i, j.Volume*w, w)
+        if err != nil {
+            return 0, err
+        }
+    }
+    return id, tx.Commit()
+}
+
+// UpdateJob sets a job's status and error message.
+func (s *SQLiteStore) UpdateJob(id int64, status, errMsg string) error {
+    _, err := s.db.Exec(`UPDATE execution_jobs SET status = ?, error = ?, updated_at = ? WHERE trade_id = ?`,
+        status, errMsg, time.Now().UnixMilli(), id)
+    return err
+}
+
+// UpdateSlice records the outcome of one slice of a job.
+func (s *SQLiteStore) UpdateSlice(id int64, index int, status string, filled float64, at time.Time) error {
+    var executed int64
+    if !at.IsZero() {
+        executed = at.UnixMilli()
+    }
+    _, err := s.db.Exec(`UPDATE slices SET status = ?, filled = ?, executed_at = ? WHERE trade_id = ? AND slice_index = ?`,
+        status, filled, executed, id, index)
+    return err
+}
+
+// AddSlice appends an executed slice to a job whose slices are sized as it
+// runs, such as a percent-of-volume order.
+func (s *SQLiteStore) AddSlice(id int64, sl SliceRecord) error {
+    var executed int64
+    if !sl.ExecutedAt.IsZero() {
+        executed = sl.ExecutedAt.UnixMilli()
+    }
+    _, err := s.db.Exec(`INSERT INTO slices(trade_id, slice_index, size, weight, status, filled, executed_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+        id, sl.Index, sl.Size, sl.Weight, sl.Status, sl.Filled, executed)
+    return err
+}
+
+// ListJobs returns jobs run by executor, or all jobs when it is empty,
+// newest first with their slices.
+func (s *SQLiteStore) ListJobs(executor string) ([]JobRecord, error) {
+    rows, err := s.db.Query(`SELECT j.trade_id, j.executor, j.kind, t.pair, t.side, t.price, t.volume, j.status, j.error, j.interval_ms, j.params, j.created_at, j.updated_at
+        FROM execution_jobs j JOIN trades t ON t.id = j.trade_id
+        WHERE ? = '' OR j.executor = ? ORDER BY j.trade_id DESC`, executor, executor)
+    if err != nil {
+        return nil, err
+    }
+    var jobs []JobRecord
+    for rows.Next() {
+        var j JobRecord
+        var errMsg sql.NullString
+        var interval, created, updated int64
+        if err := rows.Scan(&j.ID, &j.Executor, &j.Kind, &j.Pair, &j.Side, &j.Price, &j.Volume, &j.Status, &errMsg, &interval, &j.Params, &created, &updated); err != nil {
+            rows.Close()
+            return nil, err
+        }
+        j.Error = errMsg.String
+        j.Interval = time.Duration(interval) * time.Millisecond
+        j.Created, j.Updated = time.UnixMilli(created), time.UnixMilli(updated)
+        jobs = append(jobs, j)
+    }
+    rows.Close()
+    if err := rows.Err(); err != nil {
+        return nil, err
+    }
+    for i := range jobs {
+        if jobs[i].Slices, err = s.ListSlices(jobs[i].ID); err != nil {
+            return nil, fmt.Errorf("list slices of job %d: %w", jobs[i].ID, err)
+        }
+    }
+    return jobs, nil
+}
@@ -0,0 +1,42 @@
+package storage
+
+import (
+    "database/sql"
+    "errors"
+    "time"
+)
+
+// KillSwitchState is the persisted trading halt.
+type KillSwitchState struct {
+    Halted    bool      `json:"halted"`
+    Reason    string    `json:"reason"`
+    TrippedAt time.Time `json:"tripped_at"`
+}
+
+// SaveKillSwitch persists the kill switch state, replacing any previous state.
+func (s *SQLiteStore) SaveKillSwitch(state KillSwitchState) error {
+    var tripped int64
+    if !state.TrippedAt.IsZero() {
+        tripped = state.TrippedAt.UnixMilli()
+    }
+    _, err := s.db.Exec(`INSERT OR REPLACE INTO kill_switch(id, halted, reason, tripped_at) VALUES (1, ?, ?, ?)`,
+        state.Halted, state.Reason, tripped)
+    return err
+}
+
+// LoadKillSwitch returns the persisted kill switch state, or a zero state if none was saved.
+func (s *SQLiteStore) LoadKillSwitch() (KillSwitchState, error) {
+    var state KillSwitchState
+    var tripped int64
+    err := s.db.QueryRow(`SELECT halted, reason, tripped_at FROM kill_switch WHERE id = 1`).Scan(&state.Halted, &state.Reason, &tripped)
+    if errors.Is(err, sql.ErrNoRows) {
+        return KillSwitchState{}, nil
+    }
+    if err != nil {
+        return KillSwitchState{}, err
+    }
+    if tripped != 0 {
+        state.TrippedAt = time.UnixMilli(tripped)
+    }
+    return state, nil
+}
@@ -0,0 +1,227 @@
+package storage
+
+import (
+    "database/sql"
+    "fmt"
+    "sort"
+    "time"
+
+    luno "github.com/luno/luno-go"
+    "github.com/luno/luno-go/decimal"
+)
+
+// Market data tables store timestamps as Unix milliseconds so ranges can be queried directly.
+
+// TimeRange is a half-open interval [From, To).
+type TimeRange struct {
+    From time.Time
+    To   time.Time
+}
+
+// SaveCandles upserts candles for pair at the given duration in seconds.
+func (s *SQLiteStore) SaveCandles(pair string, duration int64, candles []luno.Candle) error {
+    tx, err := s.db.Begin()
+    if err != nil {
+        return err
+    }
+    stmt, err := tx.Prepare(`INSERT OR REPLACE INTO candles(pair, duration, timestamp, open, high, low, close, volume) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`)
+    if err != nil {
+        tx.Rollback()
+        return err
+    }
+    defer stmt.Close()
+    for _, c := range candles {
+        if _, err := stmt.Exec(pair, duration, time.Time(c.Timestamp).UnixMilli(),
+            c.Open.Float64(), c.High.Float64(), c.Low.Float64(), c.Close.Float64(), c.Volume.Float64()); err != nil {
+            tx.Rollback()
+            return fmt.Errorf("save candle: %w", err)
+        }
+    }
+    return tx.Commit()
+}
+
+// Candles returns cached candles for pair and duration starting in [from, to), oldest first.
+func (s *SQLiteStore) Candles(pair string, duration int64, from, to time.Time) ([]luno.Candle, error) {
+    rows, err := s.db.Query(`SELECT timestamp, open, high, low, close, volume FROM candles
+        WHERE pair = ? AND duration = ? AND timestamp >= ? AND timestamp < ? ORDER BY timestamp`,
+        pair, duration, from.UnixMilli(), to.UnixMilli())
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var candles []luno.Candle
+    for rows.Next() {
+        var ts int64
+        var o, h, l, c, v float64
+        if err := rows.Scan(&ts, &o, &h, &l, &c, &v); err != nil {
+            return nil, err
+        }
+        candles = append(candles, luno.Candle{
+            Timestamp: luno.Time(time.UnixMilli(ts)),
+            Open:      decimal.NewFromFloat64(o, 8),
+            High:      decimal.NewFromFloat64(h, 8),
+            Low:       decimal.NewFromFloat64(l, 8),
+            Close:     decimal.NewFromFloat64(c, 8),
+            Volume:    decimal.NewFromFloat64(v, 8),
+        })
+    }
+    return candles, rows.Err()
+}
+
+// MarkCandlesCovered records that [from, to) has been fetched for pair and
+// duration, merging it with overlapping or adjacent coverage. Intervals with
+// no trades have no candles, so coverage rather than candle count decides gaps.
+func (s *SQLiteStore) MarkCandlesCovered(pair string, duration int64, from, to time.Time) error {
+    if !from.Before(to) {
+        return nil
+    }
+    covered, err := s.candleCoverage(pair, duration)
+    if err != nil {
+        return err
+    }
+    merged := mergeRanges(append(covered, TimeRange{From: from, To: to}))
+
+    tx, err := s.db.Begin()
+    if err != nil {
+        return err
+    }
+    if _, err := tx.Exec(`DELETE FROM candle_coverage WHERE pair = ? AND duration = ?`, pair, duration); err != nil {
+        tx.Rollback()
+        return err
+    }
+    for _, r := range merged {
+        if _, err := tx.Exec(`INSERT INTO candle_coverage(pair, duration, start_ms, end_ms) VALUES (?, ?, ?, ?)`,
+            pair, duration, r.From.UnixMilli(), r.To.UnixMilli()); err != nil {
+            tx.Rollback()
+            return err
+        }
+    }
+    return tx.Commit()
+}
+
+// CandleGaps returns the parts of [from, to) not yet fetched for pair and duration.
+func (s *SQLiteStore) CandleGaps(pair string, duration int64, from, to time.Time) ([]TimeRange, error) {
+    covered, err := s.candleCoverage(pair, duration)
+    if err != nil {
+        return nil, err
+    }
+    return subtractRanges(TimeRange{From: from, To: to}, covered), nil
+}
+
+// candleCoverage returns fetched ranges for pair and duration, oldest first.
+func (s *SQLiteStore) candleCoverage(pair string, duration int64) ([]TimeRange, error) {
+    rows, err := s.db.Query(`SELECT start_ms, end_ms FROM candle_coverage WHERE pair = ? AND duration = ? ORDER BY start_ms`, pair, duration)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var ranges []TimeRange
+    for rows.Next() {
+        var start, end int64
+        if err := rows.Scan(&start, &end); err != nil {
+            return nil, err
+        }
+        ranges = append(ranges, TimeRange{From: time.UnixMilli(start), To: time.UnixMilli(end)})
+    }
+    return ranges, rows.Err()
+}
+
+// SavePublicTrades stores market trades for pair, ignoring sequences already cached.
+func (s *SQLiteStore) SavePublicTrades(pair string, trades []luno.PublicTrade) error {
+    tx, err := s.db.Begin()
+    if err != nil {
+        return err
+    }
+    stmt, err := tx.Prepare(`INSERT OR IGNORE INTO public_trades(pair, sequence, timestamp, price, volume, is_buy) VALUES (?, ?, ?, ?, ?, ?)`)
+    if err != nil {
+        tx.Rollback()
+        return err
+    }
+    defer stmt.Close()
+    for _, t := range trades {
+        if _, err := stmt.Exec(pair, t.Sequence, time.Time(t.Timestamp).UnixMilli(),
+            t.Price.Float64(), t.Volume.Float64(), t.IsBuy); err != nil {
+            tx.Rollback()
+            return fmt.Errorf("save public trade: %w", err)
+        }
+    }
+    return tx.Commit()
+}
+
+// PublicTrades returns cached market trades for pair executed in [from, to), oldest first.
+func (s *SQLiteStore) PublicTrades(pair string, from, to time.Time) ([]luno.PublicTrade, error) {
+    rows, err := s.db.Query(`SELECT sequence, timestamp, price, volume, is_buy FROM public_trades
+        WHERE pair = ? AND timestamp >= ? AND timestamp < ? ORDER BY sequence`,
+        pair, from.UnixMilli(), to.UnixMilli())
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var trades []luno.PublicTrade
+    for rows.Next() {
+        var t luno.PublicTrade
+        var ts int64
+        var price, volume float64
+        if err := rows.Scan(&t.Sequence, &ts, &price, &volume, &t.IsBuy); err != nil {
+            return nil, err
+        }
+        t.Timestamp = luno.Time(time.UnixMilli(ts))
+        t.Price = decimal.NewFromFloat64(price, 8)
+        t.Volume = decimal.NewFromFloat64(volume, 8)
+        trades = append(trades, t)
+    }
+    return trades, rows.Err()
+}
+
+// LatestPublicTrade returns the time of the newest cached trade for pair.
+func (s *SQLiteStore) LatestPublicTrade(pair string) (time.Time, bool, error) {
+    var ts sql.NullInt64
+    if err := s.db.QueryRow(`SELECT MAX(timestamp) FROM public_trades WHERE pair = ?`, pair).Scan(&ts); err != nil {
+        return time.Time{}, false, err
+    }
+    if !ts.Valid {
+        return time.Time{}, false, nil
+    }
+    return time.UnixMilli(ts.Int64), true, nil
+}
+
+// mergeRanges sorts ranges and joins those that overlap or touch.
+func mergeRanges(ranges []TimeRange) []TimeRange {
+    sort.Slice(ranges, func(i, j int) bool { return ranges[i].From.Before(ranges[j].From) })
+    var merged []TimeRange
+    for _, r := range ranges {
+        if n := len(merged); n > 0 && !r.From.After(merged[n-1].To) {
+            if r.To.After(merged[n-1].To) {
+                merged[n-1].To = r.To
+            }
+            continue
+        }
+        merged = append(merged, r)
+    }
+    return merged
+}
+
+// subtractRanges returns the parts of want not covered by the sorted, merged covered ranges.
+func subtractRanges(want TimeRange, covered []TimeRange) []TimeRange {
+    var gaps []TimeRange
+    cursor := want.From
+    for _, c := range covered {
+        if !c.To.After(cursor) {
+            continue
+        }
+        if !c.From.Before(want.To) {
+            break
+        }
+        if c.From.After(cursor) {
+            gaps = append(gaps, TimeRange{From: cursor, To: c.From})
+        }
+        cursor = c.To
+    }
+    if cursor.Before(want.To) {
+        gaps = append(gaps, TimeRange{From: cursor, To: want.To})
+    }
+    return gaps
+}
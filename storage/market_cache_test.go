@@ -0,0 +1,105 @@
+package storage
+
+import (
+    "path/filepath"
+    "testing"
+    "time"
+)
+
+var rangeBase = time.UnixMilli(1700000000000)
+
+// span returns the range [from, to) in minutes after rangeBase.
+func span(from, to int) TimeRange {
+    return TimeRange{From: rangeBase.Add(time.Duration(from) * time.Minute), To: rangeBase.Add(time.Duration(to) * time.Minute)}
+}
+
+// sameRanges reports whether got and want hold the same instants.
+func sameRanges(got, want []TimeRange) bool {
+    if len(got) != len(want) {
+        return false
+    }
+    for i := range got {
+        if !got[i].From.Equal(want[i].From) || !got[i].To.Equal(want[i].To) {
+            return false
+        }
+    }
+    return true
+}
+
+func TestMergeRanges(t *testing.T) {
+    tests := []struct {
+        name   string
+        ranges []TimeRange
+        want   []TimeRange
+    }{
+        {"empty", nil, nil},
+        {"disjoint are sorted", []TimeRange{span(20, 30), span(0, 10)}, []TimeRange{span(0, 10), span(20, 30)}},
+        {"overlapping", []TimeRange{span(0, 10), span(5, 15)}, []TimeRange{span(0, 15)}},
+        {"touching", []TimeRange{span(10, 20), span(0, 10)}, []TimeRange{span(0, 20)}},
+        {"contained", []TimeRange{span(0, 30), span(10, 20)}, []TimeRange{span(0, 30)}},
+        {"chain", []TimeRange{span(0, 10), span(25, 40), span(8, 26), span(50, 60)}, []TimeRange{span(0, 40), span(50, 60)}},
+    }
+    for _, tt := range tests {
+        if got := mergeRanges(tt.ranges); !sameRanges(got, tt.want) {
+            t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+        }
+    }
+}
+
+func TestSubtractRanges(t *testing.T) {
+    want := span(10, 50)
+    tests := []struct {
+        name    string
+        covered []TimeRange
+        gaps    []TimeRange
+    }{
+        {"nothing covered", nil, []TimeRange{span(10, 50)}},
+        {"fully covered", []TimeRange{span(0, 60)}, nil},
+        {"exactly covered", []TimeRange{span(10, 50)}, nil},
+        {"covered middle", []TimeRange{span(20, 30)}, []TimeRange{span(10, 20), span(30, 50)}},
+        {"overlaps start", []TimeRange{span(0, 20)}, []TimeRange{span(20, 50)}},
+        {"overlaps end", []TimeRange{span(40, 60)}, []TimeRange{span(10, 40)}},
+        {"outside", []TimeRange{span(0, 10), span(50, 60)}, []TimeRange{span(10, 50)}},
+        {"several", []TimeRange{span(0, 15), span(20, 25), span(45, 70)}, []TimeRange{span(15, 20), span(25, 45)}},
+    }
+    for _, tt := range tests {
+        if got := subtractRanges(want, tt.covered); !sameRanges(got, tt.gaps) {
+            t.Errorf("%s: got %v, want %v", tt.name, got, tt.gaps)
+        }
+    }
+}
+
+func TestCandleGaps(t *testing.T) {
+    s, err := NewSQLiteStore(filepath.Join(t.TempDir(), "candles.db"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer s.Close()
+
+    for _, r := range []TimeRange{span(0, 10), span(30, 40), span(8, 20), span(25, 25)} {
+        if err := s.MarkCandlesCovered("XBTZAR", 60, r.From, r.To); err != nil {
+            t.Fatal(err)
+        }
+    }
+    tests := []struct {
+        name     string
+        pair     string
+        duration int64
+        want     TimeRange
+        gaps     []TimeRange
+    }{
+        {"merged coverage", "XBTZAR", 60, span(0, 50), []TimeRange{span(20, 30), span(40, 50)}},
+        {"inside coverage", "XBTZAR", 60, span(2, 18), nil},
+        {"other duration", "XBTZAR", 300, span(0, 50), []TimeRange{span(0, 50)}},
+        {"other pair", "ETHZAR", 60, span(0, 50), []TimeRange{span(0, 50)}},
+    }
+    for _, tt := range tests {
+        gaps, err := s.CandleGaps(tt.pair, tt.duration, tt.want.From, tt.want.To)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if !sameRanges(gaps, tt.gaps) {
+            t.Errorf("%s: got %v, want %v", tt.name, gaps, tt.gaps)
+        }
+    }
+}
@@ -0,0 +1,48 @@
+package storage
+
+import (
+    "time"
+)
+
+// RoundTrip is a position on one executor chain from opening to flat again,
+// with the profit its fills realized.
+type RoundTrip struct {
+    ID         int64     `json:"id"`
+    Executor   string    `json:"executor"`
+    Pair       string    `json:"pair"`
+    Opened     time.Time `json:"opened"`
+    Closed     time.Time `json:"closed"`
+    EntryPrice float64   `json:"entry_price"`
+    ExitPrice  float64   `json:"exit_price"`
+    Volume     float64   `json:"volume"` // largest position held
+    PnL        float64   `json:"pnl"`
+}
+
+// SaveRoundTrip inserts a closed round trip.
+func (s *SQLiteStore) SaveRoundTrip(t RoundTrip) error {
+    _, err := s.db.Exec(`INSERT INTO round_trips(executor, pair, opened_at, closed_at, entry_price, exit_price, volume, pnl) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+        t.Executor, t.Pair, t.Opened.UnixMilli(), t.Closed.UnixMilli(), t.EntryPrice, t.ExitPrice, t.Volume, t.PnL)
+    return err
+}
+
+// ListRoundTrips returns executor's round trips on pair, oldest first.
+func (s *SQLiteStore) ListRoundTrips(executor, pair string) ([]RoundTrip, error) {
+    rows, err := s.db.Query(`SELECT id, executor, pair, opened_at, closed_at, entry_price, exit_price, volume, pnl FROM round_trips
+        WHERE executor = ? AND pair = ? ORDER BY closed_at`, executor, pair)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var trips []RoundTrip
+    for rows.Next() {
+        var t RoundTrip
+        var opened, closed int64
+        if err := rows.Scan(&t.ID, &t.Executor, &t.Pair, &opened, &closed, &t.EntryPrice, &t.ExitPrice, &t.Volume, &t.PnL); err != nil {
+            return nil, err
+        }
+        t.Opened, t.Closed = time.UnixMilli(opened), time.UnixMilli(closed)
+        trips = append(trips, t)
+    }
+    return trips, rows.Err()
+}
@@ -78,18 +78,22 @@ func (s *SQLiteStore) ListTrades() ([]Trade, error) {
     return trades, nil
 }
 
-// SliceRecord represents a persisted slice record.
+// SliceRecord represents a persisted slice record. Slices of an execution
+// job start pending and record what was filled once they run.
 type SliceRecord struct {
-    ID      int64
-    TradeID int64
-    Index   int
-    Size    float64
-    Weight  float64
+    ID         int64     `json:"id"`
+    TradeID    int64     `json:"trade_id"`
+    Index      int       `json:"index"`
+    Size       float64   `json:"size"`
+    Weight     float64   `json:"weight"`
+    Status     string    `json:"status"`
+    Filled     float64   `json:"filled"`
+    ExecutedAt time.Time `json:"executed_at"`
 }
 
 // ListSlices returns all slices for a given trade ID ordered by slice index.
 func (s *SQLiteStore) ListSlices(tradeID int64) ([]SliceRecord, error) {
-    rows, err := s.db.Query(`SELECT id, trade_id, slice_index, size, weight FROM slices WHERE trade_id = ? ORDER BY slice_index`, tradeID)
+    rows, err := s.db.Query(`SELECT id, trade_id, slice_index, size, weight, status, filled, executed_at FROM slices WHERE trade_id = ? ORDER BY slice_index`, tradeID)
     if err != nil {
         return nil, err
     }
@@ -98,15 +102,20 @@ func (s *SQLiteStore) ListSlices(tradeID int64) ([]SliceRecord, error) {
     var slices []SliceRecord
     for rows.Next() {
         var sr SliceRecord
-        if err := rows.Scan(&sr.ID, &sr.TradeID, &sr.Index, &sr.Size, &sr.Weight); err != nil {
+        var executed int64
+        if err := rows.Scan(&sr.ID, &sr.TradeID, &sr.Index, &sr.Size, &sr.Weight, &sr.Status, &sr.Filled, &executed); err != nil {
             return nil, err
         }
+        if executed != 0 {
+            sr.ExecutedAt = time.UnixMilli(executed)
+        }
         slices = append(slices, sr)
     }
     return slices, nil
 }
 
-// runMigrations creates the trades and slices tables if they do not exist.
+// runMigrations creates the trades, slices, execution job, market data cache,
+// kill switch, exit, equity and round trip tables if they do not exist.
 func runMigrations(db *sql.DB) error {
     _, err := db.Exec(`CREATE TABLE IF NOT EXISTS trades (
         id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -127,5 +136,129 @@ func runMigrations(db *sql.DB) error {
         weight REAL,
         FOREIGN KEY(trade_id) REFERENCES trades(id)
     );`)
+    if err != nil {
+        return err
+    }
+    // slices written before execution jobs existed ran straight away
+    for _, col := range []struct{ name, decl string }{
+        {"status", "TEXT NOT NULL DEFAULT 'done'"},
+        {"filled", "REAL NOT NULL DEFAULT 0"},
+        {"executed_at", "INTEGER NOT NULL DEFAULT 0"},
+    } {
+        if err := addColumn(db, "slices", col.name, col.decl); err != nil {
+            return err
+        }
+    }
+    _, err = db.Exec(`CREATE TABLE IF NOT EXISTS execution_jobs (
+        trade_id INTEGER PRIMARY KEY,
+        executor TEXT,
+        kind TEXT,
+        status TEXT,
+        interval_ms INTEGER,
+        params TEXT,
+        error TEXT,
+        created_at INTEGER,
+        updated_at INTEGER,
+        FOREIGN KEY(trade_id) REFERENCES trades(id)
+    );`)
+    if err != nil {
+        return err
+    }
+    _, err = db.Exec(`CREATE TABLE IF NOT EXISTS candles (
+        pair TEXT,
+        duration INTEGER,
+        timestamp INTEGER,
+        open REAL,
+        high REAL,
+        low REAL,
+        close REAL,
+        volume REAL,
+        PRIMARY KEY(pair, duration, timestamp)
+    );`)
+    if err != nil {
+        return err
+    }
+    _, err = db.Exec(`CREATE TABLE IF NOT EXISTS candle_coverage (
+        pair TEXT,
+        duration INTEGER,
+        start_ms INTEGER,
+        end_ms INTEGER
+    );`)
+    if err != nil {
+        return err
+    }
+    _, err = db.Exec(`CREATE TABLE IF NOT EXISTS public_trades (
+        pair TEXT,
+        sequence INTEGER,
+        timestamp INTEGER,
+        price REAL,
+        volume REAL,
+        is_buy BOOLEAN,
+        PRIMARY KEY(pair, sequence)
+    );`)
+    if err != nil {
+        return err
+    }
+    _, err = db.Exec(`CREATE TABLE IF NOT EXISTS kill_switch (
+        id INTEGER PRIMARY KEY CHECK (id = 1),
+        halted BOOLEAN,
+        reason TEXT,
+        tripped_at INTEGER
+    );`)
+    if err != nil {
+        return err
+    }
+    _, err = db.Exec(`CREATE TABLE IF NOT EXISTS exits (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        timestamp INTEGER,
+        pair TEXT,
+        reason TEXT,
+        entry_price REAL,
+        price REAL,
+        volume REAL
+    );`)
+    if err != nil {
+        return err
+    }
+    _, err = db.Exec(`CREATE TABLE IF NOT EXISTS equity (
+        timestamp INTEGER,
+        executor TEXT,
+        pair TEXT,
+        price REAL,
+        position REAL,
+        entry_price REAL DEFAULT 0,
+        realized REAL,
+        unrealized REAL,
+        equity REAL,
+        high_water REAL,
+        drawdown_pct REAL
+    );`)
+    if err != nil {
+        return err
+    }
+    _, err = db.Exec(`CREATE TABLE IF NOT EXISTS round_trips (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        executor TEXT,
+        pair TEXT,
+        opened_at INTEGER,
+        closed_at INTEGER,
+        entry_price REAL,
+        exit_price REAL,
+        volume REAL,
+        pnl REAL
+    );`)
+    return err
+}
+
+// addColumn adds a column to table unless it already exists.
+func addColumn(db *sql.DB, table, column, decl string) error {
+    var n int
+    if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, table, column).Scan(&n); err != nil {
+        return err
+    }
+    if n > 0 {
+        return nil
+    }
+    _, err := db.Exec(`ALTER TABLE ` + table + ` ADD COLUMN ` + column + ` ` + decl)
     return err
 }